@@ -15,14 +15,29 @@ import (
 	"github.com/example/solapi/internal/config"
 	apihttp "github.com/example/solapi/internal/http"
 	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/pricing"
 	"github.com/example/solapi/internal/rate"
 	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/watchlist"
+	"github.com/example/solapi/internal/webhook"
+	"github.com/example/solapi/pkg/redact"
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func main() {
 	cfg := config.Load()
+	// upstream URLs carry API keys; keep them out of every log line
+	log.SetOutput(redact.NewWriter(os.Stderr))
+	redact.AddURL(cfg.HeliusURL)
+	for _, ep := range cfg.RPCEndpoints {
+		redact.AddURL(ep.URL)
+	}
+	redact.AddURL(cfg.RPCWebSocketURL)
+	redact.AddURL(cfg.MongoURI)
+	redact.AddURL(cfg.PriceFeedURL)
 	if cfg.HeliusURL == "" {
 		log.Println("warning: HELIUS_RPC_URL is empty; server will panic on first RPC call")
 	}
@@ -45,17 +60,197 @@ func main() {
 
 	// deps
 	cl := solana.NewClient(cfg.HeliusURL, cfg.SolCommitment)
-	c := cache.New(cfg.CacheTTL)
-	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{
+	var upstream solana.BatchBalanceFetcher = cl
+	var health []apihttp.Option
+	if len(cfg.RPCEndpoints) > 0 {
+		// balances fail over across providers; other lookups stay on HeliusURL
+		pool := solana.NewPool(poolEndpoints(cfg.RPCEndpoints), cfg.SolCommitment, solana.PoolOptions{
+			HedgePercentile: cfg.HedgePercentile,
+			HedgeBudget:     cfg.HedgeBudget,
+		})
+		upstream = pool
+		health = append(health, apihttp.WithHealth("rpc_pool", func() any { return pool.Health() }))
+	}
+	var fetcher solana.BalanceFetcher = upstream
+	if cfg.BatchWindow > 0 {
+		// coalesce misses across requests into getMultipleAccounts calls
+		fetcher = solana.NewBatcher(upstream, cfg.BatchWindow, cfg.BatchMaxKeys, cfg.BalanceTimeout)
+	}
+	if cfg.RetryAttempts > 1 {
+		fetcher = solana.WithRetry(fetcher, solana.RetryOptions{
+			MaxAttempts: cfg.RetryAttempts,
+			BaseDelay:   cfg.RetryBaseDelay,
+			MaxDelay:    cfg.RetryMaxDelay,
+		})
+	}
+	if cfg.BreakerFailures > 0 {
+		// fail fast instead of every wallet waiting out BalanceTimeout
+		br := solana.NewBreaker(solana.BreakerOptions{
+			FailureThreshold: cfg.BreakerFailures,
+			OpenTimeout:      cfg.BreakerOpenTimeout,
+			HalfOpenProbes:   cfg.BreakerProbes,
+		})
+		fetcher = br.Wrap(fetcher)
+		health = append(health, apihttp.WithHealth("rpc_breaker", func() any { return br.Status() }))
+	}
+	c := cache.New(cfg.CacheTTL).WithMissingTTL(cfg.MissingCacheTTL).WithMaxEntries(cfg.CacheMaxEntries)
+	runCtx, stopRun := context.WithCancel(context.Background())
+	defer stopRun()
+	// bh is assigned below; the subscriber only calls back once running
+	var bh *handlers.BalanceHandler
+	var watcher handlers.Watcher
+	var sub *solana.Subscriber
+	if cfg.RPCWebSocketURL != "" {
+		// watched wallets are kept warm by accountSubscribe pushes
+		sub = solana.NewSubscriber(cfg.RPCWebSocketURL, rpc.CommitmentType(cfg.SolCommitment), func(pk sol.PublicKey, cm rpc.CommitmentType, bal solana.Balance) {
+			bh.Update(pk, cm, bal)
+		}, solana.SubscriberOptions{})
+		watcher = sub
+		health = append(health, apihttp.WithHealth("rpc_ws", func() any { return sub.Status() }))
+	}
+	hub := handlers.NewHub(watcher)
+	var prices *pricing.Pricer
+	var sources pricing.Chain
+	if len(cfg.PythFeeds) > 0 {
+		feeds := make(map[string]sol.PublicKey, len(cfg.PythFeeds))
+		for pair, account := range cfg.PythFeeds {
+			pk, err := sol.PublicKeyFromBase58(account)
+			if err != nil {
+				log.Fatalf("invalid pyth price account for %s: %v", pair, err)
+			}
+			feeds[pair] = pk
+		}
+		sources = append(sources, pricing.NewPythSource(cl, feeds))
+	}
+	if cfg.PriceFeedURL != "" {
+		sources = append(sources, pricing.NewHTTPSource(cfg.PriceFeedURL, cfg.BalanceTimeout))
+	}
+	if len(sources) > 0 {
+		prices = pricing.NewPricer(sources, cfg.PriceCacheTTL, cfg.PriceMaxAge).WithMissingTTL(cfg.PriceMissingTTL)
+	}
+	bh = handlers.NewBalanceHandler(handlers.BalanceDeps{
+		Cache:          c,
+		Fetcher:        fetcher,
+		Timeout:        cfg.BalanceTimeout,
+		MaxConcurrency: cfg.MaxConcurrency,
+		Commitment:     rpc.CommitmentType(cfg.SolCommitment),
+		ServeStale:     cfg.ServeStale,
+		Stake:          cl,
+		StakeTTL:       cfg.StakeCacheTTL,
+		LiveTTL:        cfg.LiveCacheTTL,
+		Hub:            hub,
+		Prices:         prices,
+	})
+	if sub != nil {
+		go func() { _ = sub.Run(runCtx) }()
+	}
+	sh := handlers.NewStreamHandler(handlers.StreamDeps{
+		Balances:  bh,
+		Hub:       hub,
+		Heartbeat: cfg.StreamHeartbeat,
+		// without pushes, streams re-read about as often as the cache turns over
+		Poll:      cfg.CacheTTL,
+		MaxPerKey: cfg.StreamsPerKey,
+	})
+	streamTokens := auth.NewStreamTokens(cfg.StreamTokenTTL)
+	wsh := handlers.NewSocketHandler(handlers.SocketDeps{
+		Balances:          bh,
+		Hub:               hub,
+		MaxConnsPerKey:    cfg.StreamsPerKey,
+		MaxSubsPerKey:     cfg.SocketSubsPerKey,
+		MessagesPerMinute: cfg.SocketMsgsPerMin,
+		Poll:              cfg.CacheTTL,
+	})
+	hooks, err := webhook.NewMongoStore(ctx, mongoClient, cfg.MongoDB)
+	if err != nil {
+		log.Fatalf("webhook store init error: %v", err)
+	}
+	whh := handlers.NewWebhookHandler(handlers.WebhookDeps{
+		Store:     hooks,
+		Outbox:    hooks,
+		Balances:  bh,
+		Hub:       hub,
+		Poll:      cfg.CacheTTL,
+		MaxPerKey: cfg.WebhooksPerKey,
+	})
+	if cfg.WebhookWatcher {
+		go whh.Run(runCtx)
+	} else {
+		log.Println("webhook watcher disabled; set WEBHOOK_WATCHER=true on one instance to enqueue deliveries")
+	}
+	go webhook.NewSender(hooks, hooks, webhook.SenderOptions{
+		MaxAttempts: cfg.WebhookAttempts,
+		Timeout:     cfg.WebhookTimeout,
+	}).Run(runCtx)
+	lists, err := watchlist.NewMongoStore(ctx, mongoClient, cfg.MongoDB)
+	if err != nil {
+		log.Fatalf("watchlist store init error: %v", err)
+	}
+	wlh := handlers.NewWatchlistHandler(handlers.WatchlistDeps{
+		Store:      lists,
+		Balances:   bh,
+		MaxPerKey:  cfg.WatchlistsPerKey,
+		MaxWallets: cfg.WatchlistWallets,
+	})
+	th := handlers.NewTokenBalanceHandler(handlers.TokenDeps{
+		Cache:          c,
+		Fetcher:        cl,
+		TTL:            cfg.TokenCacheTTL,
+		Timeout:        cfg.BalanceTimeout,
+		MaxConcurrency: cfg.MaxConcurrency,
+		Prices:         prices,
+	})
+	aih := handlers.NewAccountInfoHandler(handlers.AccountInfoDeps{
+		Cache:          c,
+		Fetcher:        cl,
+		TTL:            cfg.CacheTTL,
+		Timeout:        cfg.BalanceTimeout,
+		MaxConcurrency: cfg.MaxConcurrency,
+		Commitment:     rpc.CommitmentType(cfg.SolCommitment),
+	})
+	atah := handlers.NewAssociatedTokenHandler(aih)
+	pdah := handlers.NewProgramAddressHandler()
+	hh := handlers.NewHistoryHandler(handlers.HistoryDeps{
+		Cache:          c,
+		Fetcher:        cl,
+		TTL:            cfg.CacheTTL,
+		Timeout:        cfg.BalanceTimeout,
+		MaxConcurrency: cfg.MaxConcurrency,
+	})
+	txh := handlers.NewTransactionsHandler(handlers.TransactionDeps{
 		Cache:          c,
 		Fetcher:        cl,
+		TTL:            cfg.CacheTTL,
 		Timeout:        cfg.BalanceTimeout,
 		MaxConcurrency: cfg.MaxConcurrency,
 	})
+	txdh := handlers.NewTransactionDetailHandler(handlers.TransactionDetailDeps{
+		// whole transactions are large; keep them from crowding out balances
+		Cache:   cache.New(cfg.CacheTTL).WithMaxEntries(cfg.TxCacheEntries),
+		Fetcher: cl,
+		TTL:     cfg.CacheTTL,
+		Timeout: cfg.BalanceTimeout,
+	})
 	lm := rate.NewLimiterMap(cfg.RateLimitRPM, cfg.RateLimitRPM, 5*time.Minute)
 	defer lm.Stop()
 
-	router := apihttp.NewRouter(bh, lm, store)
+	opts := append([]apihttp.Option{
+		apihttp.WithRoute("/api/get-token-balances", th),
+		apihttp.WithRoute("/api/account-info", aih),
+		apihttp.WithRoute("/api/associated-token-account", atah),
+		apihttp.WithRoute("/api/find-program-address", pdah),
+		apihttp.WithRoute("/api/get-historical-balance", hh),
+		apihttp.WithRoute("/api/transactions", txh),
+		apihttp.WithRoute("/api/transactions/", txdh),
+		apihttp.WithStreamRoute("/api/stream/balances", sh, streamTokens),
+		apihttp.WithRoute("/api/stream/token", handlers.NewStreamTokenHandler(streamTokens)),
+		apihttp.WithRoute("/api/ws", wsh),
+		apihttp.WithRoute("/api/webhooks", whh),
+		apihttp.WithRoute("/api/webhooks/", whh),
+		apihttp.WithRoute("/api/watchlists", wlh),
+		apihttp.WithRoute("/api/watchlists/", wlh),
+	}, health...)
+	router := apihttp.NewRouter(bh, lm, store, opts...)
 
 	// Mount extra endpoints on a parent mux without changing router signature.
 	mux := http.NewServeMux()
@@ -95,6 +290,11 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// streams never go idle and sockets are hijacked; end both so Shutdown
+	// can complete
+	srv.RegisterOnShutdown(sh.Close)
+	srv.RegisterOnShutdown(wsh.Close)
+
 	go func() {
 		log.Printf("listening on :%s", cfg.Port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -111,3 +311,11 @@ func main() {
 	defer shCancel()
 	_ = srv.Shutdown(shCtx)
 }
+
+func poolEndpoints(in []config.RPCEndpoint) []solana.Endpoint {
+	out := make([]solana.Endpoint, len(in))
+	for i, ep := range in {
+		out[i] = solana.Endpoint{URL: ep.URL, Priority: ep.Priority, Weight: ep.Weight}
+	}
+	return out
+}
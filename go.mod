@@ -4,6 +4,8 @@ go 1.24.0
 
 require (
 	github.com/gagliardetto/solana-go v1.8.1
+	github.com/gorilla/websocket v1.5.3
+	github.com/mr-tron/base58 v1.2.0
 	go.mongodb.org/mongo-driver v1.16.0
 	golang.org/x/sync v0.7.0
 	golang.org/x/time v0.3.0
@@ -32,7 +34,6 @@ require (
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/montanaflynn/stats v0.7.1 // indirect
 	github.com/mostynb/zstdpool-freelist v0.0.0-20201229113212-927304c0c3b1 // indirect
-	github.com/mr-tron/base58 v1.2.0 // indirect
 	github.com/streamingfast/logging v0.0.0-20220405224725-2755dab2ce75 // indirect
 	github.com/teris-io/shortid v0.0.0-20201117134242-e59966efd125 // indirect
 	github.com/tidwall/gjson v1.9.3 // indirect
@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+type ctxKey struct{}
+
+type identity struct{ id, owner string }
+
+// WithKey returns ctx carrying the identity of the caller's API key.
+func WithKey(ctx context.Context, key string) context.Context {
+	sum := sha256.Sum256([]byte(key))
+	return context.WithValue(ctx, ctxKey{}, identity{id: HashPrefix(key), owner: hex.EncodeToString(sum[:])})
+}
+
+// KeyID returns the short key identity, its HashPrefix, for logging and
+// per-key limits; "" when unauthenticated.
+func KeyID(ctx context.Context) string {
+	v, _ := ctx.Value(ctxKey{}).(identity)
+	return v.id
+}
+
+// Owner returns the full SHA-256 of the caller's API key, for records owned
+// by a key where HashPrefix collisions would matter; "" when unauthenticated.
+func Owner(ctx context.Context) string {
+	v, _ := ctx.Value(ctxKey{}).(identity)
+	return v.owner
+}
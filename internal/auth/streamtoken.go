@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// StreamTokens issues short-lived tokens that stand in for an API key when
+// opening event streams, since EventSource cannot set headers and the key
+// itself must never appear in a URL. Tokens live in memory; the key behind
+// a token is still validated against the key store on every use.
+type StreamTokens struct {
+	ttl time.Duration
+
+	mu     sync.Mutex
+	tokens map[string]streamToken
+}
+
+type streamToken struct {
+	key     string
+	expires time.Time
+}
+
+func NewStreamTokens(ttl time.Duration) *StreamTokens {
+	return &StreamTokens{ttl: ttl, tokens: make(map[string]streamToken)}
+}
+
+// Issue returns a new token for key and when it expires.
+func (t *StreamTokens) Issue(key string) (string, time.Time) {
+	var b [32]byte
+	_, _ = rand.Read(b[:])
+	token := hex.EncodeToString(b[:])
+	now := time.Now()
+	expires := now.Add(t.ttl)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for tok, st := range t.tokens {
+		if !now.Before(st.expires) {
+			delete(t.tokens, tok)
+		}
+	}
+	t.tokens[token] = streamToken{key: key, expires: expires}
+	return token, expires
+}
+
+// Key returns the API key token was issued for, if it has not expired.
+func (t *StreamTokens) Key(token string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st, ok := t.tokens[token]
+	if !ok || !time.Now().Before(st.expires) {
+		return "", false
+	}
+	return st.key, true
+}
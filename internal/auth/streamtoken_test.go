@@ -0,0 +1,21 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStreamTokens_ResolveUntilExpiry(t *testing.T) {
+	st := NewStreamTokens(time.Minute)
+	tok, exp := st.Issue("key-1")
+	if len(tok) != 64 || time.Until(exp) <= 0 { t.Fatalf("token=%q expires=%v", tok, exp) }
+	if key, ok := st.Key(tok); !ok || key != "key-1" { t.Fatalf("key=%q ok=%v", key, ok) }
+	if _, ok := st.Key("key-1"); ok { t.Fatalf("API key accepted as a token") }
+
+	short := NewStreamTokens(time.Millisecond)
+	tok, _ = short.Issue("key-1")
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := short.Key(tok); ok { t.Fatalf("expired token accepted") }
+	short.Issue("key-2")
+	if len(short.tokens) != 1 { t.Fatalf("expired tokens not pruned: %d", len(short.tokens)) }
+}
@@ -1,7 +1,9 @@
 package cache
 
 import (
+	"container/list"
 	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,38 +14,110 @@ import (
 type Value struct {
 	Lamports  uint64
 	FetchedAt time.Time
+	// Slot is the slot the value was read at; 0 when unknown. A value never
+	// replaces one read at a later slot.
+	Slot uint64
+	// Data carries the payload for non-balance namespaces (e.g. token accounts).
+	Data any
+	// TTL, when non-zero, overrides the TTL this value is stored with, for
+	// fetches that only learn their lifetime from the result.
+	TTL time.Duration
+	// Missing marks a negative result: the account looked up does not exist.
+	// Such values never outlive the cache's missing TTL.
+	Missing bool
+}
+
+// NoExpiry as a TTL keeps an entry until it is evicted for capacity. Use it
+// only for immutable data such as finalized history. A cache without
+// WithMaxEntries keeps such values for its default TTL instead, since
+// nothing would ever remove them.
+const NoExpiry time.Duration = -1
+
+// neverExpires is far enough out that time.Now never passes it.
+var neverExpires = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func expiresAt(ttl time.Duration) time.Time {
+	if ttl == NoExpiry {
+		return neverExpires
+	}
+	return time.Now().Add(ttl)
+}
+
+// Key builds a namespaced cache key so that non-balance lookups for the same
+// wallet never collide with its balance entry.
+func Key(namespace string, parts ...string) string {
+	return namespace + ":" + strings.Join(parts, ":")
 }
 
 type item struct {
+	key       string
 	val       Value
 	expiresAt time.Time
 }
 
 // Cache provides a TTL cache with singleflight coalescing per key.
 type Cache struct {
-	mu     sync.RWMutex
-	items  map[string]item
+	mu     sync.Mutex
+	items  map[string]*list.Element // of *item
+	// lru orders items from most to least recently used.
+	lru    *list.List
 	ttl    time.Duration
 	group  singleflight.Group
+	// missingTTL caps the lifetime of Missing values; zero means no cap.
+	missingTTL time.Duration
+	// maxEntries bounds len(items); zero means no bound.
+	maxEntries int
 }
 
 func New(ttl time.Duration) *Cache {
-	return &Cache{items: make(map[string]item), ttl: ttl}
+	return &Cache{items: make(map[string]*list.Element), lru: list.New(), ttl: ttl}
+}
+
+// WithMissingTTL caps how long negative results are kept, so that an account
+// is seen soon after it is first funded. Call it before the cache is used.
+func (c *Cache) WithMissingTTL(ttl time.Duration) *Cache {
+	c.missingTTL = ttl
+	return c
+}
+
+// WithMaxEntries bounds the number of entries held. Storing a new key in a
+// full cache evicts the least recently used entry, expired or not. Call it
+// before the cache is used.
+func (c *Cache) WithMaxEntries(n int) *Cache {
+	c.maxEntries = n
+	return c
+}
+
+// lookup returns the item for key, marking it recently used. c.mu must be
+// held.
+func (c *Cache) lookup(key string) (*item, bool) {
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*item), true
 }
 
 // GetOrFetch returns a cached value if valid; otherwise it coalesces concurrent
 // fetches for the same key using singleflight and stores the result.
 // Returns the value, source ("cache" or "rpc"), and error if fetching failed.
 func (c *Cache) GetOrFetch(ctx context.Context, key string, fetch func(context.Context) (Value, error)) (Value, string, error) {
+	return c.GetOrFetchTTL(ctx, key, c.ttl, fetch)
+}
+
+// GetOrFetchTTL is GetOrFetch with an explicit TTL for the stored value, for
+// namespaces that should live longer or shorter than the cache default.
+func (c *Cache) GetOrFetchTTL(ctx context.Context, key string, ttl time.Duration, fetch func(context.Context) (Value, error)) (Value, string, error) {
 	// fast path: cache hit
-	c.mu.RLock()
-	it, ok := c.items[key]
+	c.mu.Lock()
+	it, ok := c.lookup(key)
 	if ok && time.Now().Before(it.expiresAt) {
 		v := it.val
-		c.mu.RUnlock()
+		c.mu.Unlock()
 		return v, "cache", nil
 	}
-	c.mu.RUnlock()
+	c.mu.Unlock()
 
 	// singleflight to coalesce concurrent misses
 	res, err, _ := c.group.Do(key, func() (interface{}, error) {
@@ -51,10 +125,7 @@ func (c *Cache) GetOrFetch(ctx context.Context, key string, fetch func(context.C
 		if err != nil {
 			return nil, err
 		}
-		c.mu.Lock()
-		c.items[key] = item{val: v, expiresAt: time.Now().Add(c.ttl)}
-		c.mu.Unlock()
-		return v, nil
+		return c.store(key, v, ttl), nil
 	})
 	if err != nil {
 		return Value{}, "", err
@@ -62,9 +133,70 @@ func (c *Cache) GetOrFetch(ctx context.Context, key string, fetch func(context.C
 	return res.(Value), "rpc", nil
 }
 
+// Get returns the cached value for key if present and not expired.
+func (c *Cache) Get(key string) (Value, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	it, ok := c.lookup(key)
+	if !ok || !time.Now().Before(it.expiresAt) {
+		return Value{}, false
+	}
+	return it.val, true
+}
+
+// GetStale returns the value for key even if it has expired, for serving a
+// last known value while upstream is unavailable.
+func (c *Cache) GetStale(key string) (Value, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	it, ok := c.lookup(key)
+	if !ok {
+		return Value{}, false
+	}
+	return it.val, true
+}
+
+// Set stores v under key for the cache TTL and returns the value now held,
+// which is the existing one if it was read at a later slot. Used by callers
+// that fetch several keys at once outside of GetOrFetch.
+func (c *Cache) Set(key string, v Value) Value {
+	return c.store(key, v, c.ttl)
+}
+
+// store saves v with ttl unless the value carries its own TTL, capped at the
+// missing TTL for negative results. A reading from an older slot than the
+// entry already held (expired or not) only renews that entry, so balances
+// never appear to go backwards across RPC nodes.
+func (c *Cache) store(key string, v Value, ttl time.Duration) Value {
+	if v.TTL != 0 {
+		ttl = v.TTL
+	}
+	if ttl == NoExpiry && c.maxEntries <= 0 {
+		ttl = c.ttl
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v.Missing && c.missingTTL > 0 && (ttl == NoExpiry || ttl > c.missingTTL) {
+		ttl = c.missingTTL
+	}
+	if it, ok := c.lookup(key); ok {
+		if v.Slot != 0 && it.val.Slot > v.Slot {
+			v = it.val
+		}
+		it.val, it.expiresAt = v, expiresAt(ttl)
+		return v
+	}
+	c.items[key] = c.lru.PushFront(&item{key: key, val: v, expiresAt: expiresAt(ttl)})
+	if c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Remove(c.lru.Back()).(*item)
+		delete(c.items, oldest.key)
+	}
+	return v
+}
+
 // Len returns the number of items in the cache (for tests).
 func (c *Cache) Len() int {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return len(c.items)
 }
@@ -28,3 +28,100 @@ func TestCache_GetOrFetch_CacheHitAndError(t *testing.T) {
 	_, src3, err := c.GetOrFetch(ctx, "k2", badFetch)
 	if err == nil || src3 != "" { t.Fatalf("expected error, src='%s' err=%v", src3, err) }
 }
+
+func TestCache_GetSet(t *testing.T) {
+	c := New(50 * time.Millisecond)
+	if _, ok := c.Get("k"); ok { t.Fatalf("unexpected hit") }
+	c.Set("k", Value{Lamports: 7})
+	v, ok := c.Get("k")
+	if !ok || v.Lamports != 7 { t.Fatalf("get: v=%v ok=%v", v, ok) }
+	_, src, _ := c.GetOrFetch(context.Background(), "k", func(context.Context) (Value, error) { return Value{}, errors.New("unexpected fetch") })
+	if src != "cache" { t.Fatalf("src=%s", src) }
+	time.Sleep(60 * time.Millisecond)
+	if _, ok := c.Get("k"); ok { t.Fatalf("expected expiry") }
+}
+
+func TestCache_NamespacedTTL(t *testing.T) {
+	c := New(time.Hour)
+	ctx := context.Background()
+	key := Key("tokens", "w1")
+	if key != "tokens:w1" { t.Fatalf("key=%s", key) }
+	fetch := func(context.Context) (Value, error) { return Value{Data: []string{"a"}, FetchedAt: time.Now()}, nil }
+	v, _, err := c.GetOrFetchTTL(ctx, key, 30*time.Millisecond, fetch)
+	if err != nil || v.Data.([]string)[0] != "a" { t.Fatalf("v=%v err=%v", v, err) }
+	if _, ok := c.Get("w1"); ok { t.Fatalf("namespaced key leaked into balance key") }
+	time.Sleep(40 * time.Millisecond)
+	if _, ok := c.Get(key); ok { t.Fatalf("expected namespaced entry to expire on its own TTL") }
+}
+
+func TestCache_ValueTTLOverrideAndNoExpiry(t *testing.T) {
+	c := New(20 * time.Millisecond).WithMaxEntries(10)
+	ctx := context.Background()
+	_, _, _ = c.GetOrFetch(ctx, "forever", func(context.Context) (Value, error) { return Value{Lamports: 1, TTL: NoExpiry}, nil })
+	c.Set("short", Value{Lamports: 2})
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := c.Get("forever"); !ok { t.Fatalf("NoExpiry entry expired") }
+	if _, ok := c.Get("short"); ok { t.Fatalf("default TTL entry should have expired") }
+
+	// without a capacity nothing is kept forever
+	u := New(20 * time.Millisecond)
+	_, _, _ = u.GetOrFetch(ctx, "forever", func(context.Context) (Value, error) { return Value{Lamports: 1, TTL: NoExpiry}, nil })
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := u.Get("forever"); ok { t.Fatalf("NoExpiry entry kept by an unbounded cache") }
+}
+
+func TestCache_MaxEntriesEvictsLeastRecentlyUsed(t *testing.T) {
+	c := New(time.Minute).WithMaxEntries(2)
+	c.Set("a", Value{Lamports: 1, TTL: NoExpiry})
+	c.Set("b", Value{Lamports: 2, TTL: NoExpiry})
+	if _, ok := c.Get("a"); !ok { t.Fatalf("a missing") }
+	c.Set("c", Value{Lamports: 3})
+	if c.Len() != 2 { t.Fatalf("len=%d, want 2", c.Len()) }
+	if _, ok := c.GetStale("b"); ok { t.Fatalf("least recently used entry kept") }
+	if _, ok := c.Get("a"); !ok { t.Fatalf("recently used entry evicted") }
+	// updating an existing key does not evict
+	c.Set("c", Value{Lamports: 4})
+	if v, ok := c.Get("c"); !ok || v.Lamports != 4 || c.Len() != 2 { t.Fatalf("v=%+v ok=%v len=%d", v, ok, c.Len()) }
+}
+
+func TestCache_OlderSlotDoesNotOverwrite(t *testing.T) {
+	c := New(time.Minute)
+	c.Set("k", Value{Lamports: 10, Slot: 200})
+	if got := c.Set("k", Value{Lamports: 5, Slot: 150}); got.Lamports != 10 || got.Slot != 200 { t.Fatalf("older slot replaced entry: %+v", got) }
+	if v, ok := c.Get("k"); !ok || v.Slot != 200 { t.Fatalf("v=%+v ok=%v", v, ok) }
+
+	// an expired entry still guards against going backwards
+	c2 := New(10 * time.Millisecond)
+	c2.Set("k", Value{Lamports: 10, Slot: 200})
+	time.Sleep(20 * time.Millisecond)
+	v, src, _ := c2.GetOrFetch(context.Background(), "k", func(context.Context) (Value, error) { return Value{Lamports: 5, Slot: 199}, nil })
+	if src != "rpc" || v.Lamports != 10 { t.Fatalf("src=%s v=%+v", src, v) }
+	if got := c2.Set("k", Value{Lamports: 7, Slot: 201}); got.Lamports != 7 { t.Fatalf("newer slot rejected: %+v", got) }
+	if got := c2.Set("k", Value{Lamports: 3}); got.Lamports != 3 { t.Fatalf("unknown slot rejected: %+v", got) }
+}
+
+func TestCache_GetStale(t *testing.T) {
+	c := New(5 * time.Millisecond)
+	if _, ok := c.GetStale("k"); ok { t.Fatalf("unexpected stale hit") }
+	c.Set("k", Value{Lamports: 9})
+	time.Sleep(10 * time.Millisecond)
+	if _, ok := c.Get("k"); ok { t.Fatalf("entry should have expired") }
+	if v, ok := c.GetStale("k"); !ok || v.Lamports != 9 { t.Fatalf("stale v=%+v ok=%v", v, ok) }
+}
+
+func TestCache_MissingTTL(t *testing.T) {
+	c := New(time.Minute).WithMissingTTL(10 * time.Millisecond)
+	c.Set("missing", Value{Slot: 5, Missing: true})
+	c.Set("funded", Value{Lamports: 1, Slot: 5})
+	_, _, _ = c.GetOrFetch(context.Background(), "pinned", func(context.Context) (Value, error) { return Value{Missing: true, TTL: NoExpiry}, nil })
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := c.Get("missing"); ok { t.Fatalf("negative entry outlived the missing TTL") }
+	if _, ok := c.Get("pinned"); ok { t.Fatalf("negative entry kept forever") }
+	if _, ok := c.Get("funded"); !ok { t.Fatalf("positive entry expired early") }
+
+	// without a missing TTL negative results live as long as any other
+	c2 := New(time.Minute)
+	c2.Set("missing", Value{Missing: true})
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := c2.Get("missing"); !ok { t.Fatalf("negative entry expired early") }
+}
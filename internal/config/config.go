@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -14,10 +15,129 @@ type Config struct {
 	MongoDB         string
 	RateLimitRPM    int
 	CacheTTL        time.Duration
+	// MissingCacheTTL caps how long lookups of nonexistent accounts stay
+	// cached, so first deposits show up quickly.
+	MissingCacheTTL time.Duration
+	// CacheMaxEntries bounds the shared cache; the least recently used
+	// entries are evicted beyond it.
+	CacheMaxEntries int
+	// TxCacheEntries bounds the separate cache of whole transactions
+	// behind /api/transactions/<signature>.
+	TxCacheEntries  int
+	TokenCacheTTL   time.Duration
+	StakeCacheTTL   time.Duration
 	KeyCacheTTL     time.Duration
 	BalanceTimeout  time.Duration
 	MaxConcurrency  int
 	SolCommitment   string
+	// BatchWindow enables cross-request micro-batching of balance fetches
+	// when > 0; BatchMaxKeys flushes a batch early once reached.
+	BatchWindow     time.Duration
+	BatchMaxKeys    int
+	// RPCEndpoints, when set, spreads balance fetches over several RPC
+	// providers with failover. Empty means HeliusURL alone.
+	RPCEndpoints    []RPCEndpoint
+	// HedgePercentile and HedgeBudget control hedged requests across
+	// RPCEndpoints; a percentile of 0 disables hedging.
+	HedgePercentile float64
+	HedgeBudget     float64
+	// BreakerFailures consecutive balance fetch failures open the circuit
+	// breaker for BreakerOpenTimeout; 0 disables the breaker. ServeStale
+	// answers from expired cache entries while it is open.
+	BreakerFailures    int
+	BreakerOpenTimeout time.Duration
+	BreakerProbes      int
+	ServeStale         bool
+	// RetryAttempts caps upstream attempts per balance lookup (1 disables
+	// retries); backoff starts at RetryBaseDelay and is capped at RetryMaxDelay.
+	RetryAttempts      int
+	RetryBaseDelay     time.Duration
+	RetryMaxDelay      time.Duration
+	// RPCWebSocketURL enables live balance subscriptions; pushed balances
+	// stay cached for LiveCacheTTL. Empty disables subscriptions.
+	RPCWebSocketURL    string
+	LiveCacheTTL       time.Duration
+	// StreamHeartbeat is the interval between heartbeats on balance
+	// streams; StreamsPerKey caps concurrent SSE streams, and separately
+	// WebSocket connections, per API key. StreamTokenTTL is how long a
+	// token from /api/stream/token can open streams.
+	StreamHeartbeat    time.Duration
+	StreamsPerKey      int
+	StreamTokenTTL     time.Duration
+	// SocketSubsPerKey caps wallets subscribed over WebSocket per API key;
+	// SocketMsgsPerMin rate limits client messages per API key.
+	SocketSubsPerKey   int
+	SocketMsgsPerMin   int
+	// WebhooksPerKey caps webhooks registered per API key. Deliveries are
+	// attempted up to WebhookAttempts times, each within WebhookTimeout.
+	// WebhookWatcher runs the watcher that enqueues deliveries; enable it on
+	// exactly one instance, or every instance enqueues each event.
+	WebhooksPerKey     int
+	WebhookWatcher     bool
+	WebhookAttempts    int
+	WebhookTimeout     time.Duration
+	// WatchlistsPerKey caps watchlists per API key and WatchlistWallets
+	// the wallets on one watchlist.
+	WatchlistsPerKey   int
+	WatchlistWallets   int
+	// PythFeeds maps "ASSET/CURRENCY" pairs to Pyth price accounts, and
+	// PriceFeedURL is a JSON price feed template tried after them; with
+	// neither set, quote requests are ignored. Quotes are cached for
+	// PriceCacheTTL and refused once older than PriceMaxAge; pairs without
+	// a price are remembered for PriceMissingTTL.
+	PythFeeds          map[string]string
+	PriceFeedURL       string
+	PriceCacheTTL      time.Duration
+	PriceMissingTTL    time.Duration
+	PriceMaxAge        time.Duration
+}
+
+// RPCEndpoint is one entry of RPC_ENDPOINTS.
+type RPCEndpoint struct {
+	URL      string
+	Priority int // lower is preferred
+	Weight   int // share of traffic within a priority
+}
+
+// parseEndpoints reads a comma-separated list of url[|priority[|weight]]
+// entries, e.g. "https://a.example|0|3,https://b.example|0|1,http://node:8899|1".
+// Malformed priority or weight fields fall back to 0 and 1.
+func parseEndpoints(v string) []RPCEndpoint {
+	var out []RPCEndpoint
+	for _, entry := range strings.Split(v, ",") {
+		fields := strings.Split(strings.TrimSpace(entry), "|")
+		if fields[0] == "" {
+			continue
+		}
+		ep := RPCEndpoint{URL: fields[0], Weight: 1}
+		if len(fields) > 1 {
+			if n, err := strconv.Atoi(fields[1]); err == nil {
+				ep.Priority = n
+			}
+		}
+		if len(fields) > 2 {
+			if n, err := strconv.Atoi(fields[2]); err == nil && n > 0 {
+				ep.Weight = n
+			}
+		}
+		out = append(out, ep)
+	}
+	return out
+}
+
+// parsePriceFeeds reads a comma-separated list of PAIR=account entries,
+// e.g. "SOL/USD=H6ARHf6YXhGYeQfUzQNGk6rDNnLBQKrenN712K4AQJEG". Pairs are
+// upper-cased; entries without "=" are skipped.
+func parsePriceFeeds(v string) map[string]string {
+	out := make(map[string]string)
+	for _, entry := range strings.Split(v, ",") {
+		pair, account, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || pair == "" || account == "" {
+			continue
+		}
+		out[strings.ToUpper(strings.TrimSpace(pair))] = strings.TrimSpace(account)
+	}
+	return out
 }
 
 func getenv(key, def string) string {
@@ -45,18 +165,71 @@ func getdur(key string, def time.Duration) time.Duration {
 	return def
 }
 
+func getfloat(key string, def float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+func getbool(key string, def bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
 // Load loads configuration from environment variables with sane defaults.
 func Load() Config {
 	return Config{
-		Port:           getenv("PORT", "8080"),
-		HeliusURL:      getenv("HELIUS_RPC_URL", ""),
-		MongoURI:       getenv("MONGO_URI", "mongodb://localhost:27017"),
-		MongoDB:        getenv("MONGO_DB", "solapi"),
-		RateLimitRPM:   getint("RATE_LIMIT_RPM", 10),
-		CacheTTL:       getdur("CACHE_TTL", 10*time.Second),
-		KeyCacheTTL:    getdur("KEY_CACHE_TTL", 60*time.Second),
-		BalanceTimeout: getdur("BALANCE_TIMEOUT", 3*time.Second),
-		MaxConcurrency: getint("MAX_CONCURRENCY", 16),
-		SolCommitment:  getenv("SOL_COMMITMENT", "finalized"),
+		Port:               getenv("PORT", "8080"),
+		HeliusURL:          getenv("HELIUS_RPC_URL", ""),
+		MongoURI:           getenv("MONGO_URI", "mongodb://localhost:27017"),
+		MongoDB:            getenv("MONGO_DB", "solapi"),
+		RateLimitRPM:       getint("RATE_LIMIT_RPM", 10),
+		CacheTTL:           getdur("CACHE_TTL", 10*time.Second),
+		MissingCacheTTL:    getdur("MISSING_CACHE_TTL", 2*time.Second),
+		CacheMaxEntries:    getint("CACHE_MAX_ENTRIES", 100_000),
+		TxCacheEntries:     getint("TX_CACHE_MAX_ENTRIES", 1_000),
+		TokenCacheTTL:      getdur("TOKEN_CACHE_TTL", 30*time.Second),
+		StakeCacheTTL:      getdur("STAKE_CACHE_TTL", time.Minute),
+		KeyCacheTTL:        getdur("KEY_CACHE_TTL", 60*time.Second),
+		BalanceTimeout:     getdur("BALANCE_TIMEOUT", 3*time.Second),
+		MaxConcurrency:     getint("MAX_CONCURRENCY", 16),
+		SolCommitment:      getenv("SOL_COMMITMENT", "finalized"),
+		BatchWindow:        getdur("BATCH_WINDOW", 0),
+		BatchMaxKeys:       getint("BATCH_MAX_KEYS", 100),
+		RPCEndpoints:       parseEndpoints(os.Getenv("RPC_ENDPOINTS")),
+		HedgePercentile:    getfloat("HEDGE_PERCENTILE", 0.95),
+		HedgeBudget:        getfloat("HEDGE_BUDGET", 0.1),
+		BreakerFailures:    getint("BREAKER_FAILURES", 5),
+		BreakerOpenTimeout: getdur("BREAKER_OPEN_TIMEOUT", 10*time.Second),
+		BreakerProbes:      getint("BREAKER_HALF_OPEN_PROBES", 1),
+		ServeStale:         getbool("SERVE_STALE", false),
+		RetryAttempts:      getint("RETRY_MAX_ATTEMPTS", 3),
+		RetryBaseDelay:     getdur("RETRY_BASE_DELAY", 100*time.Millisecond),
+		RetryMaxDelay:      getdur("RETRY_MAX_DELAY", time.Second),
+		RPCWebSocketURL:    getenv("RPC_WS_URL", ""),
+		LiveCacheTTL:       getdur("LIVE_CACHE_TTL", time.Minute),
+		StreamHeartbeat:    getdur("STREAM_HEARTBEAT", 15*time.Second),
+		StreamsPerKey:      getint("STREAMS_PER_KEY", 5),
+		StreamTokenTTL:     getdur("STREAM_TOKEN_TTL", time.Minute),
+		SocketSubsPerKey:   getint("WS_SUBSCRIPTIONS_PER_KEY", 500),
+		SocketMsgsPerMin:   getint("WS_MESSAGES_PER_MINUTE", 120),
+		WebhooksPerKey:     getint("WEBHOOKS_PER_KEY", 10),
+		WebhookWatcher:     getbool("WEBHOOK_WATCHER", false),
+		WebhookAttempts:    getint("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookTimeout:     getdur("WEBHOOK_TIMEOUT", 10*time.Second),
+		WatchlistsPerKey:   getint("WATCHLISTS_PER_KEY", 20),
+		WatchlistWallets:   getint("WATCHLIST_MAX_WALLETS", 1000),
+		PythFeeds:          parsePriceFeeds(os.Getenv("PYTH_PRICE_ACCOUNTS")),
+		PriceFeedURL:       getenv("PRICE_FEED_URL", ""),
+		PriceCacheTTL:      getdur("PRICE_CACHE_TTL", 30*time.Second),
+		PriceMissingTTL:    getdur("PRICE_MISSING_TTL", 10*time.Second),
+		PriceMaxAge:        getdur("PRICE_MAX_AGE", 2*time.Minute),
 	}
 }
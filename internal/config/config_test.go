@@ -14,16 +14,63 @@ func TestLoad_Defaults(t *testing.T) {
 	os.Unsetenv("MONGO_DB")
 	os.Unsetenv("RATE_LIMIT_RPM")
 	os.Unsetenv("CACHE_TTL")
+	os.Unsetenv("MISSING_CACHE_TTL")
+	os.Unsetenv("CACHE_MAX_ENTRIES")
+	os.Unsetenv("TX_CACHE_MAX_ENTRIES")
 	os.Unsetenv("KEY_CACHE_TTL")
 	os.Unsetenv("BALANCE_TIMEOUT")
 	os.Unsetenv("MAX_CONCURRENCY")
 	os.Unsetenv("SOL_COMMITMENT")
 	os.Unsetenv("ADMIN_TOKEN")
+	os.Unsetenv("BATCH_WINDOW")
+	os.Unsetenv("BATCH_MAX_KEYS")
+	os.Unsetenv("TOKEN_CACHE_TTL")
+	os.Unsetenv("STAKE_CACHE_TTL")
+	os.Unsetenv("RPC_ENDPOINTS")
+	os.Unsetenv("HEDGE_PERCENTILE")
+	os.Unsetenv("HEDGE_BUDGET")
+	os.Unsetenv("BREAKER_FAILURES")
+	os.Unsetenv("BREAKER_OPEN_TIMEOUT")
+	os.Unsetenv("BREAKER_HALF_OPEN_PROBES")
+	os.Unsetenv("SERVE_STALE")
+	os.Unsetenv("RETRY_MAX_ATTEMPTS")
+	os.Unsetenv("RETRY_BASE_DELAY")
+	os.Unsetenv("RETRY_MAX_DELAY")
+	os.Unsetenv("RPC_WS_URL")
+	os.Unsetenv("LIVE_CACHE_TTL")
+	os.Unsetenv("STREAM_HEARTBEAT")
+	os.Unsetenv("STREAMS_PER_KEY")
+	os.Unsetenv("STREAM_TOKEN_TTL")
+	os.Unsetenv("WS_SUBSCRIPTIONS_PER_KEY")
+	os.Unsetenv("WS_MESSAGES_PER_MINUTE")
+	os.Unsetenv("WEBHOOKS_PER_KEY")
+	os.Unsetenv("WEBHOOK_WATCHER")
+	os.Unsetenv("WEBHOOK_MAX_ATTEMPTS")
+	os.Unsetenv("WEBHOOK_TIMEOUT")
+	os.Unsetenv("WATCHLISTS_PER_KEY")
+	os.Unsetenv("WATCHLIST_MAX_WALLETS")
+	os.Unsetenv("PYTH_PRICE_ACCOUNTS")
+	os.Unsetenv("PRICE_FEED_URL")
+	os.Unsetenv("PRICE_CACHE_TTL")
+	os.Unsetenv("PRICE_MISSING_TTL")
+	os.Unsetenv("PRICE_MAX_AGE")
 
 	c := Load()
 	if c.Port != "8080" { t.Fatalf("port=%s", c.Port) }
 	if c.MongoURI == "" || c.MongoDB == "" { t.Fatalf("mongo not set") }
-	if c.RateLimitRPM <= 0 || c.CacheTTL <= 0 || c.KeyCacheTTL <= 0 || c.BalanceTimeout <= 0 || c.MaxConcurrency <= 0 { t.Fatalf("invalid defaults") }
+	if len(c.RPCEndpoints) != 0 { t.Fatalf("endpoints=%v", c.RPCEndpoints) }
+	if c.HedgePercentile != 0.95 || c.HedgeBudget != 0.1 { t.Fatalf("hedge percentile=%v budget=%v", c.HedgePercentile, c.HedgeBudget) }
+	if c.BreakerFailures != 5 || c.BreakerOpenTimeout != 10*time.Second || c.BreakerProbes != 1 || c.ServeStale { t.Fatalf("breaker defaults=%d %v %d %v", c.BreakerFailures, c.BreakerOpenTimeout, c.BreakerProbes, c.ServeStale) }
+	if c.RetryAttempts != 3 || c.RetryBaseDelay != 100*time.Millisecond || c.RetryMaxDelay != time.Second { t.Fatalf("retry defaults=%d %v %v", c.RetryAttempts, c.RetryBaseDelay, c.RetryMaxDelay) }
+	if c.RPCWebSocketURL != "" || c.LiveCacheTTL != time.Minute { t.Fatalf("ws defaults=%q %v", c.RPCWebSocketURL, c.LiveCacheTTL) }
+	if c.StreamHeartbeat != 15*time.Second || c.StreamsPerKey != 5 || c.StreamTokenTTL != time.Minute { t.Fatalf("stream defaults=%v %d %v", c.StreamHeartbeat, c.StreamsPerKey, c.StreamTokenTTL) }
+	if c.SocketSubsPerKey != 500 || c.SocketMsgsPerMin != 120 { t.Fatalf("socket defaults=%d %d", c.SocketSubsPerKey, c.SocketMsgsPerMin) }
+	if c.WebhooksPerKey != 10 || c.WebhookAttempts != 8 || c.WebhookTimeout != 10*time.Second || c.WebhookWatcher { t.Fatalf("webhook defaults=%d %d %v %v", c.WebhooksPerKey, c.WebhookAttempts, c.WebhookTimeout, c.WebhookWatcher) }
+	if c.WatchlistsPerKey != 20 || c.WatchlistWallets != 1000 { t.Fatalf("watchlist defaults=%d %d", c.WatchlistsPerKey, c.WatchlistWallets) }
+	if len(c.PythFeeds) != 0 || c.PriceFeedURL != "" || c.PriceCacheTTL != 30*time.Second || c.PriceMissingTTL != 10*time.Second || c.PriceMaxAge != 2*time.Minute { t.Fatalf("price defaults=%v %q %v %v", c.PythFeeds, c.PriceFeedURL, c.PriceCacheTTL, c.PriceMaxAge) }
+	if c.MissingCacheTTL != 2*time.Second || c.CacheMaxEntries != 100_000 || c.TxCacheEntries != 1_000 { t.Fatalf("missing cache ttl=%v max entries=%d tx entries=%d", c.MissingCacheTTL, c.CacheMaxEntries, c.TxCacheEntries) }
+	if c.BatchWindow != 0 || c.BatchMaxKeys != 100 { t.Fatalf("batching should default off: window=%v max=%d", c.BatchWindow, c.BatchMaxKeys) }
+	if c.RateLimitRPM <= 0 || c.CacheTTL <= 0 || c.TokenCacheTTL <= 0 || c.StakeCacheTTL <= 0 || c.KeyCacheTTL <= 0 || c.BalanceTimeout <= 0 || c.MaxConcurrency <= 0 { t.Fatalf("invalid defaults") }
 }
 
 func TestLoad_EnvOverrides(t *testing.T) {
@@ -33,12 +80,35 @@ func TestLoad_EnvOverrides(t *testing.T) {
 	os.Setenv("KEY_CACHE_TTL", "2s")
 	os.Setenv("BALANCE_TIMEOUT", "5s")
 	os.Setenv("MAX_CONCURRENCY", "7")
+	os.Setenv("BATCH_WINDOW", "5ms")
+	os.Setenv("BATCH_MAX_KEYS", "20")
+	os.Setenv("HEDGE_PERCENTILE", "0.99")
+	os.Setenv("SERVE_STALE", "true")
 	defer func(){
 		os.Unsetenv("PORT"); os.Unsetenv("RATE_LIMIT_RPM"); os.Unsetenv("CACHE_TTL"); os.Unsetenv("KEY_CACHE_TTL"); os.Unsetenv("BALANCE_TIMEOUT"); os.Unsetenv("MAX_CONCURRENCY")
+		os.Unsetenv("BATCH_WINDOW"); os.Unsetenv("BATCH_MAX_KEYS"); os.Unsetenv("HEDGE_PERCENTILE"); os.Unsetenv("SERVE_STALE")
 	}()
 	c := Load()
 	if c.Port != "9090" { t.Fatalf("port=%s", c.Port) }
 	if c.RateLimitRPM != 123 { t.Fatalf("rpm=%d", c.RateLimitRPM) }
 	if c.CacheTTL != 150*time.Millisecond || c.KeyCacheTTL != 2*time.Second || c.BalanceTimeout != 5*time.Second { t.Fatalf("durations not applied") }
 	if c.MaxConcurrency != 7 { t.Fatalf("max=%d", c.MaxConcurrency) }
+	if c.BatchWindow != 5*time.Millisecond || c.BatchMaxKeys != 20 { t.Fatalf("batch window=%v max=%d", c.BatchWindow, c.BatchMaxKeys) }
+	if c.HedgePercentile != 0.99 { t.Fatalf("hedge percentile=%v", c.HedgePercentile) }
+	if !c.ServeStale { t.Fatalf("serve stale not applied") }
+}
+
+func TestParseEndpoints(t *testing.T) {
+	eps := parseEndpoints(" https://a.example/?api-key=x|0|3, http://node:8899|1 ,https://b.example,|2|2,https://c.example|x|-1")
+	if len(eps) != 4 { t.Fatalf("eps=%+v", eps) }
+	if eps[0] != (RPCEndpoint{URL: "https://a.example/?api-key=x", Priority: 0, Weight: 3}) { t.Fatalf("eps[0]=%+v", eps[0]) }
+	if eps[1] != (RPCEndpoint{URL: "http://node:8899", Priority: 1, Weight: 1}) { t.Fatalf("eps[1]=%+v", eps[1]) }
+	if eps[2] != (RPCEndpoint{URL: "https://b.example", Weight: 1}) || eps[3] != (RPCEndpoint{URL: "https://c.example", Weight: 1}) { t.Fatalf("eps=%+v", eps[2:]) }
+	if parseEndpoints("") != nil { t.Fatalf("empty should be nil") }
+}
+
+func TestParsePriceFeeds(t *testing.T) {
+	feeds := parsePriceFeeds(" sol/usd=H6ARHf6YXhGYeQfUzQNGk6rDNnLBQKrenN712K4AQJEG ,bad,=x,SOL/EUR=,USDC/USD = Gnt27xtC473ZT2Mw5u8wZ68Z3gULkSTb5DuxJy7eJotD")
+	if len(feeds) != 2 { t.Fatalf("feeds=%v", feeds) }
+	if feeds["SOL/USD"] != "H6ARHf6YXhGYeQfUzQNGk6rDNnLBQKrenN712K4AQJEG" || feeds["USDC/USD"] != "Gnt27xtC473ZT2Mw5u8wZ68Z3gULkSTb5DuxJy7eJotD" { t.Fatalf("feeds=%v", feeds) }
 }
@@ -0,0 +1,111 @@
+package explain
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/pkg/amount"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// MemoV1ProgramID is the original SPL Memo program; sol.MemoProgramID is v2.
+var MemoV1ProgramID = sol.MustPublicKeyFromBase58("Memo1UhkJRfHyvLMcVucJwxXeuD728EqVDDwQDxFMNo")
+
+var (
+	errShortData       = errors.New("instruction data too short")
+	errMissingAccounts = errors.New("instruction has too few accounts")
+)
+
+// decodeFunc decodes one program's instruction into its type, named after
+// the program's own instruction (as the RPC's jsonParsed encoding does), and
+// its details.
+type decodeFunc func(e *explainer, ix solana.TxInstruction) (string, map[string]any, error)
+
+type program struct {
+	name   string
+	decode decodeFunc
+}
+
+// programs are the programs whose instructions are decoded, by ID.
+var programs = map[sol.PublicKey]program{
+	sol.SystemProgramID:                    {"system", decodeSystem},
+	sol.TokenProgramID:                     {solana.ProgramSPLToken, decodeToken},
+	solana.Token2022ProgramID:              {solana.ProgramSPLToken2022, decodeToken},
+	sol.SPLAssociatedTokenAccountProgramID: {"spl-associated-token-account", decodeAssociatedToken},
+	sol.StakeProgramID:                     {"stake", decodeStake},
+	sol.ComputeBudget:                      {"compute-budget", decodeComputeBudget},
+	sol.MemoProgramID:                      {"spl-memo", decodeMemo},
+	MemoV1ProgramID:                        {"spl-memo", decodeMemo},
+}
+
+func unknownInstruction(tag any) error { return fmt.Errorf("unknown instruction %v", tag) }
+
+// reader reads little-endian, bincode-style fields off instruction data. A
+// read past the end sets err and yields zero values from then on.
+type reader struct {
+	b   []byte
+	err error
+}
+
+func (r *reader) take(n int) []byte {
+	if r.err != nil || n < 0 || len(r.b) < n {
+		r.err = errShortData
+		r.b = nil
+		return make([]byte, max(n, 0))
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *reader) u8() uint8   { return r.take(1)[0] }
+func (r *reader) u32() uint32 { return binary.LittleEndian.Uint32(r.take(4)) }
+func (r *reader) u64() uint64 { return binary.LittleEndian.Uint64(r.take(8)) }
+func (r *reader) i64() int64  { return int64(r.u64()) }
+
+func (r *reader) pubkey() string { return sol.PublicKeyFromBytes(r.take(sol.PublicKeyLength)).String() }
+
+// str reads a bincode string: a u64 length, then that many bytes.
+func (r *reader) str() string {
+	n := r.u64()
+	if n > uint64(len(r.b)) {
+		r.err = errShortData
+		return ""
+	}
+	return string(r.take(int(n)))
+}
+
+// option reads the one-byte tag of an Option (bincode) or COption (SPL
+// Token instruction data) and reports whether a value follows.
+func (r *reader) option() bool { return r.u8() == 1 }
+
+// withAccounts names ix's accounts in order. The first required names must
+// be present; the rest are set only when the instruction passes them.
+func withAccounts(ix solana.TxInstruction, required int, names ...string) (map[string]any, error) {
+	if len(ix.Accounts) < required {
+		return nil, errMissingAccounts
+	}
+	info := make(map[string]any, len(names)+2)
+	for i, name := range names {
+		if i < len(ix.Accounts) {
+			info[name] = ix.Accounts[i].String()
+		}
+	}
+	return info, nil
+}
+
+func addresses(pks []sol.PublicKey) []string {
+	out := make([]string, len(pks))
+	for i, pk := range pks {
+		out[i] = pk.String()
+	}
+	return out
+}
+
+// setLamports records a SOL amount both raw and as an exact decimal.
+func setLamports(info map[string]any, lamports uint64) {
+	info["lamports"] = lamports
+	info["sol"] = amount.Format(lamports, amount.SolDecimals)
+}
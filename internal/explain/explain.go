@@ -0,0 +1,164 @@
+// Package explain turns fetched transactions into human-readable JSON:
+// decoded instructions for the common native and SPL programs, and the SOL
+// and token balance changes each account saw.
+package explain
+
+import (
+	"encoding/hex"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	"github.com/example/solapi/pkg/amount"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// explainer holds what instruction decoders need to know about the
+// transaction beyond the instruction itself.
+type explainer struct {
+	// tokens maps token accounts with a reported balance to that balance,
+	// for the mint and decimals that unchecked token instructions omit.
+	tokens map[sol.PublicKey]solana.TxTokenBalance
+}
+
+// Transaction explains tx. The response's Source is left for the caller.
+func Transaction(tx solana.TransactionDetail) types.ExplainTransactionResponse {
+	e := &explainer{tokens: make(map[sol.PublicKey]solana.TxTokenBalance)}
+	for _, bals := range [][]solana.TxTokenBalance{tx.PreTokenBalances, tx.PostTokenBalances} {
+		for _, b := range bals {
+			if b.AccountIndex >= 0 && b.AccountIndex < len(tx.Accounts) {
+				e.tokens[tx.Accounts[b.AccountIndex].Address] = b
+			}
+		}
+	}
+
+	resp := types.ExplainTransactionResponse{
+		Signature:    tx.Signature,
+		Slot:         tx.Slot,
+		Version:      tx.Version,
+		Status:       "success",
+		Err:          tx.Err,
+		Fee:          tx.Fee,
+		ComputeUnits: tx.ComputeUnits,
+		Instructions: make([]types.ExplainedInstruction, 0, len(tx.Instructions)),
+		SolChanges:   solChanges(tx),
+		TokenChanges: tokenChanges(tx),
+		Logs:         tx.Logs,
+	}
+	if tx.Err != nil {
+		resp.Status = "failed"
+	}
+	if tx.BlockTime != nil {
+		resp.BlockTime = tx.BlockTime.Format(time.RFC3339)
+	}
+	if len(tx.Accounts) > 0 {
+		resp.FeePayer = tx.Accounts[0].Address.String()
+	}
+	for _, ix := range tx.Instructions {
+		out := e.instruction(ix)
+		for _, in := range ix.Inner {
+			out.Inner = append(out.Inner, e.instruction(in))
+		}
+		resp.Instructions = append(resp.Instructions, out)
+	}
+	return resp
+}
+
+// instruction decodes ix, falling back to its raw accounts and data when the
+// program is unknown or the data does not parse.
+func (e *explainer) instruction(ix solana.TxInstruction) types.ExplainedInstruction {
+	out := types.ExplainedInstruction{ProgramID: ix.ProgramID.String()}
+	if p, ok := programs[ix.ProgramID]; ok {
+		out.Program = p.name
+		if typ, info, err := p.decode(e, ix); err == nil {
+			out.Type, out.Info = typ, info
+			return out
+		}
+	}
+	out.Accounts = addresses(ix.Accounts)
+	out.Data = hex.EncodeToString(ix.Data)
+	return out
+}
+
+// solChanges lists accounts whose SOL balance changed, in account order.
+func solChanges(tx solana.TransactionDetail) []types.SolChange {
+	out := []types.SolChange{}
+	for i, acc := range tx.Accounts {
+		if i >= len(tx.PreBalances) || i >= len(tx.PostBalances) || tx.PreBalances[i] == tx.PostBalances[i] {
+			continue
+		}
+		pre, post := tx.PreBalances[i], tx.PostBalances[i]
+		delta := int64(post - pre) // two's complement wraps to the signed change
+		out = append(out, types.SolChange{
+			Account:       acc.Address.String(),
+			PreLamports:   pre,
+			PostLamports:  post,
+			LamportsDelta: delta,
+			SolDelta:      amount.FormatInt(delta, amount.SolDecimals),
+		})
+	}
+	return out
+}
+
+// tokenChanges lists token accounts whose balance changed, in account order.
+// An account missing from the pre (post) balances was created (closed) by
+// the transaction and counts as zero there.
+func tokenChanges(tx solana.TransactionDetail) []types.TokenChange {
+	pre := make(map[int]solana.TxTokenBalance, len(tx.PreTokenBalances))
+	post := make(map[int]solana.TxTokenBalance, len(tx.PostTokenBalances))
+	var idx []int
+	for _, b := range tx.PreTokenBalances {
+		pre[b.AccountIndex] = b
+		idx = append(idx, b.AccountIndex)
+	}
+	for _, b := range tx.PostTokenBalances {
+		post[b.AccountIndex] = b
+		if _, ok := pre[b.AccountIndex]; !ok {
+			idx = append(idx, b.AccountIndex)
+		}
+	}
+	sort.Ints(idx)
+
+	out := []types.TokenChange{}
+	for _, i := range idx {
+		before, hadPre := pre[i]
+		after, hasPost := post[i]
+		b := after
+		if !hasPost {
+			b = before
+		}
+		from, to := rawAmount(before, hadPre), rawAmount(after, hasPost)
+		delta := new(big.Int).Sub(to, from)
+		if delta.Sign() == 0 || i < 0 || i >= len(tx.Accounts) {
+			continue
+		}
+		ui, _ := amount.FormatString(new(big.Int).Abs(delta).String(), b.Decimals)
+		if delta.Sign() < 0 {
+			ui = "-" + ui
+		}
+		out = append(out, types.TokenChange{
+			Account:    tx.Accounts[i].Address.String(),
+			Owner:      b.Owner,
+			Mint:       b.Mint,
+			ProgramID:  b.ProgramID,
+			Decimals:   b.Decimals,
+			PreAmount:  from.String(),
+			PostAmount: to.String(),
+			Delta:      delta.String(),
+			UIDelta:    ui,
+		})
+	}
+	return out
+}
+
+// rawAmount parses b's base-unit amount, zero when absent or malformed.
+func rawAmount(b solana.TxTokenBalance, ok bool) *big.Int {
+	if ok {
+		if n, valid := new(big.Int).SetString(b.Amount, 10); valid {
+			return n
+		}
+	}
+	return new(big.Int)
+}
@@ -0,0 +1,84 @@
+package explain
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/solana"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+func le32(tag uint32, rest ...byte) []byte { return append(binary.LittleEndian.AppendUint32(nil, tag), rest...) }
+func le64(v uint64) []byte                 { return binary.LittleEndian.AppendUint64(nil, v) }
+
+func key(b byte) sol.PublicKey { var pk sol.PublicKey; pk[0] = b; return pk }
+
+func TestTransaction(t *testing.T) {
+	payer, dest, src, dst, mint := key(1), key(2), key(3), key(4), key(5)
+	unknown := key(9)
+	bt := time.Unix(1_700_000_000, 0)
+	tx := solana.TransactionDetail{
+		Signature: "sig", Slot: 7, BlockTime: &bt, Version: "0", Fee: 5000,
+		Accounts: []solana.TxAccount{{Address: payer, Signer: true, Writable: true}, {Address: dest, Writable: true}, {Address: src, Writable: true}, {Address: dst, Writable: true}, {Address: mint}},
+		PreBalances:  []uint64{3_000_000_000, 0, 2_039_280, 2_039_280, 1},
+		PostBalances: []uint64{1_499_995_000, 1_500_000_000, 2_039_280, 2_039_280, 1},
+		PreTokenBalances: []solana.TxTokenBalance{{AccountIndex: 2, Mint: mint.String(), Owner: payer.String(), Amount: "5000000", Decimals: 6}},
+		PostTokenBalances: []solana.TxTokenBalance{
+			{AccountIndex: 2, Mint: mint.String(), Owner: payer.String(), Amount: "3500000", Decimals: 6},
+			{AccountIndex: 3, Mint: mint.String(), Owner: dest.String(), Amount: "1500000", Decimals: 6},
+		},
+		Instructions: []solana.TxInstruction{
+			{ProgramID: sol.ComputeBudget, Data: append([]byte{3}, le64(25_000)...)},
+			{ProgramID: sol.SystemProgramID, Accounts: []sol.PublicKey{payer, dest}, Data: append(le32(2), le64(1_500_000_000)...)},
+			{ProgramID: sol.SPLAssociatedTokenAccountProgramID, Accounts: []sol.PublicKey{payer, dst, dest, mint, sol.SystemProgramID, sol.TokenProgramID},
+				Inner: []solana.TxInstruction{{ProgramID: sol.TokenProgramID, Accounts: []sol.PublicKey{dst, mint}, Data: append([]byte{18}, dest[:]...)}}},
+			{ProgramID: sol.TokenProgramID, Accounts: []sol.PublicKey{src, dst, payer}, Data: append([]byte{3}, le64(1_500_000)...)},
+			{ProgramID: solana.Token2022ProgramID, Accounts: []sol.PublicKey{src, mint, dst, payer}, Data: append(append([]byte{12}, le64(250)...), 2)},
+			{ProgramID: MemoV1ProgramID, Accounts: []sol.PublicKey{payer}, Data: []byte("invoice 42")},
+			{ProgramID: unknown, Accounts: []sol.PublicKey{payer}, Data: []byte{0xde, 0xad}},
+			{ProgramID: sol.SystemProgramID, Accounts: []sol.PublicKey{payer, dest}, Data: le32(2)},
+		},
+	}
+	out := Transaction(tx)
+	if out.Status != "success" || out.FeePayer != payer.String() || out.BlockTime != "2023-11-14T22:13:20Z" || len(out.Instructions) != 8 { t.Fatalf("out=%+v", out) }
+
+	cb := out.Instructions[0]
+	if cb.Program != "compute-budget" || cb.Type != "setComputeUnitPrice" || cb.Info["micro_lamports"] != uint64(25_000) { t.Fatalf("compute budget=%+v", cb) }
+	tr := out.Instructions[1]
+	if tr.Type != "transfer" || tr.Info["source"] != payer.String() || tr.Info["destination"] != dest.String() || tr.Info["sol"] != "1.5" || tr.Data != "" { t.Fatalf("system transfer=%+v", tr) }
+	ata := out.Instructions[2]
+	if ata.Type != "create" || ata.Info["wallet"] != dest.String() || len(ata.Inner) != 1 || ata.Inner[0].Type != "initializeAccount3" || ata.Inner[0].Info["owner"] != dest.String() { t.Fatalf("ata=%+v", ata) }
+	tok := out.Instructions[3]
+	if tok.Type != "transfer" || tok.Info["amount"] != "1500000" || tok.Info["ui_amount"] != "1.5" || tok.Info["mint"] != mint.String() { t.Fatalf("token transfer=%+v", tok) }
+	chk := out.Instructions[4]
+	if chk.Program != "spl-token-2022" || chk.Type != "transferChecked" || chk.Info["ui_amount"] != "2.5" || chk.Info["mint"] != mint.String() { t.Fatalf("transferChecked=%+v", chk) }
+	if m := out.Instructions[5]; m.Program != "spl-memo" || m.Info["memo"] != "invoice 42" { t.Fatalf("memo=%+v", m) }
+	if u := out.Instructions[6]; u.Program != "" || u.Type != "" || u.ProgramID != unknown.String() || u.Data != "dead" || len(u.Accounts) != 1 { t.Fatalf("unknown=%+v", u) }
+	if bad := out.Instructions[7]; bad.Program != "system" || bad.Type != "" || bad.Data != "02000000" || len(bad.Accounts) != 2 { t.Fatalf("truncated=%+v", bad) }
+
+	if len(out.SolChanges) != 2 { t.Fatalf("sol changes=%+v", out.SolChanges) }
+	if c := out.SolChanges[0]; c.Account != payer.String() || c.LamportsDelta != -1_500_005_000 || c.SolDelta != "-1.500005" { t.Fatalf("payer change=%+v", c) }
+	if c := out.SolChanges[1]; c.LamportsDelta != 1_500_000_000 || c.SolDelta != "1.5" { t.Fatalf("dest change=%+v", c) }
+	if len(out.TokenChanges) != 2 { t.Fatalf("token changes=%+v", out.TokenChanges) }
+	if c := out.TokenChanges[0]; c.Account != src.String() || c.Delta != "-1500000" || c.UIDelta != "-1.5" { t.Fatalf("source change=%+v", c) }
+	if c := out.TokenChanges[1]; c.Account != dst.String() || c.PreAmount != "0" || c.Delta != "1500000" || c.UIDelta != "1.5" || c.Owner != dest.String() { t.Fatalf("created account change=%+v", c) }
+}
+
+func TestStakeAndFailedTransaction(t *testing.T) {
+	stake, auth, to := key(1), key(2), key(3)
+	tx := solana.TransactionDetail{
+		Err:      map[string]any{"InstructionError": []any{0, "InsufficientFunds"}},
+		Accounts: []solana.TxAccount{{Address: auth, Signer: true, Writable: true}},
+		Instructions: []solana.TxInstruction{
+			{ProgramID: sol.StakeProgramID, Accounts: []sol.PublicKey{stake, to, sol.SysVarClockPubkey, sol.SysVarStakeHistoryPubkey, auth}, Data: append(le32(4), le64(1_000_000_000)...)},
+			{ProgramID: sol.StakeProgramID, Accounts: []sol.PublicKey{stake, auth}, Data: le32(99)},
+		},
+	}
+	out := Transaction(tx)
+	if out.Status != "failed" || out.Err == nil || len(out.SolChanges) != 0 || len(out.TokenChanges) != 0 { t.Fatalf("out=%+v", out) }
+	w := out.Instructions[0]
+	if w.Program != "stake" || w.Type != "withdraw" || w.Info["destination"] != to.String() || w.Info["lamports"] != uint64(1_000_000_000) || w.Info["sol"] != "1" { t.Fatalf("withdraw=%+v", w) }
+	if _, ok := w.Info["custodian"]; ok { t.Fatalf("optional custodian reported though not passed: %+v", w.Info) }
+	if u := out.Instructions[1]; u.Type != "" || u.Data != "63000000" { t.Fatalf("unknown stake instruction=%+v", u) }
+}
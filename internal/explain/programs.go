@@ -0,0 +1,63 @@
+package explain
+
+import (
+	"errors"
+	"unicode/utf8"
+
+	"github.com/example/solapi/internal/solana"
+)
+
+// decodeAssociatedToken decodes Associated Token Account program
+// instructions. Empty data is the original Create.
+func decodeAssociatedToken(_ *explainer, ix solana.TxInstruction) (string, map[string]any, error) {
+	tag := uint8(0)
+	if len(ix.Data) > 0 {
+		tag = ix.Data[0]
+	}
+	switch tag {
+	case 0, 1:
+		info, err := withAccounts(ix, 6, "source", "account", "wallet", "mint", "system_program", "token_program")
+		if tag == 1 {
+			return "createIdempotent", info, err
+		}
+		return "create", info, err
+	case 2:
+		info, err := withAccounts(ix, 7, "nested_source", "nested_mint", "destination", "nested_owner", "owner_mint", "wallet", "token_program")
+		return "recoverNested", info, err
+	}
+	return "", nil, unknownInstruction(tag)
+}
+
+// decodeComputeBudget decodes Compute Budget program instructions, which
+// start with a u8 discriminator and take no accounts.
+func decodeComputeBudget(_ *explainer, ix solana.TxInstruction) (string, map[string]any, error) {
+	r := &reader{b: ix.Data}
+	tag := r.u8()
+	if r.err != nil {
+		return "", nil, r.err
+	}
+	switch tag {
+	case 0:
+		return "requestUnits", map[string]any{"units": r.u32(), "additional_fee": r.u32()}, r.err
+	case 1:
+		return "requestHeapFrame", map[string]any{"bytes": r.u32()}, r.err
+	case 2:
+		return "setComputeUnitLimit", map[string]any{"units": r.u32()}, r.err
+	case 3:
+		return "setComputeUnitPrice", map[string]any{"micro_lamports": r.u64()}, r.err
+	case 4:
+		return "setLoadedAccountsDataSizeLimit", map[string]any{"bytes": r.u32()}, r.err
+	}
+	return "", nil, unknownInstruction(tag)
+}
+
+// decodeMemo decodes a memo, whose data is the UTF-8 text itself. Any
+// accounts passed are required signers.
+func decodeMemo(_ *explainer, ix solana.TxInstruction) (string, map[string]any, error) {
+	if !utf8.Valid(ix.Data) {
+		return "", nil, errors.New("memo is not valid UTF-8")
+	}
+	info := map[string]any{"memo": string(ix.Data)}
+	signers(info, ix, 0)
+	return "memo", info, nil
+}
@@ -0,0 +1,135 @@
+package explain
+
+import "github.com/example/solapi/internal/solana"
+
+// stakeAuthorize names the StakeAuthorize enum.
+func stakeAuthorize(v uint32) any {
+	switch v {
+	case 0:
+		return "staker"
+	case 1:
+		return "withdrawer"
+	}
+	return v
+}
+
+// decodeStake decodes Stake program instructions, which start with a u32
+// discriminator.
+func decodeStake(_ *explainer, ix solana.TxInstruction) (string, map[string]any, error) {
+	r := &reader{b: ix.Data}
+	tag := r.u32()
+	if r.err != nil {
+		return "", nil, r.err
+	}
+	switch tag {
+	case 0:
+		info, err := withAccounts(ix, 2, "stake_account", "rent_sysvar")
+		if err != nil {
+			return "", nil, err
+		}
+		info["staker"] = r.pubkey()
+		info["withdrawer"] = r.pubkey()
+		info["lockup"] = map[string]any{"unix_timestamp": r.i64(), "epoch": r.u64(), "custodian": r.pubkey()}
+		return "initialize", info, r.err
+	case 1:
+		info, err := withAccounts(ix, 3, "stake_account", "clock_sysvar", "authority", "custodian")
+		if err != nil {
+			return "", nil, err
+		}
+		info["new_authority"] = r.pubkey()
+		info["authority_type"] = stakeAuthorize(r.u32())
+		return "authorize", info, r.err
+	case 2:
+		info, err := withAccounts(ix, 6, "stake_account", "vote_account", "clock_sysvar", "stake_history_sysvar", "stake_config", "stake_authority")
+		return "delegate", info, err
+	case 3:
+		info, err := withAccounts(ix, 3, "stake_account", "new_split_account", "stake_authority")
+		if err != nil {
+			return "", nil, err
+		}
+		setLamports(info, r.u64())
+		return "split", info, r.err
+	case 4:
+		info, err := withAccounts(ix, 5, "stake_account", "destination", "clock_sysvar", "stake_history_sysvar", "withdraw_authority", "custodian")
+		if err != nil {
+			return "", nil, err
+		}
+		setLamports(info, r.u64())
+		return "withdraw", info, r.err
+	case 5:
+		info, err := withAccounts(ix, 3, "stake_account", "clock_sysvar", "stake_authority")
+		return "deactivate", info, err
+	case 6, 12:
+		info, err := withAccounts(ix, 2, "stake_account", "custodian", "new_custodian")
+		if err != nil {
+			return "", nil, err
+		}
+		lockup := map[string]any{}
+		if r.option() {
+			lockup["unix_timestamp"] = r.i64()
+		}
+		if r.option() {
+			lockup["epoch"] = r.u64()
+		}
+		if tag == 12 {
+			info["lockup"] = lockup
+			return "setLockupChecked", info, r.err
+		}
+		if r.option() {
+			lockup["custodian"] = r.pubkey()
+		}
+		info["lockup"] = lockup
+		return "setLockup", info, r.err
+	case 7:
+		info, err := withAccounts(ix, 5, "destination", "source", "clock_sysvar", "stake_history_sysvar", "stake_authority")
+		return "merge", info, err
+	case 8:
+		info, err := withAccounts(ix, 2, "stake_account", "authority_base", "clock_sysvar", "custodian")
+		if err != nil {
+			return "", nil, err
+		}
+		info["new_authority"] = r.pubkey()
+		info["authority_type"] = stakeAuthorize(r.u32())
+		info["authority_seed"] = r.str()
+		info["authority_owner"] = r.pubkey()
+		return "authorizeWithSeed", info, r.err
+	case 9:
+		info, err := withAccounts(ix, 4, "stake_account", "rent_sysvar", "staker", "withdrawer")
+		return "initializeChecked", info, err
+	case 10:
+		info, err := withAccounts(ix, 4, "stake_account", "clock_sysvar", "authority", "new_authority", "custodian")
+		if err != nil {
+			return "", nil, err
+		}
+		info["authority_type"] = stakeAuthorize(r.u32())
+		return "authorizeChecked", info, r.err
+	case 11:
+		info, err := withAccounts(ix, 4, "stake_account", "authority_base", "clock_sysvar", "new_authority", "custodian")
+		if err != nil {
+			return "", nil, err
+		}
+		info["authority_type"] = stakeAuthorize(r.u32())
+		info["authority_seed"] = r.str()
+		info["authority_owner"] = r.pubkey()
+		return "authorizeCheckedWithSeed", info, r.err
+	case 13:
+		return "getMinimumDelegation", map[string]any{}, nil
+	case 14:
+		info, err := withAccounts(ix, 3, "stake_account", "vote_account", "reference_vote_account")
+		return "deactivateDelinquent", info, err
+	case 15:
+		info, err := withAccounts(ix, 5, "stake_account", "new_stake_account", "vote_account", "stake_config", "stake_authority")
+		return "redelegate", info, err
+	case 16, 17:
+		info, err := withAccounts(ix, 3, "source", "destination", "stake_authority")
+		if err != nil {
+			return "", nil, err
+		}
+		setLamports(info, r.u64())
+		if tag == 17 {
+			return "moveLamports", info, r.err
+		}
+		return "moveStake", info, r.err
+	}
+	return "", nil, unknownInstruction(tag)
+}
@@ -0,0 +1,112 @@
+package explain
+
+import "github.com/example/solapi/internal/solana"
+
+// decodeSystem decodes System program instructions, which start with a u32
+// discriminator.
+func decodeSystem(_ *explainer, ix solana.TxInstruction) (string, map[string]any, error) {
+	r := &reader{b: ix.Data}
+	tag := r.u32()
+	if r.err != nil {
+		return "", nil, r.err
+	}
+	switch tag {
+	case 0:
+		info, err := withAccounts(ix, 2, "source", "new_account")
+		if err != nil {
+			return "", nil, err
+		}
+		setLamports(info, r.u64())
+		info["space"] = r.u64()
+		info["owner"] = r.pubkey()
+		return "createAccount", info, r.err
+	case 1:
+		info, err := withAccounts(ix, 1, "account")
+		if err != nil {
+			return "", nil, err
+		}
+		info["owner"] = r.pubkey()
+		return "assign", info, r.err
+	case 2:
+		info, err := withAccounts(ix, 2, "source", "destination")
+		if err != nil {
+			return "", nil, err
+		}
+		setLamports(info, r.u64())
+		return "transfer", info, r.err
+	case 3:
+		info, err := withAccounts(ix, 2, "source", "new_account")
+		if err != nil {
+			return "", nil, err
+		}
+		info["base"] = r.pubkey()
+		info["seed"] = r.str()
+		setLamports(info, r.u64())
+		info["space"] = r.u64()
+		info["owner"] = r.pubkey()
+		return "createAccountWithSeed", info, r.err
+	case 4:
+		info, err := withAccounts(ix, 3, "nonce_account", "recent_blockhashes_sysvar", "nonce_authority")
+		return "advanceNonce", info, err
+	case 5:
+		info, err := withAccounts(ix, 5, "nonce_account", "destination", "recent_blockhashes_sysvar", "rent_sysvar", "nonce_authority")
+		if err != nil {
+			return "", nil, err
+		}
+		setLamports(info, r.u64())
+		return "withdrawFromNonce", info, r.err
+	case 6:
+		info, err := withAccounts(ix, 3, "nonce_account", "recent_blockhashes_sysvar", "rent_sysvar")
+		if err != nil {
+			return "", nil, err
+		}
+		info["nonce_authority"] = r.pubkey()
+		return "initializeNonce", info, r.err
+	case 7:
+		info, err := withAccounts(ix, 2, "nonce_account", "nonce_authority")
+		if err != nil {
+			return "", nil, err
+		}
+		info["new_authority"] = r.pubkey()
+		return "authorizeNonce", info, r.err
+	case 8:
+		info, err := withAccounts(ix, 1, "account")
+		if err != nil {
+			return "", nil, err
+		}
+		info["space"] = r.u64()
+		return "allocate", info, r.err
+	case 9:
+		info, err := withAccounts(ix, 2, "account", "base")
+		if err != nil {
+			return "", nil, err
+		}
+		r.pubkey() // base, also passed as an account
+		info["seed"] = r.str()
+		info["space"] = r.u64()
+		info["owner"] = r.pubkey()
+		return "allocateWithSeed", info, r.err
+	case 10:
+		info, err := withAccounts(ix, 2, "account", "base")
+		if err != nil {
+			return "", nil, err
+		}
+		r.pubkey()
+		info["seed"] = r.str()
+		info["owner"] = r.pubkey()
+		return "assignWithSeed", info, r.err
+	case 11:
+		info, err := withAccounts(ix, 3, "source", "source_base", "destination")
+		if err != nil {
+			return "", nil, err
+		}
+		setLamports(info, r.u64())
+		info["source_seed"] = r.str()
+		info["source_owner"] = r.pubkey()
+		return "transferWithSeed", info, r.err
+	case 12:
+		info, err := withAccounts(ix, 1, "nonce_account")
+		return "upgradeNonce", info, err
+	}
+	return "", nil, unknownInstruction(tag)
+}
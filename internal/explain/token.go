@@ -0,0 +1,274 @@
+package explain
+
+import (
+	"strconv"
+
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/pkg/amount"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// authorityTypes names SetAuthority's authority_type, Token-2022 additions
+// included.
+var authorityTypes = []string{
+	"mintTokens", "freezeAccount", "accountOwner", "closeAccount",
+	"transferFeeConfig", "withheldWithdraw", "closeMint", "interestRate",
+	"permanentDelegate", "confidentialTransferMint", "transferHookProgramId",
+	"confidentialTransferFeeConfig", "metadataPointer", "groupPointer",
+	"groupMemberPointer", "scaledUiAmount", "pause",
+}
+
+// token2022Extensions names the Token-2022 instructions from 25 on. Most are
+// families with their own sub-instruction; only the type is reported.
+var token2022Extensions = []string{
+	"initializeMintCloseAuthority", "transferFeeExtension",
+	"confidentialTransferExtension", "defaultAccountStateExtension",
+	"reallocate", "memoTransferExtension", "createNativeMint",
+	"initializeNonTransferableMint", "interestBearingMintExtension",
+	"cpiGuardExtension", "initializePermanentDelegate",
+	"transferHookExtension", "confidentialTransferFeeExtension",
+	"withdrawExcessLamports", "metadataPointerExtension",
+	"groupPointerExtension", "groupMemberPointerExtension",
+	"confidentialMintBurnExtension", "scaledUiAmountExtension",
+	"pausableExtension",
+}
+
+// decodeToken decodes SPL Token and Token-2022 instructions, which start
+// with a u8 discriminator. Token-2022 shares 0-24 with SPL Token.
+func decodeToken(e *explainer, ix solana.TxInstruction) (string, map[string]any, error) {
+	r := &reader{b: ix.Data}
+	tag := r.u8()
+	if r.err != nil {
+		return "", nil, r.err
+	}
+	acc := func(i int) sol.PublicKey { return ix.Accounts[i] }
+	switch tag {
+	case 0, 20:
+		info, err := withAccounts(ix, 1, "mint", "rent_sysvar")
+		if err != nil {
+			return "", nil, err
+		}
+		info["decimals"] = r.u8()
+		info["mint_authority"] = r.pubkey()
+		if r.option() {
+			info["freeze_authority"] = r.pubkey()
+		}
+		if tag == 20 {
+			delete(info, "rent_sysvar")
+			return "initializeMint2", info, r.err
+		}
+		return "initializeMint", info, r.err
+	case 1:
+		info, err := withAccounts(ix, 3, "account", "mint", "owner", "rent_sysvar")
+		return "initializeAccount", info, err
+	case 2, 19:
+		info, err := withAccounts(ix, 1, "multisig")
+		if err != nil {
+			return "", nil, err
+		}
+		first := 2 // multisig, rent sysvar
+		typ := "initializeMultisig"
+		if tag == 19 {
+			first, typ = 1, "initializeMultisig2"
+		}
+		info["m"] = r.u8()
+		signers(info, ix, first)
+		return typ, info, r.err
+	case 3:
+		info, err := withAccounts(ix, 3, "source", "destination", "authority")
+		if err != nil {
+			return "", nil, err
+		}
+		e.setTokenAmount(info, r.u64(), -1, acc(0), acc(1))
+		signers(info, ix, 3)
+		return "transfer", info, r.err
+	case 4:
+		info, err := withAccounts(ix, 3, "source", "delegate", "owner")
+		if err != nil {
+			return "", nil, err
+		}
+		e.setTokenAmount(info, r.u64(), -1, acc(0))
+		signers(info, ix, 3)
+		return "approve", info, r.err
+	case 5:
+		info, err := withAccounts(ix, 2, "source", "owner")
+		if err != nil {
+			return "", nil, err
+		}
+		signers(info, ix, 2)
+		return "revoke", info, nil
+	case 6:
+		info, err := withAccounts(ix, 2, "account", "authority")
+		if err != nil {
+			return "", nil, err
+		}
+		at := r.u8()
+		if int(at) < len(authorityTypes) {
+			info["authority_type"] = authorityTypes[at]
+		} else {
+			info["authority_type"] = at
+		}
+		info["new_authority"] = nil
+		if r.option() {
+			info["new_authority"] = r.pubkey()
+		}
+		signers(info, ix, 2)
+		return "setAuthority", info, r.err
+	case 7:
+		info, err := withAccounts(ix, 3, "mint", "account", "mint_authority")
+		if err != nil {
+			return "", nil, err
+		}
+		e.setTokenAmount(info, r.u64(), -1, acc(1))
+		signers(info, ix, 3)
+		return "mintTo", info, r.err
+	case 8:
+		info, err := withAccounts(ix, 3, "account", "mint", "authority")
+		if err != nil {
+			return "", nil, err
+		}
+		e.setTokenAmount(info, r.u64(), -1, acc(0))
+		signers(info, ix, 3)
+		return "burn", info, r.err
+	case 9:
+		info, err := withAccounts(ix, 3, "account", "destination", "owner")
+		if err != nil {
+			return "", nil, err
+		}
+		signers(info, ix, 3)
+		return "closeAccount", info, nil
+	case 10, 11:
+		info, err := withAccounts(ix, 3, "account", "mint", "freeze_authority")
+		if err != nil {
+			return "", nil, err
+		}
+		signers(info, ix, 3)
+		if tag == 11 {
+			return "thawAccount", info, nil
+		}
+		return "freezeAccount", info, nil
+	case 12:
+		info, err := withAccounts(ix, 4, "source", "mint", "destination", "authority")
+		if err != nil {
+			return "", nil, err
+		}
+		raw := r.u64()
+		e.setTokenAmount(info, raw, int(r.u8()), acc(0), acc(2))
+		signers(info, ix, 4)
+		return "transferChecked", info, r.err
+	case 13:
+		info, err := withAccounts(ix, 4, "source", "mint", "delegate", "owner")
+		if err != nil {
+			return "", nil, err
+		}
+		raw := r.u64()
+		e.setTokenAmount(info, raw, int(r.u8()), acc(0))
+		signers(info, ix, 4)
+		return "approveChecked", info, r.err
+	case 14:
+		info, err := withAccounts(ix, 3, "mint", "account", "mint_authority")
+		if err != nil {
+			return "", nil, err
+		}
+		raw := r.u64()
+		e.setTokenAmount(info, raw, int(r.u8()), acc(1))
+		signers(info, ix, 3)
+		return "mintToChecked", info, r.err
+	case 15:
+		info, err := withAccounts(ix, 3, "account", "mint", "authority")
+		if err != nil {
+			return "", nil, err
+		}
+		raw := r.u64()
+		e.setTokenAmount(info, raw, int(r.u8()), acc(0))
+		signers(info, ix, 3)
+		return "burnChecked", info, r.err
+	case 16:
+		info, err := withAccounts(ix, 2, "account", "mint", "rent_sysvar")
+		if err != nil {
+			return "", nil, err
+		}
+		info["owner"] = r.pubkey()
+		return "initializeAccount2", info, r.err
+	case 17:
+		info, err := withAccounts(ix, 1, "account")
+		return "syncNative", info, err
+	case 18:
+		info, err := withAccounts(ix, 2, "account", "mint")
+		if err != nil {
+			return "", nil, err
+		}
+		info["owner"] = r.pubkey()
+		return "initializeAccount3", info, r.err
+	case 21:
+		info, err := withAccounts(ix, 1, "mint")
+		return "getAccountDataSize", info, err
+	case 22:
+		info, err := withAccounts(ix, 1, "account")
+		return "initializeImmutableOwner", info, err
+	case 23:
+		info, err := withAccounts(ix, 1, "mint")
+		if err != nil {
+			return "", nil, err
+		}
+		info["amount"] = strconv.FormatUint(r.u64(), 10)
+		return "amountToUiAmount", info, r.err
+	case 24:
+		info, err := withAccounts(ix, 1, "mint")
+		if err != nil {
+			return "", nil, err
+		}
+		info["ui_amount"] = string(r.b)
+		return "uiAmountToAmount", info, nil
+	}
+
+	if !ix.ProgramID.Equals(solana.Token2022ProgramID) || int(tag)-25 >= len(token2022Extensions) {
+		return "", nil, unknownInstruction(tag)
+	}
+	if tag == 26 && len(r.b) > 0 && r.b[0] == 1 {
+		r.u8()
+		info, err := withAccounts(ix, 4, "source", "mint", "destination", "authority")
+		if err != nil {
+			return "", nil, err
+		}
+		raw := r.u64()
+		dec := int(r.u8())
+		e.setTokenAmount(info, raw, dec, acc(0), acc(2))
+		info["fee"] = strconv.FormatUint(r.u64(), 10)
+		signers(info, ix, 4)
+		return "transferCheckedWithFee", info, r.err
+	}
+	return token2022Extensions[tag-25], map[string]any{"accounts": addresses(ix.Accounts)}, nil
+}
+
+// signers lists the multisig signers passed after the first n accounts.
+func signers(info map[string]any, ix solana.TxInstruction, n int) {
+	if len(ix.Accounts) > n {
+		info["signers"] = addresses(ix.Accounts[n:])
+	}
+}
+
+// setTokenAmount records a token amount in base units, and in whole tokens
+// when the decimals are known: from the instruction itself (decimals >= 0)
+// or from the reported balance of one of the token accounts. It also fills
+// in the mint when the instruction does not name it.
+func (e *explainer) setTokenAmount(info map[string]any, raw uint64, decimals int, accounts ...sol.PublicKey) {
+	info["amount"] = strconv.FormatUint(raw, 10)
+	for _, a := range accounts {
+		b, ok := e.tokens[a]
+		if !ok {
+			continue
+		}
+		if _, ok := info["mint"]; !ok {
+			info["mint"] = b.Mint
+		}
+		if decimals < 0 {
+			decimals = int(b.Decimals)
+		}
+		break
+	}
+	if decimals >= 0 {
+		info["decimals"] = decimals
+		info["ui_amount"] = amount.Format(raw, uint8(decimals))
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// accountNamespace prefixes cache keys holding account metadata, which is
+// keyed by (address, commitment).
+const accountNamespace = "acct"
+
+// AccountInfoDeps bundles dependencies needed by the account info handler.
+type AccountInfoDeps struct {
+	Cache          *cache.Cache
+	Fetcher        solana.AccountInfoFetcher
+	TTL            time.Duration
+	Timeout        time.Duration
+	MaxConcurrency int
+	// Commitment is used when a request does not name one; empty means finalized.
+	Commitment rpc.CommitmentType
+}
+
+// AccountInfoHandler serves account metadata for a list of addresses.
+type AccountInfoHandler struct{ Deps AccountInfoDeps }
+
+func NewAccountInfoHandler(deps AccountInfoDeps) *AccountInfoHandler {
+	return &AccountInfoHandler{Deps: deps}
+}
+
+func accountKey(address string, cm rpc.CommitmentType) string {
+	return cache.Key(accountNamespace, address, string(cm))
+}
+
+// commitment resolves the requested commitment level, falling back to the
+// configured default.
+func (h *AccountInfoHandler) commitment(requested string) (rpc.CommitmentType, bool) {
+	if requested != "" {
+		return solana.ParseCommitment(requested)
+	}
+	if h.Deps.Commitment != "" {
+		return h.Deps.Commitment, true
+	}
+	return rpc.CommitmentFinalized, true
+}
+
+func (h *AccountInfoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req types.GetBalanceRequest
+	valid, invalid, ok := readWallets(w, r, &req)
+	if !ok {
+		return
+	}
+	cm, ok := h.commitment(req.Commitment)
+	if !ok {
+		http.Error(w, `{"error":"invalid commitment"}`, http.StatusBadRequest)
+		return
+	}
+	resp := types.GetAccountInfoResponse{Accounts: make([]types.AccountInfoEntry, 0, len(valid)), Errors: invalid}
+	h.lookup(r.Context(), cm, valid, &resp)
+
+	sort.Slice(resp.Accounts, func(i, j int) bool { return resp.Accounts[i].Address < resp.Accounts[j].Address })
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// lookup resolves valid addresses at cm into resp, serving cache hits
+// directly and the rest in chunks of solana.MaxMultipleAccounts.
+func (h *AccountInfoHandler) lookup(ctx context.Context, cm rpc.CommitmentType, valid []string, resp *types.GetAccountInfoResponse) {
+	misses := make([]string, 0, len(valid))
+	for _, addr := range valid {
+		if val, ok := h.Deps.Cache.Get(accountKey(addr, cm)); ok {
+			resp.Accounts = append(resp.Accounts, accountEntry(addr, cm, val, "cache"))
+			continue
+		}
+		misses = append(misses, addr)
+	}
+
+	sem := make(chan struct{}, h.Deps.MaxConcurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for i := 0; i < len(misses); i += solana.MaxMultipleAccounts {
+		end := min(i+solana.MaxMultipleAccounts, len(misses))
+		chunk := misses[i:end]
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			pks := make([]sol.PublicKey, len(chunk))
+			for j, addr := range chunk {
+				pks[j], _ = parsePubkey(addr)
+			}
+			ctx, cancel := context.WithTimeout(ctx, h.Deps.Timeout)
+			defer cancel()
+			infos, latency, err := h.Deps.Fetcher.GetAccountInfos(ctx, pks, cm)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				for _, addr := range chunk {
+					resp.Errors = append(resp.Errors, walletError(addr, err))
+				}
+				return
+			}
+			log.Printf("event=rpc_fetch_account_info addresses=%d commitment=%s latency_ms=%d", len(chunk), cm, latency.Milliseconds())
+			now := time.Now().UTC()
+			for j, addr := range chunk {
+				info := infos[j]
+				val := h.Deps.Cache.Set(accountKey(addr, cm), cache.Value{Lamports: info.Lamports, Slot: info.Slot, FetchedAt: now, Data: info, TTL: h.Deps.TTL, Missing: !info.Exists})
+				resp.Accounts = append(resp.Accounts, accountEntry(addr, cm, val, "rpc"))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// accountEntry renders cached account metadata read at cm as a response entry.
+func accountEntry(address string, cm rpc.CommitmentType, val cache.Value, source string) types.AccountInfoEntry {
+	pk, _ := parsePubkey(address)
+	info, _ := val.Data.(solana.AccountInfo)
+	e := types.AccountInfoEntry{
+		Address:    address,
+		Exists:     info.Exists,
+		OnCurve:    pk.IsOnCurve(),
+		Source:     source,
+		FetchedAt:  val.FetchedAt.Format(time.RFC3339),
+		Commitment: string(cm),
+		Slot:       val.Slot,
+	}
+	if info.Exists {
+		minimum := solana.RentExemptMinimum(info.DataLen)
+		e.Account = &types.AccountDetails{
+			Kind:              info.Kind,
+			Owner:             info.Owner.String(),
+			Executable:        info.Executable,
+			Lamports:          info.Lamports,
+			DataLength:        info.DataLen,
+			RentEpoch:         info.RentEpoch,
+			RentExempt:        info.Lamports >= minimum,
+			RentExemptMinimum: minimum,
+		}
+	}
+	return e
+}
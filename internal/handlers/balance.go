@@ -3,20 +3,27 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
-	"math"
 	"net/http"
 	"sort"
 	"sync"
 	"time"
 
 	"github.com/example/solapi/internal/cache"
+	"github.com/example/solapi/internal/pricing"
 	"github.com/example/solapi/internal/solana"
 	"github.com/example/solapi/internal/types"
 	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
 )
 
-const lamportsPerSOL = 1_000_000_000.0
+// balanceNamespace prefixes cache keys holding native balances, which are
+// keyed by (wallet, commitment).
+const balanceNamespace = "bal"
+
+// stakeNamespace prefixes cache keys holding a wallet's stake accounts.
+const stakeNamespace = "stake"
 
 // BalanceDeps bundles dependencies needed by the handler.
 type BalanceDeps struct {
@@ -24,11 +31,59 @@ type BalanceDeps struct {
 	Fetcher        solana.BalanceFetcher
 	Timeout        time.Duration
 	MaxConcurrency int
+	// Commitment is used when a request does not name one; empty means finalized.
+	Commitment rpc.CommitmentType
+	// ServeStale answers from expired cache entries, marked source "stale",
+	// while the fetcher's circuit breaker is open.
+	ServeStale bool
+	// Stake serves include_stake requests; when nil the flag is ignored.
+	Stake    solana.StakeFetcher
+	StakeTTL time.Duration
+	// LiveTTL is how long a balance pushed by Update stays cached; zero
+	// means the cache default.
+	LiveTTL time.Duration
+	// Hub, when set, receives pushed balances for streaming clients.
+	Hub *Hub
+	// Prices serves quote requests; when nil the field is ignored.
+	Prices *pricing.Pricer
 }
 
-type BalanceHandler struct{ Deps BalanceDeps }
+type BalanceHandler struct {
+	Deps BalanceDeps
+
+	// flights holds the balance keys fetchBatched is reading upstream, so
+	// concurrent requests for a wallet share one read as fetchEach's do
+	// through the cache's singleflight.
+	flightMu sync.Mutex
+	flights  map[string]*balanceFlight
+}
+
+// balanceFlight is one wallet's pending batched read; done is closed once
+// val or err is set. chunkErr marks an error that failed the whole upstream
+// call rather than this wallet alone.
+type balanceFlight struct {
+	done     chan struct{}
+	val      cache.Value
+	err      error
+	chunkErr bool
+}
 
-func NewBalanceHandler(deps BalanceDeps) *BalanceHandler { return &BalanceHandler{Deps: deps} }
+func NewBalanceHandler(deps BalanceDeps) *BalanceHandler {
+	return &BalanceHandler{Deps: deps, flights: make(map[string]*balanceFlight)}
+}
+
+// Update stores a balance pushed by a live subscription, so reads of a
+// watched wallet are served from cache without RPC calls. A push older than
+// the cached reading is dropped by the cache's slot check.
+func (h *BalanceHandler) Update(pubkey sol.PublicKey, cm rpc.CommitmentType, bal solana.Balance) {
+	wstr := pubkey.String()
+	val := h.Deps.Cache.Set(balanceKey(wstr, cm), cache.Value{Lamports: bal.Lamports, Slot: bal.Slot, FetchedAt: time.Now().UTC(), TTL: h.Deps.LiveTTL, Missing: !bal.Exists})
+	log.Printf("event=balance_push wallet=%s commitment=%s slot=%d", wstr, cm, bal.Slot)
+	// streams follow the default commitment only
+	if def, _ := h.commitment(""); h.Deps.Hub != nil && cm == def && val.Slot == bal.Slot {
+		h.Deps.Hub.Publish(balanceEntry(wstr, cm, val, "live"))
+	}
+}
 
 func dedupe(in []string) []string {
 	m := make(map[string]struct{}, len(in))
@@ -43,6 +98,10 @@ func dedupe(in []string) []string {
 	return out
 }
 
+func balanceKey(wallet string, cm rpc.CommitmentType) string {
+	return cache.Key(balanceNamespace, wallet, string(cm))
+}
+
 func parsePubkey(s string) (sol.PublicKey, bool) {
 	pk, err := sol.PublicKeyFromBase58(s)
 	if err != nil {
@@ -51,33 +110,19 @@ func parsePubkey(s string) (sol.PublicKey, bool) {
 	return pk, true
 }
 
-func (h *BalanceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	var req types.GetBalanceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"bad request"}`, http.StatusBadRequest)
-		return
-	}
-	if len(req.Wallets) == 0 {
-		http.Error(w, `{"error":"wallets required"}`, http.StatusBadRequest)
-		return
-	}
-	if len(req.Wallets) > 100 {
-		http.Error(w, `{"error":"too many wallets"}`, http.StatusBadRequest)
+// lookup resolves valid wallets at cm into resp, batched when the fetcher
+// supports it.
+func (h *BalanceHandler) lookup(ctx context.Context, cm rpc.CommitmentType, valid []string, resp *types.GetBalanceResponse) {
+	if bf, ok := h.Deps.Fetcher.(solana.BatchBalanceFetcher); ok {
+		h.fetchBatched(ctx, bf, cm, valid, resp)
 		return
 	}
+	h.fetchEach(ctx, cm, valid, resp)
+}
 
-	wallets := dedupe(req.Wallets)
-	resp := types.GetBalanceResponse{Balances: make([]types.BalanceEntry, 0, len(wallets))}
-
-	// parse & collect invalids
-	valid := make([]string, 0, len(wallets))
-	for _, wstr := range wallets {
-		if _, ok := parsePubkey(wstr); !ok {
-			resp.Errors = append(resp.Errors, types.ErrorEntry{Wallet: wstr, Error: "invalid public key"})
-			continue
-		}
-		valid = append(valid, wstr)
-	}
+// fetchEach resolves wallets one upstream call at a time, coalescing
+// concurrent misses per wallet through the cache.
+func (h *BalanceHandler) fetchEach(ctx context.Context, cm rpc.CommitmentType, valid []string, resp *types.GetBalanceResponse) {
 	// concurrency control
 	sem := make(chan struct{}, h.Deps.MaxConcurrency)
 	var wg sync.WaitGroup
@@ -89,41 +134,317 @@ func (h *BalanceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		go func() {
 			defer func() { <-sem; wg.Done() }()
 			pk, _ := parsePubkey(wstr)
-			ctx, cancel := context.WithTimeout(r.Context(), h.Deps.Timeout)
+			ctx, cancel := context.WithTimeout(ctx, h.Deps.Timeout)
 			defer cancel()
-			val, source, err := h.Deps.Cache.GetOrFetch(ctx, wstr, func(ctx context.Context) (cache.Value, error) {
-				lamports, latency, err := h.Deps.Fetcher.GetBalance(ctx, pk)
+			val, source, err := h.Deps.Cache.GetOrFetch(ctx, balanceKey(wstr, cm), func(ctx context.Context) (cache.Value, error) {
+				bal, latency, err := h.Deps.Fetcher.GetBalance(ctx, pk, cm)
 				if err != nil {
 					return cache.Value{}, err
 				}
 				// log rpc latency only on miss
-				log.Printf("event=rpc_fetch wallet=%s latency_ms=%d", wstr, latency.Milliseconds())
-				return cache.Value{Lamports: lamports, FetchedAt: time.Now().UTC()}, nil
+				log.Printf("event=rpc_fetch wallet=%s commitment=%s slot=%d latency_ms=%d", wstr, cm, bal.Slot, latency.Milliseconds())
+				return cache.Value{Lamports: bal.Lamports, Slot: bal.Slot, FetchedAt: time.Now().UTC(), Missing: !bal.Exists}, nil
 			})
+			mu.Lock()
+			defer mu.Unlock()
 			if err != nil {
-				mu.Lock()
-				resp.Errors = append(resp.Errors, types.ErrorEntry{Wallet: wstr, Error: err.Error()})
-				mu.Unlock()
+				h.failWallet(wstr, cm, err, resp)
 				return
 			}
-			solAmt := float64(val.Lamports) / lamportsPerSOL
-			// avoid -0
-			if solAmt == 0 {
-				solAmt = 0
+			resp.Balances = append(resp.Balances, balanceEntry(wstr, cm, val, source))
+			log.Printf("event=balance wallet=%s commitment=%s source=%s", wstr, cm, source)
+		}()
+	}
+	wg.Wait()
+}
+
+// fetchBatched serves cache hits directly and resolves the remaining wallets
+// in chunks of solana.MaxMultipleAccounts, one upstream call per chunk.
+// Wallets another request is already reading wait for that read instead.
+func (h *BalanceHandler) fetchBatched(ctx context.Context, bf solana.BatchBalanceFetcher, cm rpc.CommitmentType, valid []string, resp *types.GetBalanceResponse) {
+	misses := make([]string, 0, len(valid))
+	joined := make(map[string]*balanceFlight)
+	h.flightMu.Lock()
+	for _, wstr := range valid {
+		key := balanceKey(wstr, cm)
+		if val, ok := h.Deps.Cache.Get(key); ok {
+			resp.Balances = append(resp.Balances, balanceEntry(wstr, cm, val, "cache"))
+			log.Printf("event=balance wallet=%s commitment=%s source=cache", wstr, cm)
+			continue
+		}
+		if f, ok := h.flights[key]; ok {
+			joined[wstr] = f
+			continue
+		}
+		h.flights[key] = &balanceFlight{done: make(chan struct{})}
+		misses = append(misses, wstr)
+	}
+	h.flightMu.Unlock()
+
+	sem := make(chan struct{}, h.Deps.MaxConcurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for i := 0; i < len(misses); i += solana.MaxMultipleAccounts {
+		end := i + solana.MaxMultipleAccounts
+		if end > len(misses) {
+			end = len(misses)
+		}
+		chunk := misses[i:end]
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			pks := make([]sol.PublicKey, len(chunk))
+			for j, wstr := range chunk {
+				pks[j], _ = parsePubkey(wstr)
 			}
+			ctx, cancel := context.WithTimeout(ctx, h.Deps.Timeout)
+			defer cancel()
+			results, latency, err := bf.GetBalances(ctx, pks, cm)
+			flights := make([]balanceFlight, len(chunk))
+			if err != nil {
+				for j := range chunk {
+					flights[j] = balanceFlight{err: err, chunkErr: true}
+				}
+			} else {
+				log.Printf("event=rpc_fetch_batch wallets=%d commitment=%s latency_ms=%d", len(chunk), cm, latency.Milliseconds())
+				now := time.Now().UTC()
+				for j, wstr := range chunk {
+					if res := results[j]; res.Err != nil {
+						flights[j] = balanceFlight{err: res.Err}
+					} else {
+						// an entry from a later slot wins over this reading
+						flights[j] = balanceFlight{val: h.Deps.Cache.Set(balanceKey(wstr, cm), cache.Value{Lamports: res.Lamports, Slot: res.Slot, FetchedAt: now, Missing: !res.Exists})}
+					}
+				}
+			}
+			h.land(cm, chunk, flights)
 			mu.Lock()
-			resp.Balances = append(resp.Balances, types.BalanceEntry{
-				Wallet:    wstr,
-				Lamports:  val.Lamports,
-				Sol:       math.Round(solAmt*1e9) / 1e9,
-				Source:    source,
-				FetchedAt: val.FetchedAt.Format(time.RFC3339),
+			defer mu.Unlock()
+			for j, wstr := range chunk {
+				h.flightResult(wstr, cm, &flights[j], resp)
+			}
+		}()
+	}
+	for wstr, f := range joined {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait, cancel := context.WithTimeout(ctx, h.Deps.Timeout)
+			defer cancel()
+			select {
+			case <-f.done:
+			case <-wait.Done():
+				mu.Lock()
+				defer mu.Unlock()
+				h.failWallet(wstr, cm, wait.Err(), resp)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			h.flightResult(wstr, cm, f, resp)
+		}()
+	}
+	wg.Wait()
+}
+
+// land completes the flights of chunk with their results and releases the
+// keys for later reads.
+func (h *BalanceHandler) land(cm rpc.CommitmentType, chunk []string, results []balanceFlight) {
+	h.flightMu.Lock()
+	defer h.flightMu.Unlock()
+	for j, wstr := range chunk {
+		key := balanceKey(wstr, cm)
+		f := h.flights[key]
+		delete(h.flights, key)
+		f.val, f.err, f.chunkErr = results[j].val, results[j].err, results[j].chunkErr
+		close(f.done)
+	}
+}
+
+// flightResult records a completed batched read of wstr in resp.
+func (h *BalanceHandler) flightResult(wstr string, cm rpc.CommitmentType, f *balanceFlight, resp *types.GetBalanceResponse) {
+	switch {
+	case f.chunkErr:
+		h.failWallet(wstr, cm, f.err, resp)
+	case f.err != nil:
+		resp.Errors = append(resp.Errors, walletError(wstr, f.err))
+	default:
+		resp.Balances = append(resp.Balances, balanceEntry(wstr, cm, f.val, "rpc"))
+		log.Printf("event=balance wallet=%s commitment=%s source=rpc", wstr, cm)
+	}
+}
+
+// failWallet records a failed lookup, or serves the last known balance when
+// the breaker is open and stale serving is on. Caller holds the response lock.
+func (h *BalanceHandler) failWallet(wstr string, cm rpc.CommitmentType, err error, resp *types.GetBalanceResponse) {
+	if h.Deps.ServeStale && errors.Is(err, solana.ErrCircuitOpen) {
+		if val, ok := h.Deps.Cache.GetStale(balanceKey(wstr, cm)); ok {
+			resp.Balances = append(resp.Balances, balanceEntry(wstr, cm, val, "stale"))
+			log.Printf("event=balance wallet=%s commitment=%s source=stale", wstr, cm)
+			return
+		}
+	}
+	resp.Errors = append(resp.Errors, walletError(wstr, err))
+}
+
+// attachStake adds a stake summary to every balance entry. A failed stake
+// lookup keeps the native balance and reports the failure in Errors.
+func (h *BalanceHandler) attachStake(ctx context.Context, resp *types.GetBalanceResponse) {
+	sem := make(chan struct{}, h.Deps.MaxConcurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for i := range resp.Balances {
+		be := &resp.Balances[i]
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			pk, _ := parsePubkey(be.Wallet)
+			ctx, cancel := context.WithTimeout(ctx, h.Deps.Timeout)
+			defer cancel()
+			val, source, err := h.Deps.Cache.GetOrFetchTTL(ctx, cache.Key(stakeNamespace, be.Wallet), h.Deps.StakeTTL, func(ctx context.Context) (cache.Value, error) {
+				accounts, latency, err := h.Deps.Stake.GetStakeAccounts(ctx, pk)
+				if err != nil {
+					return cache.Value{}, err
+				}
+				log.Printf("event=rpc_fetch_stake wallet=%s accounts=%d latency_ms=%d", be.Wallet, len(accounts), latency.Milliseconds())
+				return cache.Value{Data: accounts, FetchedAt: time.Now().UTC()}, nil
 			})
-			mu.Unlock()
-			log.Printf("event=balance wallet=%s source=%s", wstr, source)
+			if err != nil {
+				mu.Lock()
+				entry := walletError(be.Wallet, err)
+				entry.Error = "stake lookup: " + entry.Error
+				resp.Errors = append(resp.Errors, entry)
+				mu.Unlock()
+				return
+			}
+			accounts, _ := val.Data.([]solana.StakeAccount)
+			summary := summarizeStake(accounts)
+			summary.Source = source
+			summary.FetchedAt = val.FetchedAt.Format(time.RFC3339)
+			// each goroutine owns its entry; no lock needed
+			be.Stake = &summary
+			be.TotalLamports = be.Lamports
+			for _, a := range accounts {
+				be.TotalLamports += a.Lamports
+			}
 		}()
 	}
 	wg.Wait()
+}
+
+func summarizeStake(accounts []solana.StakeAccount) types.StakeSummary {
+	s := types.StakeSummary{Accounts: len(accounts)}
+	for _, a := range accounts {
+		switch a.State {
+		case solana.StakeActive:
+			s.Active += a.Delegated
+		case solana.StakeActivating:
+			s.Activating += a.Delegated
+		case solana.StakeDeactivating:
+			s.Deactivating += a.Delegated
+		default:
+			s.Inactive += a.Delegated
+		}
+		// undelegated remainder (rent reserve, excess) is never staked
+		s.Inactive += a.Lamports - a.Delegated
+	}
+	return s
+}
+
+// balanceEntry renders a cached value read at cm as a response entry.
+func balanceEntry(wallet string, cm rpc.CommitmentType, val cache.Value, source string) types.BalanceEntry {
+	return types.BalanceEntry{
+		Wallet:     wallet,
+		Lamports:   val.Lamports,
+		Exists:     !val.Missing,
+		Sol:        solFloat(val.Lamports),
+		Source:     source,
+		FetchedAt:  val.FetchedAt.Format(time.RFC3339),
+		Commitment: string(cm),
+		Slot:       val.Slot,
+	}
+}
+
+// maxWallets caps the number of wallets accepted per request.
+const maxWallets = 100
+
+// readWallets decodes a GetBalanceRequest, dedupes its wallets and splits out
+// invalid public keys. On a malformed request it writes the 400 response and
+// returns ok=false.
+func readWallets(w http.ResponseWriter, r *http.Request, req *types.GetBalanceRequest) (valid []string, invalid []types.ErrorEntry, ok bool) {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, `{"error":"bad request"}`, http.StatusBadRequest)
+		return nil, nil, false
+	}
+	return checkWallets(w, req.Wallets)
+}
+
+// checkWallets applies the wallet count limits, dedupes and validates keys.
+// It writes the 400 response and returns ok=false when the list is unusable.
+func checkWallets(w http.ResponseWriter, in []string) (valid []string, invalid []types.ErrorEntry, ok bool) {
+	if len(in) == 0 {
+		http.Error(w, `{"error":"wallets required"}`, http.StatusBadRequest)
+		return nil, nil, false
+	}
+	if len(in) > maxWallets {
+		http.Error(w, `{"error":"too many wallets"}`, http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	wallets := dedupe(in)
+	valid = make([]string, 0, len(wallets))
+	for _, wstr := range wallets {
+		if _, ok := parsePubkey(wstr); !ok {
+			invalid = append(invalid, invalidPubkey(wstr))
+			continue
+		}
+		valid = append(valid, wstr)
+	}
+	return valid, invalid, true
+}
+
+// commitment resolves the requested commitment level, falling back to the
+// configured default.
+func (h *BalanceHandler) commitment(requested string) (rpc.CommitmentType, bool) {
+	if requested != "" {
+		return solana.ParseCommitment(requested)
+	}
+	if h.Deps.Commitment != "" {
+		return h.Deps.Commitment, true
+	}
+	return rpc.CommitmentFinalized, true
+}
+
+func (h *BalanceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req types.GetBalanceRequest
+	valid, invalid, ok := readWallets(w, r, &req)
+	if !ok {
+		return
+	}
+	cm, ok := h.commitment(req.Commitment)
+	if !ok {
+		http.Error(w, `{"error":"invalid commitment"}`, http.StatusBadRequest)
+		return
+	}
+	currency, ok := quoteCurrency(w, req.Quote)
+	if !ok {
+		return
+	}
+	units, ok := amountUnits(w, req.Units)
+	if !ok {
+		return
+	}
+	resp := types.GetBalanceResponse{Balances: make([]types.BalanceEntry, 0, len(valid)), Errors: invalid}
+	h.lookup(r.Context(), cm, valid, &resp)
+	if req.IncludeStake && h.Deps.Stake != nil {
+		h.attachStake(r.Context(), &resp)
+	}
+	if currency != "" && h.Deps.Prices != nil {
+		h.attachFiat(r.Context(), currency, &resp)
+	}
+	applyBalanceUnits(units, resp.Balances)
 
 	// sort by wallet for deterministic tests
 	sort.Slice(resp.Balances, func(i, j int) bool { return resp.Balances[i].Wallet < resp.Balances[j].Wallet })
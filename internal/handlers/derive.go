@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	"github.com/example/solapi/pkg/jsonutil"
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/mr-tron/base58"
+)
+
+// AssociatedTokenHandler derives associated token accounts and reports
+// whether they exist.
+//
+//	POST /api/associated-token-account {"owner":..., "mint":..., "token_program":...}
+type AssociatedTokenHandler struct {
+	// Accounts checks existence through the same cache and fetch path as
+	// /api/account-info.
+	Accounts *AccountInfoHandler
+}
+
+func NewAssociatedTokenHandler(accounts *AccountInfoHandler) *AssociatedTokenHandler {
+	return &AssociatedTokenHandler{Accounts: accounts}
+}
+
+// pubkeyField parses a request field holding a public key. It writes the 400
+// response naming the field and returns ok=false when invalid.
+func pubkeyField(w http.ResponseWriter, name, value string) (sol.PublicKey, bool) {
+	pk, ok := parsePubkey(value)
+	if !ok {
+		jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "invalid " + name})
+	}
+	return pk, ok
+}
+
+func (h *AssociatedTokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonutil.JSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	var req types.AssociatedTokenAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "bad request"})
+		return
+	}
+	owner, ok := pubkeyField(w, "owner", req.Owner)
+	if !ok {
+		return
+	}
+	mint, ok := pubkeyField(w, "mint", req.Mint)
+	if !ok {
+		return
+	}
+	program, ok := solana.TokenProgram(req.TokenProgram)
+	if !ok {
+		jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "invalid token_program"})
+		return
+	}
+	cm, ok := h.Accounts.commitment(req.Commitment)
+	if !ok {
+		jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "invalid commitment"})
+		return
+	}
+	ata, bump, err := solana.AssociatedTokenAddress(owner, mint, program)
+	if err != nil {
+		// only when no bump yields an off-curve address, which never happens
+		log.Printf("event=derive_error owner=%s mint=%s err=%q", owner, mint, err)
+		jsonutil.JSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	var found types.GetAccountInfoResponse
+	h.Accounts.lookup(r.Context(), cm, []string{ata.String()}, &found)
+	if len(found.Accounts) == 0 {
+		e := found.Errors[0]
+		jsonutil.JSON(w, http.StatusBadGateway, map[string]any{"error": e.Error, "code": e.Code, "retryable": e.Retryable})
+		return
+	}
+	acc := found.Accounts[0]
+	jsonutil.JSON(w, http.StatusOK, types.AssociatedTokenAccountResponse{
+		Address:      ata.String(),
+		Bump:         bump,
+		Owner:        owner.String(),
+		Mint:         mint.String(),
+		TokenProgram: program.String(),
+		Exists:       acc.Exists,
+		Account:      acc.Account,
+		Source:       acc.Source,
+		Commitment:   acc.Commitment,
+		Slot:         acc.Slot,
+	})
+}
+
+// ProgramAddressHandler finds program derived addresses. It makes no RPC
+// calls.
+//
+//	POST /api/find-program-address {"program_id":..., "seeds":[{"value":..., "encoding":...}]}
+type ProgramAddressHandler struct{}
+
+func NewProgramAddressHandler() *ProgramAddressHandler { return &ProgramAddressHandler{} }
+
+// decodeSeed decodes one seed value in its encoding.
+func decodeSeed(s types.ProgramAddressSeed) ([]byte, error) {
+	var b []byte
+	var err error
+	switch s.Encoding {
+	case "", types.SeedUTF8:
+		b = []byte(s.Value)
+	case types.SeedHex:
+		b, err = hex.DecodeString(s.Value)
+	case types.SeedBase58:
+		b, err = base58.Decode(s.Value)
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", s.Encoding)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("not valid %s", s.Encoding)
+	}
+	if len(b) > sol.MaxSeedLength {
+		return nil, fmt.Errorf("longer than %d bytes", sol.MaxSeedLength)
+	}
+	return b, nil
+}
+
+func (h *ProgramAddressHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonutil.JSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	var req types.FindProgramAddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "bad request"})
+		return
+	}
+	program, ok := pubkeyField(w, "program_id", req.ProgramID)
+	if !ok {
+		return
+	}
+	if len(req.Seeds) > solana.MaxUserSeeds {
+		jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("at most %d seeds", solana.MaxUserSeeds)})
+		return
+	}
+	seeds := make([][]byte, len(req.Seeds))
+	for i, s := range req.Seeds {
+		b, err := decodeSeed(s)
+		if err != nil {
+			jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("seed %d: %v", i, err)})
+			return
+		}
+		seeds[i] = b
+	}
+	addr, bump, err := sol.FindProgramAddress(seeds, program)
+	if err != nil {
+		jsonutil.JSON(w, http.StatusUnprocessableEntity, map[string]string{"error": "no program address found for these seeds"})
+		return
+	}
+	jsonutil.JSON(w, http.StatusOK, types.FindProgramAddressResponse{Address: addr.String(), Bump: bump, ProgramID: program.String()})
+}
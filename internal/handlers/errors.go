@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+)
+
+// classify maps a lookup error onto the public error taxonomy. Messages are
+// fixed so upstream URLs, keys and node output never reach clients.
+func classify(err error) (code, message string, retryable bool) {
+	var rl *solana.RateLimitedError
+	var ne net.Error
+	switch {
+	case errors.Is(err, solana.ErrCircuitOpen):
+		return types.CodeCircuitOpen, "upstream disabled after repeated failures", true
+	case errors.As(err, &rl):
+		return types.CodeUpstreamRateLimited, "upstream rate limit reached", true
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &ne) && ne.Timeout():
+		return types.CodeUpstreamTimeout, "upstream did not respond in time", true
+	case solana.Retryable(err):
+		return types.CodeUpstreamUnavailable, "upstream temporarily unavailable", true
+	}
+	return types.CodeInternal, "internal error", false
+}
+
+// walletError renders a failed lookup for wallet as a response entry. The
+// underlying error only goes to the log, which is redacted.
+func walletError(wallet string, err error) types.ErrorEntry {
+	code, msg, retryable := classify(err)
+	log.Printf("event=lookup_error wallet=%s code=%s err=%q", wallet, code, err)
+	return types.ErrorEntry{Wallet: wallet, Code: code, Error: msg, Retryable: retryable, Attempts: solana.Attempts(err)}
+}
+
+func invalidPubkey(wallet string) types.ErrorEntry {
+	return types.ErrorEntry{Wallet: wallet, Code: types.CodeInvalidPubkey, Error: "invalid public key"}
+}
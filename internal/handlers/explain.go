@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	"github.com/example/solapi/internal/explain"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/pkg/jsonutil"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// txDetailNamespace prefixes cache keys holding a whole fetched transaction.
+const txDetailNamespace = "txdetail"
+
+// TransactionDetailDeps bundles dependencies needed by the transaction
+// explain handler.
+type TransactionDetailDeps struct {
+	// Cache holds whole transactions, keyed by caller-supplied signatures;
+	// give it a cache of its own bounded with WithMaxEntries.
+	Cache   *cache.Cache
+	Fetcher solana.TransactionDetailFetcher
+	// TTL applies to transactions that are not finalized yet; finalized ones
+	// are kept until Cache evicts them.
+	TTL     time.Duration
+	Timeout time.Duration
+}
+
+// TransactionDetailHandler explains a single transaction: its decoded
+// instructions and the SOL and token balance changes it made.
+//
+//	GET /api/transactions/<signature>
+type TransactionDetailHandler struct{ Deps TransactionDetailDeps }
+
+func NewTransactionDetailHandler(deps TransactionDetailDeps) *TransactionDetailHandler {
+	return &TransactionDetailHandler{Deps: deps}
+}
+
+func (h *TransactionDetailHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		jsonutil.JSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	s := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/transactions"), "/")
+	sig, err := sol.SignatureFromBase58(s)
+	if err != nil {
+		jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "invalid signature"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.Deps.Timeout)
+	defer cancel()
+	val, source, err := h.Deps.Cache.GetOrFetchTTL(ctx, cache.Key(txDetailNamespace, s), h.Deps.TTL, func(ctx context.Context) (cache.Value, error) {
+		tx, latency, err := h.Deps.Fetcher.GetTransaction(ctx, sig)
+		if err != nil {
+			return cache.Value{}, err
+		}
+		log.Printf("event=rpc_fetch_tx_detail sig=%s latency_ms=%d", s, latency.Milliseconds())
+		v := cache.Value{Data: tx, Slot: tx.Slot, FetchedAt: time.Now().UTC()}
+		if tx.Finalized {
+			v.TTL = cache.NoExpiry
+		}
+		return v, nil
+	})
+	if errors.Is(err, solana.ErrTransactionNotFound) {
+		jsonutil.JSON(w, http.StatusNotFound, map[string]string{"error": "transaction not found"})
+		return
+	}
+	if err != nil {
+		code, msg, retryable := classify(err)
+		log.Printf("event=rpc_fetch_tx_detail_error sig=%s code=%s err=%q", s, code, err)
+		jsonutil.JSON(w, http.StatusBadGateway, map[string]any{"error": msg, "code": code, "retryable": retryable})
+		return
+	}
+	tx, _ := val.Data.(solana.TransactionDetail)
+	resp := explain.Transaction(tx)
+	resp.Source = source
+	jsonutil.JSON(w, http.StatusOK, resp)
+}
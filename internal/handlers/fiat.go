@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/example/solapi/internal/pricing"
+	"github.com/example/solapi/internal/types"
+	"github.com/example/solapi/pkg/amount"
+	"github.com/example/solapi/pkg/jsonutil"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// quoteCurrency validates the request's quote currency, "" when none was
+// asked for. It writes the 400 response and returns ok=false when invalid.
+func quoteCurrency(w http.ResponseWriter, quote string) (string, bool) {
+	if quote == "" {
+		return "", true
+	}
+	currency, ok := pricing.ParseCurrency(quote)
+	if !ok {
+		jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "invalid quote currency"})
+		return "", false
+	}
+	return currency, true
+}
+
+func fiatValue(q pricing.Quote, amount float64) *types.FiatValue {
+	return &types.FiatValue{
+		Currency:  q.Currency,
+		Price:     q.Price,
+		PriceTime: q.PublishedAt.Format(time.RFC3339),
+		Value:     math.Round(amount*q.Price*1e6) / 1e6,
+	}
+}
+
+// priceError reports a balance that could not be valued.
+func priceError(wallet string, err error) types.ErrorEntry {
+	log.Printf("event=price_error wallet=%s err=%q", wallet, err)
+	msg := "price unavailable"
+	if errors.Is(err, pricing.ErrStale) {
+		msg = "price is stale"
+	}
+	return types.ErrorEntry{Wallet: wallet, Code: types.CodePriceUnavailable, Error: msg, Retryable: !errors.Is(err, pricing.ErrNoPrice)}
+}
+
+// attachFiat values every balance in currency. Without a usable SOL price
+// the balances are kept and each wallet reports the failure in Errors.
+func (h *BalanceHandler) attachFiat(ctx context.Context, currency string, resp *types.GetBalanceResponse) {
+	if len(resp.Balances) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.Deps.Timeout)
+	defer cancel()
+	q, err := h.Deps.Prices.Quote(ctx, pricing.SOL, currency)
+	for i := range resp.Balances {
+		be := &resp.Balances[i]
+		if err != nil {
+			resp.Errors = append(resp.Errors, priceError(be.Wallet, err))
+			continue
+		}
+		be.Fiat = fiatValue(q, amount.Float(be.Lamports, amount.SolDecimals))
+	}
+}
+
+// attachFiat values every token whose mint has a price in currency; wrapped
+// SOL takes the SOL price. Tokens without one are left unvalued.
+func (h *TokenBalanceHandler) attachFiat(ctx context.Context, currency string, resp *types.GetTokenBalancesResponse) {
+	var mints []string
+	seen := make(map[string]bool)
+	for _, wt := range resp.Wallets {
+		for _, tb := range wt.Tokens {
+			if !seen[tb.Mint] {
+				seen[tb.Mint] = true
+				mints = append(mints, tb.Mint)
+			}
+		}
+	}
+	quotes := make(map[string]pricing.Quote, len(mints))
+	sem := make(chan struct{}, h.Deps.MaxConcurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, mint := range mints {
+		mint := mint
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			ctx, cancel := context.WithTimeout(ctx, h.Deps.Timeout)
+			defer cancel()
+			asset := mint
+			if mint == sol.WrappedSol.String() {
+				asset = pricing.SOL
+			}
+			q, err := h.Deps.Prices.Quote(ctx, asset, currency)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if !errors.Is(err, pricing.ErrNoPrice) {
+					log.Printf("event=price_error mint=%s err=%q", mint, err)
+				}
+				return
+			}
+			quotes[mint] = q
+		}()
+	}
+	wg.Wait()
+	for i := range resp.Wallets {
+		for j := range resp.Wallets[i].Tokens {
+			tb := &resp.Wallets[i].Tokens[j]
+			if q, ok := quotes[tb.Mint]; ok && tb.UIAmount != nil {
+				tb.Fiat = fiatValue(q, *tb.UIAmount)
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+)
+
+// historyNamespace prefixes cache keys holding historical balances.
+const historyNamespace = "hist"
+
+// HistoryDeps bundles dependencies needed by the historical balance handler.
+type HistoryDeps struct {
+	Cache   *cache.Cache
+	Fetcher solana.HistoryFetcher
+	// TTL applies to results that may still change (target at the chain tip);
+	// settled results are kept until Cache evicts them, so Cache must be
+	// bounded with WithMaxEntries for them to outlive TTL.
+	TTL            time.Duration
+	Timeout        time.Duration
+	MaxConcurrency int
+}
+
+// HistoryHandler serves balances at a past slot or timestamp.
+type HistoryHandler struct{ Deps HistoryDeps }
+
+func NewHistoryHandler(deps HistoryDeps) *HistoryHandler { return &HistoryHandler{Deps: deps} }
+
+func (h *HistoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req types.GetHistoricalBalanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"bad request"}`, http.StatusBadRequest)
+		return
+	}
+	var at solana.HistoryPoint
+	var atKey []string
+	switch {
+	case req.Slot != 0 && req.Timestamp != "":
+		http.Error(w, `{"error":"only one of slot or timestamp allowed"}`, http.StatusBadRequest)
+		return
+	case req.Slot != 0:
+		at.Slot = req.Slot
+		atKey = []string{"slot", strconv.FormatUint(req.Slot, 10)}
+	case req.Timestamp != "":
+		ts, err := time.Parse(time.RFC3339, req.Timestamp)
+		if err != nil {
+			http.Error(w, `{"error":"timestamp must be RFC3339"}`, http.StatusBadRequest)
+			return
+		}
+		at.Time = ts
+		atKey = []string{"time", strconv.FormatInt(ts.Unix(), 10)}
+	default:
+		http.Error(w, `{"error":"slot or timestamp required"}`, http.StatusBadRequest)
+		return
+	}
+	units, ok := amountUnits(w, req.Units)
+	if !ok {
+		return
+	}
+	valid, invalid, ok := checkWallets(w, req.Wallets)
+	if !ok {
+		return
+	}
+	resp := types.GetHistoricalBalanceResponse{Balances: make([]types.HistoricalBalanceEntry, 0, len(valid)), Errors: invalid}
+
+	sem := make(chan struct{}, h.Deps.MaxConcurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, wstr := range valid {
+		wstr := wstr
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			pk, _ := parsePubkey(wstr)
+			ctx, cancel := context.WithTimeout(r.Context(), h.Deps.Timeout)
+			defer cancel()
+			key := cache.Key(historyNamespace, append([]string{wstr}, atKey...)...)
+			val, source, err := h.Deps.Cache.GetOrFetchTTL(ctx, key, h.Deps.TTL, func(ctx context.Context) (cache.Value, error) {
+				hb, latency, err := h.Deps.Fetcher.GetBalanceAt(ctx, pk, at)
+				if err != nil {
+					return cache.Value{}, err
+				}
+				log.Printf("event=rpc_fetch_history wallet=%s slot=%d latency_ms=%d", wstr, hb.Slot, latency.Milliseconds())
+				v := cache.Value{Lamports: hb.Lamports, FetchedAt: time.Now().UTC(), Data: hb}
+				if hb.Final {
+					v.TTL = cache.NoExpiry
+				}
+				return v, nil
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				resp.Errors = append(resp.Errors, walletError(wstr, err))
+				return
+			}
+			hb, _ := val.Data.(solana.HistoricalBalance)
+			entry := types.HistoricalBalanceEntry{
+				Wallet:    wstr,
+				Lamports:  hb.Lamports,
+				Sol:       solFloat(hb.Lamports),
+				Slot:      hb.Slot,
+				Signature: hb.Signature,
+				Source:    source,
+			}
+			if hb.BlockTime != nil {
+				entry.BlockTime = hb.BlockTime.Format(time.RFC3339)
+			}
+			resp.Balances = append(resp.Balances, entry)
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(resp.Balances, func(i, j int) bool { return resp.Balances[i].Wallet < resp.Balances[j].Wallet })
+	applyHistoryUnits(units, resp.Balances)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
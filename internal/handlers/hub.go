@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// Watcher keeps upstream subscriptions for wallets that have listeners.
+// solana.Subscriber implements it; Watch and Unwatch are reference counted.
+type Watcher interface {
+	Watch(pubkey sol.PublicKey)
+	Unwatch(pubkey sol.PublicKey)
+}
+
+// Hub fans balance entries out to the feeds watching each wallet, and keeps
+// the upstream subscription set in step with what feeds watch.
+type Hub struct {
+	watcher Watcher
+
+	mu    sync.Mutex
+	feeds map[string]map[*Feed]struct{} // wallet -> feeds
+}
+
+// NewHub returns a hub; watcher may be nil when there is no live upstream.
+func NewHub(watcher Watcher) *Hub {
+	return &Hub{watcher: watcher, feeds: make(map[string]map[*Feed]struct{})}
+}
+
+// Live reports whether updates are pushed from upstream rather than only
+// published by lookups.
+func (h *Hub) Live() bool { return h.watcher != nil }
+
+// Subscribe returns a feed for wallets, which must be valid public keys.
+func (h *Hub) Subscribe(wallets []string) *Feed {
+	f := newFeed()
+	h.Add(f, wallets)
+	return f
+}
+
+// Add starts delivering updates for wallets to f. Wallets f already
+// watches are ignored.
+func (h *Hub) Add(f *Feed, wallets []string) {
+	var watch []string
+	h.mu.Lock()
+	for _, w := range wallets {
+		if _, ok := f.wallets[w]; ok {
+			continue
+		}
+		f.wallets[w] = struct{}{}
+		set, ok := h.feeds[w]
+		if !ok {
+			set = make(map[*Feed]struct{})
+			h.feeds[w] = set
+		}
+		set[f] = struct{}{}
+		watch = append(watch, w)
+	}
+	h.mu.Unlock()
+	h.sync(watch, nil)
+}
+
+// Remove stops delivering updates for wallets to f.
+func (h *Hub) Remove(f *Feed, wallets []string) {
+	var unwatch []string
+	h.mu.Lock()
+	for _, w := range wallets {
+		if _, ok := f.wallets[w]; !ok {
+			continue
+		}
+		delete(f.wallets, w)
+		h.removeLocked(f, w)
+		unwatch = append(unwatch, w)
+	}
+	h.mu.Unlock()
+	h.sync(nil, unwatch)
+}
+
+// Unsubscribe detaches f from every wallet it watches.
+func (h *Hub) Unsubscribe(f *Feed) {
+	var unwatch []string
+	h.mu.Lock()
+	for w := range f.wallets {
+		h.removeLocked(f, w)
+		unwatch = append(unwatch, w)
+	}
+	f.wallets = make(map[string]struct{})
+	h.mu.Unlock()
+	h.sync(nil, unwatch)
+}
+
+func (h *Hub) removeLocked(f *Feed, w string) {
+	if set, ok := h.feeds[w]; ok {
+		delete(set, f)
+		if len(set) == 0 {
+			delete(h.feeds, w)
+		}
+	}
+	f.forget(w)
+}
+
+// sync passes subscription changes to the watcher. It runs without h.mu
+// held: Watch and Unwatch may write to the upstream socket, which must not
+// stall Publish or other feeds. A feed is only changed from one goroutine,
+// so its own Watch always precedes its Unwatch, and the watcher's reference
+// counts absorb any interleaving between feeds.
+func (h *Hub) sync(watch, unwatch []string) {
+	if h.watcher == nil {
+		return
+	}
+	for _, w := range watch {
+		pk, _ := parsePubkey(w)
+		h.watcher.Watch(pk)
+	}
+	for _, w := range unwatch {
+		pk, _ := parsePubkey(w)
+		h.watcher.Unwatch(pk)
+	}
+}
+
+// Wallets returns the wallets f watches, sorted.
+func (h *Hub) Wallets(f *Feed) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]string, 0, len(f.wallets))
+	for w := range f.wallets {
+		out = append(out, w)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Publish offers entry to every feed watching its wallet.
+func (h *Hub) Publish(entry types.BalanceEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for f := range h.feeds[entry.Wallet] {
+		f.offer(entry)
+	}
+}
+
+// Feed is one client's view of its wallets. Updates are coalesced per wallet
+// so a slow consumer only ever sees the latest balance, and an entry whose
+// lamports match the last one taken for that wallet is dropped.
+type Feed struct {
+	wallets map[string]struct{} // guarded by Hub.mu
+
+	mu      sync.Mutex
+	pending map[string]types.BalanceEntry
+	sent    map[string]uint64
+	ready   chan struct{}
+}
+
+func newFeed() *Feed {
+	return &Feed{
+		wallets: make(map[string]struct{}),
+		pending: make(map[string]types.BalanceEntry),
+		sent:    make(map[string]uint64),
+		ready:   make(chan struct{}, 1),
+	}
+}
+
+func (f *Feed) offer(entry types.BalanceEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if last, ok := f.sent[entry.Wallet]; ok && last == entry.Lamports {
+		delete(f.pending, entry.Wallet)
+		return
+	}
+	if prev, ok := f.pending[entry.Wallet]; ok && prev.Slot > entry.Slot {
+		return
+	}
+	f.pending[entry.Wallet] = entry
+	select {
+	case f.ready <- struct{}{}:
+	default:
+	}
+}
+
+// forget drops what f holds for wallet, so watching it again starts afresh.
+func (f *Feed) forget(wallet string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.pending, wallet)
+	delete(f.sent, wallet)
+}
+
+// Ready is signalled when Take has entries to return.
+func (f *Feed) Ready() <-chan struct{} { return f.ready }
+
+// Take returns the pending entries sorted by wallet and marks them sent.
+func (f *Feed) Take() []types.BalanceEntry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]types.BalanceEntry, 0, len(f.pending))
+	for w, e := range f.pending {
+		out = append(out, e)
+		f.sent[w] = e.Lamports
+		delete(f.pending, w)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Wallet < out[j].Wallet })
+	return out
+}
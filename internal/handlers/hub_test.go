@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// blockingWatcher stalls Watch until released, like a slow upstream write.
+type blockingWatcher struct{ entered, release chan struct{} }
+
+func (b *blockingWatcher) Watch(sol.PublicKey)   { b.entered <- struct{}{}; <-b.release }
+func (b *blockingWatcher) Unwatch(sol.PublicKey) {}
+
+func TestFeed_CoalescesAndSkipsUnchanged(t *testing.T) {
+	h := NewHub(nil)
+	f := h.Subscribe([]string{"a", "b"})
+	h.Publish(types.BalanceEntry{Wallet: "a", Lamports: 1, Slot: 10})
+	h.Publish(types.BalanceEntry{Wallet: "a", Lamports: 2, Slot: 11})
+	h.Publish(types.BalanceEntry{Wallet: "a", Lamports: 9, Slot: 5}) // out of order
+	h.Publish(types.BalanceEntry{Wallet: "b", Lamports: 7, Slot: 11})
+	h.Publish(types.BalanceEntry{Wallet: "c", Lamports: 7, Slot: 11}) // not watched
+	<-f.Ready()
+	got := f.Take()
+	if len(got) != 2 || got[0].Lamports != 2 || got[1].Wallet != "b" { t.Fatalf("got=%+v", got) }
+
+	// same lamports at a later slot is not a change
+	h.Publish(types.BalanceEntry{Wallet: "a", Lamports: 2, Slot: 12})
+	if got := f.Take(); len(got) != 0 { t.Fatalf("got=%+v", got) }
+
+	h.Unsubscribe(f)
+	h.Publish(types.BalanceEntry{Wallet: "b", Lamports: 8, Slot: 13})
+	if got := f.Take(); len(got) != 0 { t.Fatalf("delivered after unsubscribe: %+v", got) }
+}
+
+func TestHub_SlowWatcherDoesNotBlockPublish(t *testing.T) {
+	w := &blockingWatcher{entered: make(chan struct{}), release: make(chan struct{})}
+	h := NewHub(w)
+	wallet := sol.NewWallet().PublicKey().String()
+	f := newFeed()
+	go h.Add(f, []string{wallet})
+	<-w.entered // Watch is now stuck upstream
+
+	done := make(chan struct{})
+	go func() { h.Publish(types.BalanceEntry{Wallet: wallet, Lamports: 1, Slot: 1}); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Publish blocked behind a slow Watch")
+	}
+	if got := f.Take(); len(got) != 1 { t.Fatalf("got=%+v", got) }
+	close(w.release)
+}
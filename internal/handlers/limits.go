@@ -0,0 +1,38 @@
+package handlers
+
+import "sync"
+
+// keyLimit caps concurrent long-lived connections per API key. A max of
+// zero or less means no cap.
+type keyLimit struct {
+	max int
+
+	mu sync.Mutex
+	n  map[string]int
+}
+
+func newKeyLimit(max int) *keyLimit { return &keyLimit{max: max, n: make(map[string]int)} }
+
+// acquire takes a slot for key, reporting false when key is at its cap.
+func (l *keyLimit) acquire(key string) bool { return l.acquireN(key, 1) }
+
+func (l *keyLimit) release(key string) { l.releaseN(key, 1) }
+
+// acquireN takes n slots for key, or none if that would pass the cap.
+func (l *keyLimit) acquireN(key string, n int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.max > 0 && l.n[key]+n > l.max {
+		return false
+	}
+	l.n[key] += n
+	return true
+}
+
+func (l *keyLimit) releaseN(key string, n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.n[key] -= n; l.n[key] <= 0 {
+		delete(l.n, key)
+	}
+}
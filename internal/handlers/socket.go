@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/example/solapi/internal/auth"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	socketReadLimit = 64 << 10
+	// socketQueue bounds queued acknowledgements and errors per connection.
+	// Balances never queue: a connection's feed holds only the latest one
+	// per wallet.
+	socketQueue = 64
+)
+
+// SocketDeps bundles dependencies of the WebSocket API.
+type SocketDeps struct {
+	Balances *BalanceHandler
+	Hub      *Hub
+	// MaxConnsPerKey caps concurrent connections per API key and
+	// MaxSubsPerKey the wallets subscribed across them; zero means no cap.
+	MaxConnsPerKey int
+	MaxSubsPerKey  int
+	// MessagesPerMinute rate limits client messages per API key.
+	MessagesPerMinute int
+	// PingInterval is how often the server pings; a connection that has not
+	// answered within two intervals is closed.
+	PingInterval time.Duration
+	// Poll re-reads subscribed wallets at this interval when the hub has no
+	// live upstream; most reads hit the cache. Zero disables polling.
+	Poll time.Duration
+}
+
+// SocketHandler serves the WebSocket API. Clients send SocketRequests to
+// subscribe and unsubscribe wallets and receive SocketMessages: a balance
+// for each newly subscribed wallet and whenever its lamports change, and
+// acknowledgements and errors for their requests. The API key is checked on
+// the handshake by the Auth middleware.
+//
+// A consumer that reads slowly receives only the latest balance per wallet;
+// one that lets acknowledgements and errors pile up past socketQueue, or
+// stalls a write for streamWriteTimeout, is disconnected.
+type SocketHandler struct {
+	Deps SocketDeps
+
+	upgrader  websocket.Upgrader
+	conns     *keyLimit
+	subs      *keyLimit
+	msgs      *rate.LimiterMap
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewSocketHandler(deps SocketDeps) *SocketHandler {
+	if deps.PingInterval <= 0 {
+		deps.PingInterval = 30 * time.Second
+	}
+	if deps.MessagesPerMinute <= 0 {
+		deps.MessagesPerMinute = 120
+	}
+	return &SocketHandler{
+		Deps: deps,
+		// callers authenticate with an API key, not cookies, so any origin may connect
+		upgrader: websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }},
+		conns:    newKeyLimit(deps.MaxConnsPerKey),
+		subs:     newKeyLimit(deps.MaxSubsPerKey),
+		msgs:     rate.NewLimiterMap(deps.MessagesPerMinute, deps.MessagesPerMinute, 10*time.Minute),
+		done:     make(chan struct{}),
+	}
+}
+
+// Close disconnects every client. Hijacked connections are invisible to
+// http.Server.Shutdown, so register it with RegisterOnShutdown.
+func (h *SocketHandler) Close() {
+	h.closeOnce.Do(func() {
+		close(h.done)
+		h.msgs.Stop()
+	})
+}
+
+func (h *SocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := auth.KeyID(r.Context())
+	if !h.conns.acquire(key) {
+		http.Error(w, `{"error":"too many connections"}`, http.StatusTooManyRequests)
+		return
+	}
+	defer h.conns.release(key)
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has already replied
+		return
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	sc := &socketConn{
+		h:      h,
+		conn:   conn,
+		key:    key,
+		feed:   h.Deps.Hub.Subscribe(nil),
+		wallet: make(map[string]struct{}),
+		out:    make(chan types.SocketMessage, socketQueue),
+	}
+	log.Printf("event=socket_open api=%s", key)
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		sc.writeLoop(ctx)
+	}()
+	sc.readLoop(ctx)
+	cancel()
+	<-writerDone
+	conn.Close()
+	h.Deps.Hub.Unsubscribe(sc.feed)
+	h.subs.releaseN(key, len(sc.wallet))
+	log.Printf("event=socket_close api=%s wallets=%d", key, len(sc.wallet))
+}
+
+// socketConn is one client connection. The read loop owns wallet; the
+// write loop is the only writer of data frames.
+type socketConn struct {
+	h      *SocketHandler
+	conn   *websocket.Conn
+	key    string
+	feed   *Feed
+	wallet map[string]struct{}
+	out    chan types.SocketMessage
+}
+
+// send queues a control message, disconnecting a client that lets the
+// queue fill up.
+func (c *socketConn) send(msg types.SocketMessage) {
+	select {
+	case c.out <- msg:
+	default:
+		log.Printf("event=socket_slow_consumer api=%s", c.key)
+		c.conn.Close()
+	}
+}
+
+func (c *socketConn) sendError(e types.ErrorEntry) { c.send(types.SocketMessage{Type: "error", Error: &e}) }
+
+// write sends one data frame within streamWriteTimeout.
+func (c *socketConn) write(msg types.SocketMessage) bool {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	return c.conn.WriteJSON(msg) == nil
+}
+
+// writeLoop drains control messages and the feed, pings the client, and
+// without a live upstream polls the subscribed wallets. On shutdown it sends
+// a going-away close frame.
+func (c *socketConn) writeLoop(ctx context.Context) {
+	ping := time.NewTicker(c.h.Deps.PingInterval)
+	defer ping.Stop()
+	var pollC <-chan time.Time
+	if c.h.Deps.Poll > 0 && !c.h.Deps.Hub.Live() {
+		poll := time.NewTicker(c.h.Deps.Poll)
+		defer poll.Stop()
+		pollC = poll.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.h.done:
+			msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+			_ = c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+			c.conn.Close()
+			return
+		case msg := <-c.out:
+			if !c.write(msg) {
+				c.conn.Close()
+				return
+			}
+		case <-c.feed.Ready():
+			// acknowledgements queued before these balances go first
+			if !c.flushQueued() {
+				c.conn.Close()
+				return
+			}
+			for _, e := range c.feed.Take() {
+				if !c.write(types.SocketMessage{Type: "balance", Balance: &e}) {
+					c.conn.Close()
+					return
+				}
+			}
+		case <-ping.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				c.conn.Close()
+				return
+			}
+		case <-pollC:
+			if !c.refresh(ctx) {
+				c.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// refresh re-reads the subscribed wallets and offers the results to the
+// feed, which drops the ones that did not change. Lookup errors are written
+// directly.
+func (c *socketConn) refresh(ctx context.Context) bool {
+	wallets := c.h.Deps.Hub.Wallets(c.feed)
+	if len(wallets) == 0 {
+		return true
+	}
+	b := c.h.Deps.Balances
+	cm, _ := b.commitment("")
+	var resp types.GetBalanceResponse
+	b.lookup(ctx, cm, wallets, &resp)
+	for _, e := range resp.Balances {
+		c.feed.offer(e)
+	}
+	for _, e := range resp.Errors {
+		if !c.write(types.SocketMessage{Type: "error", Error: &e}) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *socketConn) flushQueued() bool {
+	for {
+		select {
+		case msg := <-c.out:
+			if !c.write(msg) {
+				return false
+			}
+		default:
+			return true
+		}
+	}
+}
+
+// isJSONError reports a message that arrived intact but did not decode; the
+// connection stays usable.
+func isJSONError(err error) bool {
+	var se *json.SyntaxError
+	var te *json.UnmarshalTypeError
+	return errors.As(err, &se) || errors.As(err, &te)
+}
+
+func (c *socketConn) readLoop(ctx context.Context) {
+	pongWait := 2 * c.h.Deps.PingInterval
+	c.conn.SetReadLimit(socketReadLimit)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error { return c.conn.SetReadDeadline(time.Now().Add(pongWait)) })
+	for {
+		var req types.SocketRequest
+		if err := c.conn.ReadJSON(&req); err != nil {
+			if !isJSONError(err) {
+				return
+			}
+			c.sendError(types.ErrorEntry{Code: types.CodeInvalidMessage, Error: "message is not a valid request"})
+			continue
+		}
+		_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		if !c.h.msgs.Allow(c.key) {
+			c.sendError(types.ErrorEntry{Code: types.CodeLimitExceeded, Error: "message rate limit reached", Retryable: true})
+			continue
+		}
+		switch req.Op {
+		case "subscribe":
+			c.subscribe(ctx, req.Wallets)
+		case "unsubscribe":
+			c.unsubscribe(req.Wallets)
+		default:
+			c.sendError(types.ErrorEntry{Code: types.CodeInvalidMessage, Error: `op must be "subscribe" or "unsubscribe"`})
+		}
+	}
+}
+
+func (c *socketConn) subscribe(ctx context.Context, in []string) {
+	if len(in) == 0 || len(in) > maxWallets {
+		c.sendError(types.ErrorEntry{Code: types.CodeInvalidMessage, Error: "wallets must list 1 to 100 keys"})
+		return
+	}
+	var added []string
+	for _, w := range dedupe(in) {
+		if _, ok := parsePubkey(w); !ok {
+			c.sendError(invalidPubkey(w))
+			continue
+		}
+		if _, ok := c.wallet[w]; !ok {
+			added = append(added, w)
+		}
+	}
+	if len(added) == 0 {
+		return
+	}
+	if !c.h.subs.acquireN(c.key, len(added)) {
+		c.sendError(types.ErrorEntry{Code: types.CodeLimitExceeded, Error: "subscription limit reached"})
+		return
+	}
+	for _, w := range added {
+		c.wallet[w] = struct{}{}
+	}
+	c.h.Deps.Hub.Add(c.feed, added)
+	c.send(types.SocketMessage{Type: "subscribed", Wallets: added})
+
+	// initial balances go through the feed, so they follow the acknowledgement
+	b := c.h.Deps.Balances
+	cm, _ := b.commitment("")
+	var resp types.GetBalanceResponse
+	b.lookup(ctx, cm, added, &resp)
+	for _, e := range resp.Balances {
+		c.feed.offer(e)
+	}
+	for _, e := range resp.Errors {
+		c.sendError(e)
+	}
+}
+
+func (c *socketConn) unsubscribe(in []string) {
+	var removed []string
+	for _, w := range dedupe(in) {
+		if _, ok := c.wallet[w]; ok {
+			delete(c.wallet, w)
+			removed = append(removed, w)
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+	c.h.Deps.Hub.Remove(c.feed, removed)
+	c.h.subs.releaseN(c.key, len(removed))
+	c.send(types.SocketMessage{Type: "unsubscribed", Wallets: removed})
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/example/solapi/internal/auth"
+	"github.com/example/solapi/internal/types"
+	"github.com/example/solapi/pkg/jsonutil"
+)
+
+// streamWriteTimeout bounds each write to a stream. The server's
+// WriteTimeout covers whole responses, so streams extend their deadline per
+// event instead.
+const streamWriteTimeout = 10 * time.Second
+
+// StreamDeps bundles dependencies of the balance stream.
+type StreamDeps struct {
+	// Balances serves the initial snapshot and, without a live upstream,
+	// the periodic re-reads.
+	Balances *BalanceHandler
+	Hub      *Hub
+	// Heartbeat is the interval between heartbeat events.
+	Heartbeat time.Duration
+	// Poll re-reads the wallets at this interval when the hub has no live
+	// upstream; most reads hit the cache. Zero disables polling.
+	Poll time.Duration
+	// MaxPerKey caps concurrent streams per API key; zero means no cap.
+	MaxPerKey int
+}
+
+// StreamHandler serves GET /api/stream/balances?wallets=a,b as Server-Sent
+// Events: a "balance" event with a BalanceEntry for each wallet on connect
+// and whenever its lamports change, "error" events with an ErrorEntry, and a
+// "heartbeat" event every Heartbeat. Balances are at the default commitment.
+type StreamHandler struct {
+	Deps StreamDeps
+
+	limit     *keyLimit
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewStreamHandler(deps StreamDeps) *StreamHandler {
+	if deps.Heartbeat <= 0 {
+		deps.Heartbeat = 15 * time.Second
+	}
+	return &StreamHandler{Deps: deps, limit: newKeyLimit(deps.MaxPerKey), done: make(chan struct{})}
+}
+
+// Close ends every open stream. Register it with http.Server.RegisterOnShutdown,
+// since Shutdown otherwise waits for streams that never go idle.
+func (h *StreamHandler) Close() { h.closeOnce.Do(func() { close(h.done) }) }
+
+// StreamTokenHandler issues stream tokens for the caller's API key.
+//
+//	POST /api/stream/token
+type StreamTokenHandler struct{ Tokens *auth.StreamTokens }
+
+func NewStreamTokenHandler(tokens *auth.StreamTokens) *StreamTokenHandler {
+	return &StreamTokenHandler{Tokens: tokens}
+}
+
+func (h *StreamTokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonutil.JSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	// mounted behind Auth, which only accepts the header
+	token, expires := h.Tokens.Issue(r.Header.Get("X-API-Key"))
+	jsonutil.JSON(w, http.StatusOK, types.StreamTokenResponse{Token: token, ExpiresAt: expires.UTC().Format(time.RFC3339)})
+}
+
+// streamWallets reads wallets from repeated or comma-separated query values.
+func streamWallets(r *http.Request) []string {
+	var out []string
+	for _, v := range r.URL.Query()["wallets"] {
+		for _, w := range strings.Split(v, ",") {
+			if w = strings.TrimSpace(w); w != "" {
+				out = append(out, w)
+			}
+		}
+	}
+	return out
+}
+
+func (h *StreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	valid, invalid, ok := checkWallets(w, streamWallets(r))
+	if !ok {
+		return
+	}
+	key := auth.KeyID(r.Context())
+	if !h.limit.acquire(key) {
+		http.Error(w, `{"error":"too many streams"}`, http.StatusTooManyRequests)
+		return
+	}
+	defer h.limit.release(key)
+
+	feed := h.Deps.Hub.Subscribe(valid)
+	defer h.Deps.Hub.Unsubscribe(feed)
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	send := func(event string, v any) bool {
+		data, _ := json.Marshal(v)
+		_ = rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+	for _, e := range invalid {
+		if !send("error", e) {
+			return
+		}
+	}
+	log.Printf("event=stream_open wallets=%d api=%s", len(valid), key)
+	defer log.Printf("event=stream_close wallets=%d api=%s", len(valid), key)
+
+	ctx := r.Context()
+	if !h.refresh(ctx, feed, valid, send) {
+		return
+	}
+	heartbeat := time.NewTicker(h.Deps.Heartbeat)
+	defer heartbeat.Stop()
+	var pollC <-chan time.Time
+	if h.Deps.Poll > 0 && !h.Deps.Hub.Live() {
+		poll := time.NewTicker(h.Deps.Poll)
+		defer poll.Stop()
+		pollC = poll.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.done:
+			return
+		case <-feed.Ready():
+			for _, e := range feed.Take() {
+				if !send("balance", e) {
+					return
+				}
+			}
+		case <-heartbeat.C:
+			if !send("heartbeat", map[string]string{"time": types.NowRFC3339()}) {
+				return
+			}
+		case <-pollC:
+			if !h.refresh(ctx, feed, valid, send) {
+				return
+			}
+		}
+	}
+}
+
+// refresh looks wallets up through the balance handler and offers the
+// results to feed, which drops the ones that did not change. Lookup errors
+// are sent directly.
+func (h *StreamHandler) refresh(ctx context.Context, feed *Feed, valid []string, send func(string, any) bool) bool {
+	b := h.Deps.Balances
+	cm, _ := b.commitment("")
+	var resp types.GetBalanceResponse
+	b.lookup(ctx, cm, valid, &resp)
+	for _, e := range resp.Balances {
+		feed.offer(e)
+	}
+	for _, e := range resp.Errors {
+		if !send("error", e) {
+			return false
+		}
+	}
+	return true
+}
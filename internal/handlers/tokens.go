@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	"github.com/example/solapi/internal/pricing"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+)
+
+// tokenNamespace prefixes cache keys holding an owner's token accounts.
+const tokenNamespace = "tokens"
+
+// TokenDeps bundles dependencies needed by the token balances handler.
+type TokenDeps struct {
+	Cache          *cache.Cache
+	Fetcher        solana.TokenFetcher
+	TTL            time.Duration
+	Timeout        time.Duration
+	MaxConcurrency int
+	// Prices serves quote requests; when nil the field is ignored.
+	Prices *pricing.Pricer
+}
+
+// TokenBalanceHandler serves SPL token balances for a list of owners.
+type TokenBalanceHandler struct{ Deps TokenDeps }
+
+func NewTokenBalanceHandler(deps TokenDeps) *TokenBalanceHandler {
+	return &TokenBalanceHandler{Deps: deps}
+}
+
+func (h *TokenBalanceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req types.GetBalanceRequest
+	valid, invalid, ok := readWallets(w, r, &req)
+	if !ok {
+		return
+	}
+	currency, ok := quoteCurrency(w, req.Quote)
+	if !ok {
+		return
+	}
+	units, ok := amountUnits(w, req.Units)
+	if !ok {
+		return
+	}
+	resp := types.GetTokenBalancesResponse{Wallets: make([]types.WalletTokens, 0, len(valid)), Errors: invalid}
+
+	sem := make(chan struct{}, h.Deps.MaxConcurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, wstr := range valid {
+		wstr := wstr
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			pk, _ := parsePubkey(wstr)
+			ctx, cancel := context.WithTimeout(r.Context(), h.Deps.Timeout)
+			defer cancel()
+			val, source, err := h.Deps.Cache.GetOrFetchTTL(ctx, cache.Key(tokenNamespace, wstr), h.Deps.TTL, func(ctx context.Context) (cache.Value, error) {
+				accounts, latency, err := h.Deps.Fetcher.GetTokenAccounts(ctx, pk)
+				if err != nil {
+					return cache.Value{}, err
+				}
+				log.Printf("event=rpc_fetch_tokens wallet=%s accounts=%d latency_ms=%d", wstr, len(accounts), latency.Milliseconds())
+				return cache.Value{Data: accounts, FetchedAt: time.Now().UTC()}, nil
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				resp.Errors = append(resp.Errors, walletError(wstr, err))
+				return
+			}
+			accounts, _ := val.Data.([]solana.TokenAccount)
+			resp.Wallets = append(resp.Wallets, walletTokens(wstr, accounts, source, val.FetchedAt))
+		}()
+	}
+	wg.Wait()
+	if currency != "" && h.Deps.Prices != nil {
+		h.attachFiat(r.Context(), currency, &resp)
+	}
+	applyTokenUnits(units, resp.Wallets)
+
+	sort.Slice(resp.Wallets, func(i, j int) bool { return resp.Wallets[i].Wallet < resp.Wallets[j].Wallet })
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func walletTokens(wallet string, accounts []solana.TokenAccount, source string, fetchedAt time.Time) types.WalletTokens {
+	tokens := make([]types.TokenBalance, 0, len(accounts))
+	for _, a := range accounts {
+		tokens = append(tokens, types.TokenBalance{
+			Account:        a.Address,
+			Mint:           a.Mint,
+			Program:        a.Program,
+			Amount:         a.Amount,
+			Decimals:       a.Decimals,
+			UIAmount:       floatPtr(a.UIAmount),
+			UIAmountString: a.UIAmountString,
+			Extensions:     a.Extensions,
+		})
+	}
+	// stable order: by mint, then account
+	sort.Slice(tokens, func(i, j int) bool {
+		if tokens[i].Mint != tokens[j].Mint {
+			return tokens[i].Mint < tokens[j].Mint
+		}
+		return tokens[i].Account < tokens[j].Account
+	})
+	return types.WalletTokens{
+		Wallet:    wallet,
+		Tokens:    tokens,
+		Source:    source,
+		FetchedAt: fetchedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	"github.com/example/solapi/pkg/amount"
+	"github.com/example/solapi/pkg/jsonutil"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// txNamespace prefixes cache keys holding a wallet's delta for a transaction.
+const txNamespace = "tx"
+
+const (
+	defaultTxPageLimit = 20
+	maxTxPageLimit     = 100
+)
+
+// TransactionDeps bundles dependencies needed by the transactions handler.
+type TransactionDeps struct {
+	Cache   *cache.Cache
+	Fetcher solana.TransactionFetcher
+	// TTL applies to transactions that are not finalized yet; finalized ones
+	// are kept until Cache evicts them, so Cache must be bounded with
+	// WithMaxEntries.
+	TTL            time.Duration
+	Timeout        time.Duration
+	MaxConcurrency int
+}
+
+// TransactionsHandler pages through a wallet's transaction history.
+//
+//	GET /api/transactions?wallet=<pubkey>&before=<sig>&until=<sig>&limit=<n>&units=<units>
+type TransactionsHandler struct{ Deps TransactionDeps }
+
+func NewTransactionsHandler(deps TransactionDeps) *TransactionsHandler {
+	return &TransactionsHandler{Deps: deps}
+}
+
+func (h *TransactionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		jsonutil.JSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	q := r.URL.Query()
+	wallet, ok := parsePubkey(q.Get("wallet"))
+	if !ok {
+		jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "invalid wallet"})
+		return
+	}
+	units, ok := amountUnits(w, q.Get("units"))
+	if !ok {
+		return
+	}
+	page := solana.SignaturePage{Limit: defaultTxPageLimit}
+	for name, dst := range map[string]*sol.Signature{"before": &page.Before, "until": &page.Until} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		sig, err := sol.SignatureFromBase58(v)
+		if err != nil {
+			jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "invalid " + name + " signature"})
+			return
+		}
+		*dst = sig
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxTxPageLimit {
+			jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be between 1 and 100"})
+			return
+		}
+		page.Limit = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.Deps.Timeout)
+	sigs, latency, err := h.Deps.Fetcher.GetSignatures(ctx, wallet, page)
+	cancel()
+	if err != nil {
+		code, msg, retryable := classify(err)
+		log.Printf("event=rpc_fetch_signatures_error wallet=%s code=%s err=%q", wallet, code, err)
+		jsonutil.JSON(w, http.StatusBadGateway, map[string]any{"error": msg, "code": code, "retryable": retryable})
+		return
+	}
+	log.Printf("event=rpc_fetch_signatures wallet=%s count=%d latency_ms=%d", wallet, len(sigs), latency.Milliseconds())
+
+	resp := types.GetTransactionsResponse{Wallet: wallet.String(), Transactions: make([]types.TransactionEntry, len(sigs))}
+	failed := make([]bool, len(sigs))
+	sem := make(chan struct{}, h.Deps.MaxConcurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for i, si := range sigs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			entry, err := h.entry(r.Context(), wallet, si)
+			if err != nil {
+				mu.Lock()
+				code, msg, retryable := classify(err)
+				resp.Errors = append(resp.Errors, types.TransactionError{Signature: si.Signature, Code: code, Error: msg, Retryable: retryable})
+				mu.Unlock()
+				failed[i] = true
+				return
+			}
+			resp.Transactions[i] = entry
+		}()
+	}
+	wg.Wait()
+
+	// keep newest-first order, dropping transactions that failed to load
+	kept := resp.Transactions[:0]
+	for i := range resp.Transactions {
+		if !failed[i] {
+			kept = append(kept, resp.Transactions[i])
+		}
+	}
+	resp.Transactions = kept
+	applyTxUnits(units, resp.Transactions)
+	if len(sigs) == page.Limit {
+		resp.NextBefore = sigs[len(sigs)-1].Signature
+	}
+	jsonutil.JSON(w, http.StatusOK, resp)
+}
+
+// entry builds the history entry for si, caching the wallet's delta per
+// transaction. Finalized transactions never change, so they stay cached
+// until evicted for capacity.
+func (h *TransactionsHandler) entry(ctx context.Context, wallet sol.PublicKey, si solana.SignatureInfo) (types.TransactionEntry, error) {
+	sig, err := sol.SignatureFromBase58(si.Signature)
+	if err != nil {
+		return types.TransactionEntry{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.Deps.Timeout)
+	defer cancel()
+	ttl := h.Deps.TTL
+	if si.Finalized() {
+		ttl = cache.NoExpiry
+	}
+	val, source, err := h.Deps.Cache.GetOrFetchTTL(ctx, cache.Key(txNamespace, si.Signature, wallet.String()), ttl, func(ctx context.Context) (cache.Value, error) {
+		d, latency, err := h.Deps.Fetcher.GetWalletDelta(ctx, sig, wallet)
+		if err != nil {
+			return cache.Value{}, err
+		}
+		log.Printf("event=rpc_fetch_tx sig=%s latency_ms=%d", si.Signature, latency.Milliseconds())
+		return cache.Value{Data: d, FetchedAt: time.Now().UTC()}, nil
+	})
+	if err != nil {
+		return types.TransactionEntry{}, err
+	}
+	d, _ := val.Data.(solana.WalletDelta)
+	entry := types.TransactionEntry{
+		Signature:          si.Signature,
+		Slot:               si.Slot,
+		Status:             "success",
+		Err:                si.Err,
+		ConfirmationStatus: si.ConfirmationStatus,
+		Fee:                d.Fee,
+		LamportsDelta:      d.Delta(),
+		SolDelta:           floatPtr(amount.FloatInt(d.Delta(), amount.SolDecimals)),
+		Source:             source,
+	}
+	if si.Err != nil {
+		entry.Status = "failed"
+	}
+	if si.BlockTime != nil {
+		entry.BlockTime = si.BlockTime.Format(time.RFC3339)
+	}
+	return entry, nil
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/example/solapi/internal/types"
+	"github.com/example/solapi/pkg/amount"
+	"github.com/example/solapi/pkg/jsonutil"
+)
+
+// amountUnits validates the request's units, defaulting to UnitsFloat. It
+// writes the 400 response and returns ok=false when they are unknown.
+func amountUnits(w http.ResponseWriter, units string) (string, bool) {
+	switch units {
+	case "":
+		return types.UnitsFloat, true
+	case types.UnitsFloat, types.UnitsLamports, types.UnitsSolString:
+		return units, true
+	}
+	jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "units must be float, lamports or sol-string"})
+	return "", false
+}
+
+func floatPtr(f float64) *float64 { return &f }
+
+// solFloat is the SOL amount of lamports as rendered under UnitsFloat.
+func solFloat(lamports uint64) *float64 {
+	return floatPtr(amount.Float(lamports, amount.SolDecimals))
+}
+
+// applyBalanceUnits re-renders balances, built with UnitsFloat, in units.
+func applyBalanceUnits(units string, balances []types.BalanceEntry) {
+	if units == types.UnitsFloat {
+		return
+	}
+	for i := range balances {
+		be := &balances[i]
+		be.Sol = nil
+		if units == types.UnitsSolString {
+			be.SolString = amount.Format(be.Lamports, amount.SolDecimals)
+		}
+	}
+}
+
+// applyHistoryUnits re-renders historical balances, built with UnitsFloat,
+// in units.
+func applyHistoryUnits(units string, balances []types.HistoricalBalanceEntry) {
+	if units == types.UnitsFloat {
+		return
+	}
+	for i := range balances {
+		be := &balances[i]
+		be.Sol = nil
+		if units == types.UnitsSolString {
+			be.SolString = amount.Format(be.Lamports, amount.SolDecimals)
+		}
+	}
+}
+
+// applyTxUnits re-renders transaction deltas, built with UnitsFloat, in units.
+func applyTxUnits(units string, txs []types.TransactionEntry) {
+	if units == types.UnitsFloat {
+		return
+	}
+	for i := range txs {
+		te := &txs[i]
+		te.SolDelta = nil
+		if units == types.UnitsSolString {
+			te.SolDeltaString = amount.FormatInt(te.LamportsDelta, amount.SolDecimals)
+		}
+	}
+}
+
+// applyTokenUnits re-renders token amounts, built with UnitsFloat, in
+// units. Exact strings come from the raw amount and mint decimals, except
+// for interest-bearing mints whose UI amount includes accrued interest; for
+// those the node's string is kept.
+func applyTokenUnits(units string, wallets []types.WalletTokens) {
+	if units == types.UnitsFloat {
+		return
+	}
+	for i := range wallets {
+		for j := range wallets[i].Tokens {
+			tb := &wallets[i].Tokens[j]
+			tb.UIAmount = nil
+			if units == types.UnitsLamports {
+				tb.UIAmountString = ""
+				continue
+			}
+			if tb.Extensions != nil && tb.Extensions.InterestBearing != nil {
+				continue
+			}
+			s, err := amount.FormatString(tb.Amount, tb.Decimals)
+			if err != nil {
+				log.Printf("event=token_amount_error account=%s err=%v", tb.Account, err)
+				continue
+			}
+			tb.UIAmountString = s
+		}
+	}
+}
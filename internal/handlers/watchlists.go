@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/solapi/internal/auth"
+	"github.com/example/solapi/internal/types"
+	"github.com/example/solapi/internal/watchlist"
+	"github.com/example/solapi/pkg/jsonutil"
+)
+
+// WatchlistDeps bundles dependencies of the watchlist API.
+type WatchlistDeps struct {
+	Store watchlist.Store
+	// Balances resolves members through the same cache and fetch path as
+	// /api/get-balance.
+	Balances *BalanceHandler
+	// MaxPerKey caps watchlists per API key and MaxWallets the members of
+	// one list; zero means no cap.
+	MaxPerKey  int
+	MaxWallets int
+}
+
+// WatchlistHandler serves named wallet lists scoped to the caller's API key.
+//
+//	GET    /api/watchlists
+//	POST   /api/watchlists                 {"name":..., "wallets":[...]}
+//	GET    /api/watchlists/{id}
+//	DELETE /api/watchlists/{id}
+//	POST   /api/watchlists/{id}/wallets    {"wallets":[...]}
+//	DELETE /api/watchlists/{id}/wallets    {"wallets":[...]}
+//	GET    /api/watchlists/{id}/balances?after=<wallet>&limit=<n>&commitment=<level>
+//
+// Wallets are added and removed at most maxWallets per call; balances are
+// paged in wallet order, maxWallets at a time.
+type WatchlistHandler struct{ Deps WatchlistDeps }
+
+func NewWatchlistHandler(deps WatchlistDeps) *WatchlistHandler {
+	return &WatchlistHandler{Deps: deps}
+}
+
+func watchlistEntry(l watchlist.Watchlist) types.WatchlistEntry {
+	wallets := l.Wallets
+	if wallets == nil {
+		wallets = []string{}
+	}
+	return types.WatchlistEntry{ID: l.ID, Name: l.Name, Wallets: wallets, CreatedAt: l.Created.UTC().Format(time.RFC3339)}
+}
+
+// readMembers decodes a WatchlistRequest and dedupes and validates its
+// wallets, which may be empty only when allowEmpty is set. It writes the 400
+// response and returns ok=false when the request is unusable.
+func readMembers(w http.ResponseWriter, r *http.Request, allowEmpty bool) (types.WatchlistRequest, bool) {
+	var req types.WatchlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "bad request"})
+		return req, false
+	}
+	if allowEmpty && len(req.Wallets) == 0 {
+		return req, true
+	}
+	valid, invalid, ok := checkWallets(w, req.Wallets)
+	if !ok {
+		return req, false
+	}
+	if len(invalid) > 0 {
+		jsonutil.JSON(w, http.StatusBadRequest, map[string]any{"error": "invalid wallets", "errors": invalid})
+		return req, false
+	}
+	req.Wallets = valid
+	return req, true
+}
+
+func (h *WatchlistHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/watchlists"), "/")
+	id, sub, _ := strings.Cut(rest, "/")
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		h.list(w, r)
+	case id == "" && r.Method == http.MethodPost:
+		h.create(w, r)
+	case sub == "" && r.Method == http.MethodGet:
+		if l, ok := h.owned(w, r, id); ok {
+			jsonutil.JSON(w, http.StatusOK, watchlistEntry(l))
+		}
+	case sub == "" && r.Method == http.MethodDelete:
+		h.remove(w, r, id)
+	case sub == "wallets" && r.Method == http.MethodPost:
+		h.addWallets(w, r, id)
+	case sub == "wallets" && r.Method == http.MethodDelete:
+		h.removeWallets(w, r, id)
+	case sub == "balances" && r.Method == http.MethodGet:
+		h.balances(w, r, id)
+	case sub != "" && sub != "wallets" && sub != "balances":
+		jsonutil.JSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	default:
+		jsonutil.JSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+func (h *WatchlistHandler) list(w http.ResponseWriter, r *http.Request) {
+	lists, err := h.Deps.Store.List(r.Context(), auth.Owner(r.Context()))
+	if err != nil {
+		h.storeError(w, err)
+		return
+	}
+	out := make([]types.WatchlistEntry, len(lists))
+	for i, l := range lists {
+		out[i] = watchlistEntry(l)
+	}
+	jsonutil.JSON(w, http.StatusOK, map[string]any{"watchlists": out})
+}
+
+func (h *WatchlistHandler) create(w http.ResponseWriter, r *http.Request) {
+	req, ok := readMembers(w, r, true)
+	if !ok {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "name must be 1 to 100 characters"})
+		return
+	}
+	if h.Deps.MaxWallets > 0 && len(req.Wallets) > h.Deps.MaxWallets {
+		jsonutil.JSON(w, http.StatusConflict, map[string]string{"error": "watchlist wallet limit reached"})
+		return
+	}
+	ctx := r.Context()
+	owner := auth.Owner(ctx)
+	l := watchlist.Watchlist{ID: watchlist.NewID(), Owner: owner, Name: req.Name, Wallets: req.Wallets, Created: time.Now().UTC()}
+	if err := h.Deps.Store.Create(ctx, l, h.Deps.MaxPerKey); err != nil {
+		h.storeError(w, err)
+		return
+	}
+	log.Printf("event=watchlist_create id=%s wallets=%d api=%s", l.ID, len(l.Wallets), auth.KeyID(ctx))
+	jsonutil.JSON(w, http.StatusCreated, watchlistEntry(l))
+}
+
+func (h *WatchlistHandler) remove(w http.ResponseWriter, r *http.Request, id string) {
+	if _, ok := h.owned(w, r, id); !ok {
+		return
+	}
+	if err := h.Deps.Store.Delete(r.Context(), id); err != nil {
+		h.storeError(w, err)
+		return
+	}
+	log.Printf("event=watchlist_delete id=%s api=%s", id, auth.KeyID(r.Context()))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WatchlistHandler) addWallets(w http.ResponseWriter, r *http.Request, id string) {
+	if _, ok := h.owned(w, r, id); !ok {
+		return
+	}
+	req, ok := readMembers(w, r, false)
+	if !ok {
+		return
+	}
+	add := func(ctx context.Context, id string, wallets []string) error {
+		return h.Deps.Store.AddWallets(ctx, id, wallets, h.Deps.MaxWallets)
+	}
+	h.changeWallets(w, r, id, add, req.Wallets)
+}
+
+func (h *WatchlistHandler) removeWallets(w http.ResponseWriter, r *http.Request, id string) {
+	if _, ok := h.owned(w, r, id); !ok {
+		return
+	}
+	req, ok := readMembers(w, r, false)
+	if !ok {
+		return
+	}
+	h.changeWallets(w, r, id, h.Deps.Store.RemoveWallets, req.Wallets)
+}
+
+// changeWallets applies a membership change and responds with the updated
+// watchlist.
+func (h *WatchlistHandler) changeWallets(w http.ResponseWriter, r *http.Request, id string, change func(ctx context.Context, id string, wallets []string) error, wallets []string) {
+	ctx := r.Context()
+	if err := change(ctx, id, wallets); err != nil {
+		h.storeError(w, err)
+		return
+	}
+	l, err := h.Deps.Store.Get(ctx, id)
+	if err != nil {
+		h.storeError(w, err)
+		return
+	}
+	jsonutil.JSON(w, http.StatusOK, watchlistEntry(l))
+}
+
+// balances serves one page of the watchlist's balances. Members are paged
+// in wallet order so the after cursor stays valid as the list changes.
+func (h *WatchlistHandler) balances(w http.ResponseWriter, r *http.Request, id string) {
+	l, ok := h.owned(w, r, id)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	limit := maxWallets
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxWallets {
+			jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be between 1 and 100"})
+			return
+		}
+		limit = n
+	}
+	cm, ok := h.Deps.Balances.commitment(q.Get("commitment"))
+	if !ok {
+		jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "invalid commitment"})
+		return
+	}
+	wallets := dedupe(l.Wallets)
+	sort.Strings(wallets)
+	start := 0
+	if after := q.Get("after"); after != "" {
+		start = sort.SearchStrings(wallets, after)
+		if start < len(wallets) && wallets[start] == after {
+			start++
+		}
+	}
+	page := wallets[start:]
+	resp := types.WatchlistBalancesResponse{Watchlist: l.ID}
+	if len(page) > limit {
+		page = page[:limit]
+		resp.NextAfter = page[limit-1]
+	}
+	var balances types.GetBalanceResponse
+	balances.Balances = make([]types.BalanceEntry, 0, len(page))
+	h.Deps.Balances.lookup(r.Context(), cm, page, &balances)
+	sort.Slice(balances.Balances, func(i, j int) bool { return balances.Balances[i].Wallet < balances.Balances[j].Wallet })
+	resp.Balances, resp.Errors = balances.Balances, balances.Errors
+	jsonutil.JSON(w, http.StatusOK, resp)
+}
+
+// owned loads watchlist id for the caller. Another key's watchlist is
+// reported as not found, so ids cannot be probed.
+func (h *WatchlistHandler) owned(w http.ResponseWriter, r *http.Request, id string) (watchlist.Watchlist, bool) {
+	l, err := h.Deps.Store.Get(r.Context(), id)
+	if err == nil && l.Owner != auth.Owner(r.Context()) {
+		err = watchlist.ErrNotFound
+	}
+	if err != nil {
+		h.storeError(w, err)
+		return watchlist.Watchlist{}, false
+	}
+	return l, true
+}
+
+func (h *WatchlistHandler) storeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, watchlist.ErrNotFound) {
+		jsonutil.JSON(w, http.StatusNotFound, map[string]string{"error": "watchlist not found"})
+		return
+	}
+	if errors.Is(err, watchlist.ErrLimit) {
+		jsonutil.JSON(w, http.StatusConflict, map[string]string{"error": "watchlist limit reached"})
+		return
+	}
+	if errors.Is(err, watchlist.ErrFull) {
+		jsonutil.JSON(w, http.StatusConflict, map[string]string{"error": "watchlist wallet limit reached"})
+		return
+	}
+	log.Printf("event=watchlist_store_error err=%v", err)
+	jsonutil.JSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+}
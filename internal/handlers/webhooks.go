@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/example/solapi/internal/auth"
+	"github.com/example/solapi/internal/types"
+	"github.com/example/solapi/internal/webhook"
+	"github.com/example/solapi/pkg/jsonutil"
+)
+
+// WebhookDeps bundles dependencies of the webhook API and its watcher.
+type WebhookDeps struct {
+	Store  webhook.Store
+	Outbox webhook.Outbox
+	// Balances seeds each wallet's starting balance and, without a live
+	// upstream, re-reads wallets every Poll.
+	Balances *BalanceHandler
+	Hub      *Hub
+	Poll     time.Duration
+	// Reload re-reads all webhooks at this interval, picking up changes made
+	// through other instances (default 1m).
+	Reload time.Duration
+	// MaxPerKey caps webhooks per API key; zero means no cap.
+	MaxPerKey int
+}
+
+// WebhookHandler serves CRUD for webhooks under /api/webhooks, scoped to the
+// caller's API key, and with Run watches their wallets and enqueues a
+// delivery whenever a change meets a webhook's thresholds. Balances are at
+// the default commitment.
+type WebhookHandler struct {
+	Deps WebhookDeps
+
+	reload chan struct{}
+}
+
+func NewWebhookHandler(deps WebhookDeps) *WebhookHandler {
+	if deps.Reload <= 0 {
+		deps.Reload = time.Minute
+	}
+	return &WebhookHandler{Deps: deps, reload: make(chan struct{}, 1)}
+}
+
+func webhookEntry(wh webhook.Webhook) types.WebhookEntry {
+	return types.WebhookEntry{
+		ID:               wh.ID,
+		URL:              wh.URL,
+		Wallets:          wh.Wallets,
+		MinDeltaLamports: wh.MinDelta,
+		FloorLamports:    wh.Floor,
+		CreatedAt:        wh.Created.UTC().Format(time.RFC3339),
+	}
+}
+
+// readWebhook decodes and validates a WebhookRequest, writing the 400
+// response and returning ok=false when it is unusable.
+func readWebhook(w http.ResponseWriter, r *http.Request) (types.WebhookRequest, bool) {
+	var req types.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "bad request"})
+		return req, false
+	}
+	switch err := webhook.CheckURL(req.URL); {
+	case errors.Is(err, webhook.ErrPrivateDestination):
+		jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "url must not point at a loopback, private or link-local address"})
+		return req, false
+	case err != nil:
+		jsonutil.JSON(w, http.StatusBadRequest, map[string]string{"error": "url must be an absolute http(s) URL"})
+		return req, false
+	}
+	valid, invalid, ok := checkWallets(w, req.Wallets)
+	if !ok {
+		return req, false
+	}
+	if len(invalid) > 0 {
+		jsonutil.JSON(w, http.StatusBadRequest, map[string]any{"error": "invalid wallets", "errors": invalid})
+		return req, false
+	}
+	req.Wallets = valid
+	return req, true
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/webhooks"), "/")
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		h.list(w, r)
+	case id == "" && r.Method == http.MethodPost:
+		h.create(w, r)
+	case id != "" && r.Method == http.MethodGet:
+		if wh, ok := h.owned(w, r, id); ok {
+			jsonutil.JSON(w, http.StatusOK, webhookEntry(wh))
+		}
+	case id != "" && r.Method == http.MethodPut:
+		h.update(w, r, id)
+	case id != "" && r.Method == http.MethodDelete:
+		h.remove(w, r, id)
+	default:
+		jsonutil.JSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+func (h *WebhookHandler) list(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.Deps.Store.List(r.Context(), auth.Owner(r.Context()))
+	if err != nil {
+		h.storeError(w, err)
+		return
+	}
+	out := make([]types.WebhookEntry, len(hooks))
+	for i, wh := range hooks {
+		out[i] = webhookEntry(wh)
+	}
+	jsonutil.JSON(w, http.StatusOK, map[string]any{"webhooks": out})
+}
+
+func (h *WebhookHandler) create(w http.ResponseWriter, r *http.Request) {
+	req, ok := readWebhook(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	owner := auth.Owner(ctx)
+	wh := webhook.Webhook{
+		ID:       webhook.NewID(),
+		Owner:    owner,
+		URL:      req.URL,
+		Secret:   webhook.NewSecret(),
+		Wallets:  req.Wallets,
+		MinDelta: req.MinDeltaLamports,
+		Floor:    req.FloorLamports,
+		Created:  time.Now().UTC(),
+	}
+	if err := h.Deps.Store.Create(ctx, wh, h.Deps.MaxPerKey); err != nil {
+		h.storeError(w, err)
+		return
+	}
+	h.changed()
+	log.Printf("event=webhook_create id=%s wallets=%d api=%s", wh.ID, len(wh.Wallets), auth.KeyID(ctx))
+	entry := webhookEntry(wh)
+	// the secret is shown once
+	entry.Secret = wh.Secret
+	jsonutil.JSON(w, http.StatusCreated, entry)
+}
+
+func (h *WebhookHandler) update(w http.ResponseWriter, r *http.Request, id string) {
+	wh, ok := h.owned(w, r, id)
+	if !ok {
+		return
+	}
+	req, ok := readWebhook(w, r)
+	if !ok {
+		return
+	}
+	wh.URL, wh.Wallets, wh.MinDelta, wh.Floor = req.URL, req.Wallets, req.MinDeltaLamports, req.FloorLamports
+	if err := h.Deps.Store.Update(r.Context(), wh); err != nil {
+		h.storeError(w, err)
+		return
+	}
+	h.changed()
+	jsonutil.JSON(w, http.StatusOK, webhookEntry(wh))
+}
+
+func (h *WebhookHandler) remove(w http.ResponseWriter, r *http.Request, id string) {
+	if _, ok := h.owned(w, r, id); !ok {
+		return
+	}
+	if err := h.Deps.Store.Delete(r.Context(), id); err != nil {
+		h.storeError(w, err)
+		return
+	}
+	h.changed()
+	log.Printf("event=webhook_delete id=%s api=%s", id, auth.KeyID(r.Context()))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// owned loads webhook id for the caller. Another key's webhook is reported
+// as not found, so ids cannot be probed.
+func (h *WebhookHandler) owned(w http.ResponseWriter, r *http.Request, id string) (webhook.Webhook, bool) {
+	wh, err := h.Deps.Store.Get(r.Context(), id)
+	if err == nil && wh.Owner != auth.Owner(r.Context()) {
+		err = webhook.ErrNotFound
+	}
+	if err != nil {
+		h.storeError(w, err)
+		return webhook.Webhook{}, false
+	}
+	return wh, true
+}
+
+func (h *WebhookHandler) storeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, webhook.ErrNotFound) {
+		jsonutil.JSON(w, http.StatusNotFound, map[string]string{"error": "webhook not found"})
+		return
+	}
+	if errors.Is(err, webhook.ErrLimit) {
+		jsonutil.JSON(w, http.StatusConflict, map[string]string{"error": "webhook limit reached"})
+		return
+	}
+	log.Printf("event=webhook_store_error err=%v", err)
+	jsonutil.JSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+}
+
+// changed asks Run to reload webhooks.
+func (h *WebhookHandler) changed() {
+	select {
+	case h.reload <- struct{}{}:
+	default:
+	}
+}
+
+// webhookWatch is Run's state: the webhooks on each wallet and the last
+// balance seen for it.
+type webhookWatch struct {
+	h     *WebhookHandler
+	feed  *Feed
+	hooks map[string][]webhook.Webhook
+	last  map[string]types.BalanceEntry
+}
+
+// Run watches the wallets of every webhook until ctx is cancelled, taking
+// changes from hub pushes or, without a live upstream, from polling. A
+// wallet's first reading is its baseline and never fires. Run it on one
+// instance only (see config WebhookWatcher); each running watcher enqueues
+// its own deliveries.
+func (h *WebhookHandler) Run(ctx context.Context) {
+	ws := &webhookWatch{
+		h:     h,
+		feed:  h.Deps.Hub.Subscribe(nil),
+		hooks: make(map[string][]webhook.Webhook),
+		last:  make(map[string]types.BalanceEntry),
+	}
+	defer h.Deps.Hub.Unsubscribe(ws.feed)
+	ws.load(ctx)
+	reload := time.NewTicker(h.Deps.Reload)
+	defer reload.Stop()
+	var pollC <-chan time.Time
+	if h.Deps.Poll > 0 && !h.Deps.Hub.Live() {
+		poll := time.NewTicker(h.Deps.Poll)
+		defer poll.Stop()
+		pollC = poll.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.reload:
+			ws.load(ctx)
+		case <-reload.C:
+			ws.load(ctx)
+		case <-ws.feed.Ready():
+			for _, e := range ws.feed.Take() {
+				ws.observe(ctx, e)
+			}
+		case <-pollC:
+			var resp types.GetBalanceResponse
+			ws.lookup(ctx, ws.wallets(), &resp)
+			for _, e := range resp.Balances {
+				ws.observe(ctx, e)
+			}
+		}
+	}
+}
+
+func (ws *webhookWatch) wallets() []string {
+	out := make([]string, 0, len(ws.hooks))
+	for w := range ws.hooks {
+		out = append(out, w)
+	}
+	return out
+}
+
+func (ws *webhookWatch) lookup(ctx context.Context, wallets []string, resp *types.GetBalanceResponse) {
+	if len(wallets) == 0 {
+		return
+	}
+	b := ws.h.Deps.Balances
+	cm, _ := b.commitment("")
+	b.lookup(ctx, cm, wallets, resp)
+}
+
+// load re-reads webhooks, moves the feed onto their wallets and records a
+// baseline for wallets not watched before. On a store error the previous
+// set stays in force.
+func (ws *webhookWatch) load(ctx context.Context) {
+	all, err := ws.h.Deps.Store.All(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("event=webhook_store_error err=%v", err)
+		}
+		return
+	}
+	hooks := make(map[string][]webhook.Webhook)
+	for _, wh := range all {
+		for _, w := range wh.Wallets {
+			hooks[w] = append(hooks[w], wh)
+		}
+	}
+	var added, removed []string
+	for w := range hooks {
+		if _, ok := ws.hooks[w]; !ok {
+			added = append(added, w)
+		}
+	}
+	for w := range ws.hooks {
+		if _, ok := hooks[w]; !ok {
+			removed = append(removed, w)
+			delete(ws.last, w)
+		}
+	}
+	ws.hooks = hooks
+	ws.h.Deps.Hub.Remove(ws.feed, removed)
+	ws.h.Deps.Hub.Add(ws.feed, added)
+	var resp types.GetBalanceResponse
+	ws.lookup(ctx, added, &resp)
+	for _, e := range resp.Balances {
+		ws.observe(ctx, e)
+	}
+}
+
+// observe records e as its wallet's balance and enqueues a delivery for
+// each webhook the change from the previous balance fires.
+func (ws *webhookWatch) observe(ctx context.Context, e types.BalanceEntry) {
+	hooks, ok := ws.hooks[e.Wallet]
+	if !ok {
+		return
+	}
+	prev, seen := ws.last[e.Wallet]
+	if seen && e.Slot < prev.Slot {
+		return
+	}
+	ws.last[e.Wallet] = e
+	if !seen || prev.Lamports == e.Lamports {
+		return
+	}
+	now := time.Now().UTC()
+	for _, wh := range hooks {
+		if !wh.Fires(prev.Lamports, e.Lamports) {
+			continue
+		}
+		id := webhook.NewID()
+		payload, _ := json.Marshal(types.WebhookEvent{
+			ID:            id,
+			Type:          "balance.changed",
+			WebhookID:     wh.ID,
+			Wallet:        e.Wallet,
+			Previous:      prev,
+			Current:       e,
+			DeltaLamports: int64(e.Lamports) - int64(prev.Lamports),
+			CreatedAt:     now.Format(time.RFC3339),
+		})
+		d := webhook.Delivery{ID: id, WebhookID: wh.ID, Payload: payload, Status: webhook.StatusPending, NextAttempt: now, Created: now}
+		if err := ws.h.Deps.Outbox.Enqueue(ctx, d); err != nil {
+			log.Printf("event=webhook_outbox_error webhook=%s err=%v", wh.ID, err)
+			continue
+		}
+		log.Printf("event=webhook_enqueue id=%s webhook=%s wallet=%s delta=%d", id, wh.ID, e.Wallet, int64(e.Lamports)-int64(prev.Lamports))
+	}
+}
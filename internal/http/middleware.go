@@ -1,10 +1,12 @@
 package apihttp
 
 import (
+	"bufio"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"log"
+	"net"
 	"net/http"
 	"time"
 
@@ -15,10 +17,7 @@ import (
 
 type ctxKey string
 
-const (
-	ctxKeyRequestID ctxKey = "req_id"
-	ctxKeyAPIKeyHP  ctxKey = "api_key_hp"
-)
+const ctxKeyRequestID ctxKey = "req_id"
 
 // RequestID middleware injects a random request id into context and response header.
 func RequestID(next http.Handler) http.Handler {
@@ -40,7 +39,7 @@ func Logger(next http.Handler) http.Handler {
 		next.ServeHTTP(rlw, r)
 		reqID, _ := r.Context().Value(ctxKeyRequestID).(string)
 		ip := rate.IPFromRequest(r)
-		apiHP, _ := r.Context().Value(ctxKeyAPIKeyHP).(string)
+		apiHP := auth.KeyID(r.Context())
 		log.Printf("event=request method=%s path=%s status=%d dur_ms=%d ip=%s req_id=%s api=%s", r.Method, r.URL.Path, rlw.status, time.Since(start).Milliseconds(), ip, reqID, apiHP)
 	})
 }
@@ -49,6 +48,16 @@ type respLogger struct{ http.ResponseWriter; status int }
 
 func (r *respLogger) WriteHeader(code int) { r.status = code; r.ResponseWriter.WriteHeader(code) }
 
+// Hijack lets WebSocket upgrades through the logger.
+func (r *respLogger) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.status = http.StatusSwitchingProtocols
+	return http.NewResponseController(r.ResponseWriter).Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer to flush
+// and extend deadlines on streaming responses.
+func (r *respLogger) Unwrap() http.ResponseWriter { return r.ResponseWriter }
+
 // CORS middleware: allows cross-origin requests for demo/testing UI.
 func CORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -79,27 +88,51 @@ func RateLimit(lm *rate.LimiterMap) func(http.Handler) http.Handler {
 
 // Auth middleware validates the X-API-Key header using the provided store.
 func Auth(store auth.APIKeyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serveWithKey(w, r, store, r.Header.Get("X-API-Key"), next)
+		})
+	}
+}
+
+// StreamAuth is Auth for event streams. EventSource cannot set headers, so
+// without X-API-Key it accepts ?token= holding a token from tokens. The API
+// key itself is never read from the URL, where access logs, browser history
+// and Referer headers would expose it.
+func StreamAuth(store auth.APIKeyStore, tokens *auth.StreamTokens) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			key := r.Header.Get("X-API-Key")
-			if key == "" {
-				jsonutil.JSON(w, http.StatusUnauthorized, map[string]string{"error": "missing api key"})
-				return
+			if token := r.URL.Query().Get("token"); key == "" && token != "" {
+				var ok bool
+				if key, ok = tokens.Key(token); !ok {
+					jsonutil.JSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid or expired stream token"})
+					return
+				}
 			}
-			ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
-			defer cancel()
-			ok, err := store.Validate(ctx, key)
-			if err != nil {
-				jsonutil.JSON(w, http.StatusForbidden, map[string]string{"error": "invalid api key"})
-				return
-			}
-			if !ok {
-				jsonutil.JSON(w, http.StatusForbidden, map[string]string{"error": "invalid or inactive api key"})
-				return
-			}
-			// store hash prefix in context for logging
-			r = r.WithContext(context.WithValue(r.Context(), ctxKeyAPIKeyHP, auth.HashPrefix(key)))
-			next.ServeHTTP(w, r)
+			serveWithKey(w, r, store, key, next)
 		})
 	}
 }
+
+// serveWithKey validates key and serves r with its identity in context.
+func serveWithKey(w http.ResponseWriter, r *http.Request, store auth.APIKeyStore, key string, next http.Handler) {
+	if key == "" {
+		jsonutil.JSON(w, http.StatusUnauthorized, map[string]string{"error": "missing api key"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+	ok, err := store.Validate(ctx, key)
+	if err != nil {
+		jsonutil.JSON(w, http.StatusForbidden, map[string]string{"error": "invalid api key"})
+		return
+	}
+	if !ok {
+		jsonutil.JSON(w, http.StatusForbidden, map[string]string{"error": "invalid or inactive api key"})
+		return
+	}
+	// store hash prefix in context for logging
+	r = r.WithContext(auth.WithKey(r.Context(), key))
+	next.ServeHTTP(w, r)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/example/solapi/internal/auth"
 	"github.com/example/solapi/internal/handlers"
 	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/pkg/jsonutil"
 )
 
 // chain applies middlewares in order over a handler.
@@ -16,8 +17,51 @@ func chain(h http.Handler, mws ...func(http.Handler) http.Handler) http.Handler
 	return h
 }
 
+// Option customizes the router built by NewRouter.
+type Option func(*routerOptions)
+
+type routerOptions struct {
+	routes []route
+	health []healthCheck
+}
+
+type healthCheck struct {
+	name   string
+	report func() any
+}
+
+type route struct {
+	pattern string
+	handler http.Handler
+	// tokens, when set, also admits stream tokens (see StreamAuth)
+	tokens *auth.StreamTokens
+}
+
+// WithRoute mounts an additional auth-protected API endpoint.
+func WithRoute(pattern string, h http.Handler) Option {
+	return func(o *routerOptions) { o.routes = append(o.routes, route{pattern: pattern, handler: h}) }
+}
+
+// WithStreamRoute mounts an event-stream endpoint that also accepts stream
+// tokens issued by tokens in place of the X-API-Key header.
+func WithStreamRoute(pattern string, h http.Handler, tokens *auth.StreamTokens) Option {
+	return func(o *routerOptions) {
+		o.routes = append(o.routes, route{pattern: pattern, handler: h, tokens: tokens})
+	}
+}
+
+// WithHealth adds a component to the /healthz body under name. report is
+// called on every health request and must be cheap.
+func WithHealth(name string, report func() any) Option {
+	return func(o *routerOptions) { o.health = append(o.health, healthCheck{name: name, report: report}) }
+}
+
 // NewRouter wires routes and middlewares using the standard library only.
-func NewRouter(bh *handlers.BalanceHandler, lm *rate.LimiterMap, store auth.APIKeyStore) http.Handler {
+func NewRouter(bh *handlers.BalanceHandler, lm *rate.LimiterMap, store auth.APIKeyStore, opts ...Option) http.Handler {
+	var o routerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
 	mux := http.NewServeMux()
 
 	// Health endpoint
@@ -29,12 +73,27 @@ func NewRouter(bh *handlers.BalanceHandler, lm *rate.LimiterMap, store auth.APIK
 				return
 			}
 		}
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("{\"status\":\"ok\"}"))
+		if len(o.health) == 0 {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("{\"status\":\"ok\"}"))
+			return
+		}
+		body := map[string]any{"status": "ok"}
+		for _, hc := range o.health {
+			body[hc.name] = hc.report()
+		}
+		jsonutil.JSON(w, http.StatusOK, body)
 	})
 
 	// API endpoints (auth-protected)
 	mux.Handle("/api/get-balance", Auth(store)(bh))
+	for _, rt := range o.routes {
+		if rt.tokens != nil {
+			mux.Handle(rt.pattern, StreamAuth(store, rt.tokens)(rt.handler))
+			continue
+		}
+		mux.Handle(rt.pattern, Auth(store)(rt.handler))
+	}
 
 	// Wrap mux with common middlewares (order: req id -> logger -> cors -> rate)
 	return chain(mux, RequestID, Logger, CORS, RateLimit(lm))
@@ -0,0 +1,67 @@
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPSource reads prices from a generic JSON price feed. The feed URL
+// template may contain {asset} and {currency}, which are replaced by the
+// (query-escaped) pair; the response body must be
+//
+//	{"price": 145.23, "timestamp": 1700000000}
+//
+// with timestamp in Unix seconds. A 404 means the feed does not price the
+// pair.
+type HTTPSource struct {
+	template string
+	client   *http.Client
+}
+
+func NewHTTPSource(template string, timeout time.Duration) *HTTPSource {
+	return &HTTPSource{template: template, client: &http.Client{Timeout: timeout}}
+}
+
+func (s *HTTPSource) Name() string { return "http" }
+
+type feedPrice struct {
+	Price     *float64 `json:"price"`
+	Timestamp int64    `json:"timestamp"`
+}
+
+func (s *HTTPSource) Price(ctx context.Context, asset, currency string) (Quote, error) {
+	u := strings.NewReplacer("{asset}", url.QueryEscape(asset), "{currency}", url.QueryEscape(currency)).Replace(s.template)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return Quote{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Quote{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return Quote{}, ErrNoPrice
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Quote{}, fmt.Errorf("price feed: HTTP %d", resp.StatusCode)
+	}
+	var fp feedPrice
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&fp); err != nil {
+		return Quote{}, fmt.Errorf("price feed: %w", err)
+	}
+	if fp.Price == nil || *fp.Price < 0 {
+		return Quote{}, fmt.Errorf("price feed: missing or negative price")
+	}
+	log.Printf("event=price_feed_fetch asset=%s currency=%s latency_ms=%d", asset, currency, time.Since(start).Milliseconds())
+	return Quote{Asset: asset, Currency: currency, Price: *fp.Price, PublishedAt: time.Unix(fp.Timestamp, 0).UTC(), Source: s.Name()}, nil
+}
@@ -0,0 +1,130 @@
+// Package pricing values balances in fiat currencies through pluggable
+// price sources.
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+)
+
+// SOL names native SOL; tokens are named by their mint address.
+const SOL = "SOL"
+
+// Supported quote currencies.
+const (
+	USD = "USD"
+	EUR = "EUR"
+)
+
+// priceNamespace prefixes cache keys holding quotes, keyed by (asset, currency).
+const priceNamespace = "price"
+
+var (
+	// ErrNoPrice is returned when no source prices the pair.
+	ErrNoPrice = errors.New("pricing: no price for pair")
+	// ErrStale is returned when the newest available price is older than
+	// the staleness cutoff.
+	ErrStale = errors.New("pricing: price is stale")
+)
+
+// Quote is the price of one whole unit of Asset in Currency, as published
+// by the source at PublishedAt.
+type Quote struct {
+	Asset       string
+	Currency    string
+	Price       float64
+	PublishedAt time.Time
+	Source      string // the source's Name
+}
+
+// PriceSource looks up the current price of an asset. It returns ErrNoPrice
+// for pairs it does not cover.
+type PriceSource interface {
+	Name() string
+	Price(ctx context.Context, asset, currency string) (Quote, error)
+}
+
+// ParseCurrency validates a quote currency supplied by a caller,
+// case-insensitively.
+func ParseCurrency(s string) (string, bool) {
+	switch c := strings.ToUpper(s); c {
+	case USD, EUR:
+		return c, true
+	}
+	return "", false
+}
+
+// Chain asks each source in turn, returning the first price found. Pairs
+// no source covers report ErrNoPrice; otherwise the last failure is
+// returned.
+type Chain []PriceSource
+
+func (c Chain) Name() string { return "chain" }
+
+func (c Chain) Price(ctx context.Context, asset, currency string) (Quote, error) {
+	err := ErrNoPrice
+	for _, s := range c {
+		q, serr := s.Price(ctx, asset, currency)
+		if serr == nil {
+			return q, nil
+		}
+		if !errors.Is(serr, ErrNoPrice) {
+			err = serr
+		}
+	}
+	return Quote{}, err
+}
+
+// Pricer caches quotes from a source in a cache of its own and refuses
+// quotes older than MaxAge. Pairs the source has no price for are cached
+// too, so unpriced mints do not reach the source on every request.
+type Pricer struct {
+	source PriceSource
+	cache  *cache.Cache
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+// NewPricer caches quotes for ttl. A quote published more than maxAge ago
+// is refused with ErrStale; zero disables the cutoff.
+func NewPricer(source PriceSource, ttl, maxAge time.Duration) *Pricer {
+	return &Pricer{source: source, cache: cache.New(ttl), maxAge: maxAge, now: time.Now}
+}
+
+// WithMissingTTL caps how long a pair without a price is remembered, so
+// that a newly listed price is picked up soon. Call it before the pricer
+// is used.
+func (p *Pricer) WithMissingTTL(ttl time.Duration) *Pricer {
+	p.cache.WithMissingTTL(ttl)
+	return p
+}
+
+// Quote returns the price of asset in currency.
+func (p *Pricer) Quote(ctx context.Context, asset, currency string) (Quote, error) {
+	val, _, err := p.cache.GetOrFetch(ctx, cache.Key(priceNamespace, asset, currency), func(ctx context.Context) (cache.Value, error) {
+		q, err := p.source.Price(ctx, asset, currency)
+		if errors.Is(err, ErrNoPrice) {
+			return cache.Value{Missing: true, FetchedAt: p.now().UTC()}, nil
+		}
+		if err != nil {
+			return cache.Value{}, err
+		}
+		return cache.Value{Data: q, FetchedAt: p.now().UTC()}, nil
+	})
+	if err != nil {
+		return Quote{}, err
+	}
+	if val.Missing {
+		return Quote{}, ErrNoPrice
+	}
+	q, _ := val.Data.(Quote)
+	if age := p.now().Sub(q.PublishedAt); p.maxAge > 0 && age > p.maxAge {
+		return Quote{}, fmt.Errorf("%w: %s/%s published %s ago", ErrStale, asset, currency, age.Round(time.Second))
+	}
+	return q, nil
+}
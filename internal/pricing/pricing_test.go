@@ -0,0 +1,108 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeSource struct {
+	mu     sync.Mutex
+	calls  int
+	quotes map[string]Quote
+	err    error
+}
+
+func (f *fakeSource) Name() string { return "fake" }
+
+func (f *fakeSource) Price(_ context.Context, asset, currency string) (Quote, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.err != nil { return Quote{}, f.err }
+	q, ok := f.quotes[asset+"/"+currency]
+	if !ok { return Quote{}, ErrNoPrice }
+	return q, nil
+}
+
+func TestParseCurrency(t *testing.T) {
+	if c, ok := ParseCurrency("usd"); !ok || c != USD { t.Fatalf("usd -> %q %v", c, ok) }
+	if c, ok := ParseCurrency("EUR"); !ok || c != EUR { t.Fatalf("EUR -> %q %v", c, ok) }
+	if _, ok := ParseCurrency("btc"); ok { t.Fatalf("btc accepted") }
+}
+
+func TestChainFallsThrough(t *testing.T) {
+	now := time.Now()
+	down := &fakeSource{err: errors.New("boom")}
+	empty := &fakeSource{}
+	good := &fakeSource{quotes: map[string]Quote{"SOL/USD": {Price: 150, PublishedAt: now}}}
+	if q, err := (Chain{down, empty, good}).Price(context.Background(), SOL, USD); err != nil || q.Price != 150 {
+		t.Fatalf("q=%+v err=%v", q, err)
+	}
+	if _, err := (Chain{empty, good}).Price(context.Background(), SOL, EUR); !errors.Is(err, ErrNoPrice) {
+		t.Fatalf("uncovered pair err=%v", err)
+	}
+	if _, err := (Chain{down, empty}).Price(context.Background(), SOL, USD); err == nil || errors.Is(err, ErrNoPrice) {
+		t.Fatalf("failing source err=%v", err)
+	}
+}
+
+func TestPricerCachesAndRefusesStale(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	src := &fakeSource{quotes: map[string]Quote{
+		"SOL/USD": {Asset: SOL, Currency: USD, Price: 150, PublishedAt: now.Add(-10 * time.Second)},
+		"SOL/EUR": {Asset: SOL, Currency: EUR, Price: 140, PublishedAt: now.Add(-5 * time.Minute)},
+	}}
+	p := NewPricer(src, time.Minute, time.Minute)
+	p.now = func() time.Time { return now }
+	for i := 0; i < 3; i++ {
+		if q, err := p.Quote(context.Background(), SOL, USD); err != nil || q.Price != 150 { t.Fatalf("q=%+v err=%v", q, err) }
+	}
+	if src.calls != 1 { t.Fatalf("source calls=%d, want 1", src.calls) }
+	if _, err := p.Quote(context.Background(), SOL, EUR); !errors.Is(err, ErrStale) { t.Fatalf("stale err=%v", err) }
+
+	// a cached quote goes stale as time passes
+	p.now = func() time.Time { return now.Add(55 * time.Second) }
+	if _, err := p.Quote(context.Background(), SOL, USD); !errors.Is(err, ErrStale) { t.Fatalf("aged err=%v", err) }
+}
+
+func TestPricerCachesMissingPrices(t *testing.T) {
+	src := &fakeSource{}
+	p := NewPricer(src, time.Minute, time.Minute).WithMissingTTL(20 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if _, err := p.Quote(context.Background(), "mint", USD); !errors.Is(err, ErrNoPrice) { t.Fatalf("err=%v", err) }
+	}
+	if src.calls != 1 { t.Fatalf("source calls=%d, want 1", src.calls) }
+	time.Sleep(30 * time.Millisecond)
+	if _, err := p.Quote(context.Background(), "mint", USD); !errors.Is(err, ErrNoPrice) || src.calls != 2 { t.Fatalf("after missing TTL err=%v calls=%d", err, src.calls) }
+
+	// other failures are not cached
+	down := &fakeSource{err: errors.New("boom")}
+	p = NewPricer(down, time.Minute, time.Minute)
+	_, _ = p.Quote(context.Background(), SOL, USD)
+	if _, err := p.Quote(context.Background(), SOL, USD); err == nil || errors.Is(err, ErrNoPrice) || down.calls != 2 { t.Fatalf("err=%v calls=%d", err, down.calls) }
+}
+
+func TestHTTPSource(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("ids") != "SOL" { http.NotFound(w, r); return }
+		if r.URL.Query().Get("vs") == "EUR" { w.WriteHeader(http.StatusBadGateway); return }
+		_, _ = w.Write([]byte(`{"price": 145.5, "timestamp": 1700000000}`))
+	}))
+	defer ts.Close()
+	s := NewHTTPSource(ts.URL+"/price?ids={asset}&vs={currency}", time.Second)
+	q, err := s.Price(context.Background(), SOL, USD)
+	if err != nil || q.Price != 145.5 || !q.PublishedAt.Equal(time.Unix(1_700_000_000, 0)) || q.Source != "http" {
+		t.Fatalf("q=%+v err=%v", q, err)
+	}
+	if _, err := s.Price(context.Background(), "So11111111111111111111111111111111111111112", USD); !errors.Is(err, ErrNoPrice) {
+		t.Fatalf("unknown asset err=%v", err)
+	}
+	if _, err := s.Price(context.Background(), SOL, EUR); err == nil || errors.Is(err, ErrNoPrice) {
+		t.Fatalf("upstream failure err=%v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package pricing
+
+import (
+	"context"
+	"log"
+
+	"github.com/example/solapi/internal/solana"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// PythSource reads prices from on-chain Pyth price accounts.
+type PythSource struct {
+	fetcher solana.PythPriceFetcher
+	feeds   map[string]sol.PublicKey
+}
+
+// NewPythSource prices the pairs in feeds, which maps "ASSET/CURRENCY"
+// (e.g. "SOL/USD") to the pair's Pyth price account.
+func NewPythSource(fetcher solana.PythPriceFetcher, feeds map[string]sol.PublicKey) *PythSource {
+	return &PythSource{fetcher: fetcher, feeds: feeds}
+}
+
+func (s *PythSource) Name() string { return "pyth" }
+
+func (s *PythSource) Price(ctx context.Context, asset, currency string) (Quote, error) {
+	account, ok := s.feeds[asset+"/"+currency]
+	if !ok {
+		return Quote{}, ErrNoPrice
+	}
+	p, latency, err := s.fetcher.GetPythPrice(ctx, account)
+	if err != nil {
+		return Quote{}, err
+	}
+	log.Printf("event=rpc_fetch_price asset=%s currency=%s latency_ms=%d", asset, currency, latency.Milliseconds())
+	return Quote{Asset: asset, Currency: currency, Price: p.Price, PublishedAt: p.PublishedAt, Source: s.Name()}, nil
+}
@@ -0,0 +1,196 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Account kinds, as classified by GetAccountInfos.
+const (
+	KindWallet       = "wallet"        // system-owned with no data
+	KindProgram      = "program"       // executable
+	KindTokenAccount = "token_account" // SPL Token or Token-2022 token account
+	KindMint         = "mint"          // SPL Token or Token-2022 mint
+	KindData         = "data"          // any other data account, e.g. PDA state
+)
+
+// Token program account layouts: a token account is 165 bytes and a mint 82.
+// Token-2022 accounts with extensions are longer and mark their type in the
+// byte after the base token account layout.
+const (
+	tokenAccountSize   = 165
+	tokenMintSize      = 82
+	tokenAccountTypeAt = 165
+	tokenTypeMint      = 1
+	tokenTypeAccount   = 2
+)
+
+// Rent parameters, fixed on every public cluster: an account is exempt from
+// rent when it holds two years of rent for its data plus the 128-byte
+// account header.
+const (
+	rentLamportsPerByteYear = 3480
+	rentExemptionYears      = 2
+	accountStorageOverhead  = 128
+)
+
+// RentExemptMinimum is the balance at which an account with dataLen bytes
+// of data is rent-exempt.
+func RentExemptMinimum(dataLen uint64) uint64 {
+	return (accountStorageOverhead + dataLen) * rentLamportsPerByteYear * rentExemptionYears
+}
+
+// AccountInfo is the metadata of one account. When Exists is false only
+// Slot is set.
+type AccountInfo struct {
+	Exists     bool
+	Lamports   uint64
+	Owner      sol.PublicKey
+	Executable bool
+	DataLen    uint64
+	RentEpoch  uint64
+	Kind       string
+	Slot       uint64
+}
+
+// AccountInfoFetcher abstracts reading account metadata in batches. Results
+// are in the same order as pubkeys.
+type AccountInfoFetcher interface {
+	GetAccountInfos(ctx context.Context, pubkeys []sol.PublicKey, commitment rpc.CommitmentType) (infos []AccountInfo, latency time.Duration, err error)
+}
+
+// rawAccount mirrors a getMultipleAccounts entry. Space, the full data
+// length, is reported by nodes since 1.18 whatever the data slice.
+type rawAccount struct {
+	Lamports   uint64    `json:"lamports"`
+	Owner      string    `json:"owner"`
+	Executable bool      `json:"executable"`
+	RentEpoch  uint64    `json:"rentEpoch"`
+	Space      *uint64   `json:"space"`
+	Data       [2]string `json:"data"` // [base64, "base64"]
+}
+
+type rawMultipleAccounts struct {
+	Context struct {
+		Slot uint64 `json:"slot"`
+	} `json:"context"`
+	Value []*rawAccount `json:"value"`
+}
+
+// GetAccountInfos reads account metadata via getMultipleAccounts in chunks
+// of MaxMultipleAccounts, fetching only the head of each account's data.
+// Accounts on nodes that do not report space are re-read in full to learn
+// their length.
+func (cl *Client) GetAccountInfos(ctx context.Context, pubkeys []sol.PublicKey, commitment rpc.CommitmentType) ([]AccountInfo, time.Duration, error) {
+	start := time.Now()
+	out := make([]AccountInfo, 0, len(pubkeys))
+	for i := 0; i < len(pubkeys); i += MaxMultipleAccounts {
+		end := min(i+MaxMultipleAccounts, len(pubkeys))
+		chunk := pubkeys[i:end]
+		res, err := cl.multipleAccounts(ctx, chunk, commitment, &rpc.DataSlice{Offset: uint64Ptr(0), Length: uint64Ptr(tokenAccountTypeAt + 1)})
+		if err != nil {
+			return nil, time.Since(start), err
+		}
+		var unsized []sol.PublicKey
+		for j, acc := range res.Value {
+			if acc != nil && acc.Space == nil {
+				unsized = append(unsized, chunk[j])
+			}
+		}
+		var full *rawMultipleAccounts
+		if len(unsized) > 0 {
+			if full, err = cl.multipleAccounts(ctx, unsized, commitment, nil); err != nil {
+				return nil, time.Since(start), err
+			}
+		}
+		for j := range chunk {
+			if j >= len(res.Value) {
+				return nil, time.Since(start), fmt.Errorf("getMultipleAccounts returned %d of %d accounts", len(res.Value), len(chunk))
+			}
+			acc := res.Value[j]
+			if acc == nil {
+				out = append(out, AccountInfo{Slot: res.Context.Slot})
+				continue
+			}
+			head, err := base64.StdEncoding.DecodeString(acc.Data[0])
+			if err != nil {
+				return nil, time.Since(start), fmt.Errorf("account %s data: %w", chunk[j], err)
+			}
+			size := uint64(len(head))
+			if acc.Space != nil {
+				size = *acc.Space
+			} else if full != nil && len(full.Value) > 0 {
+				if fa := full.Value[0]; fa != nil {
+					data, err := base64.StdEncoding.DecodeString(fa.Data[0])
+					if err != nil {
+						return nil, time.Since(start), fmt.Errorf("account %s data: %w", chunk[j], err)
+					}
+					size = uint64(len(data))
+				}
+				full.Value = full.Value[1:]
+			}
+			owner, err := sol.PublicKeyFromBase58(acc.Owner)
+			if err != nil {
+				return nil, time.Since(start), fmt.Errorf("account %s owner: %w", chunk[j], err)
+			}
+			info := AccountInfo{
+				Exists:     true,
+				Lamports:   acc.Lamports,
+				Owner:      owner,
+				Executable: acc.Executable,
+				DataLen:    size,
+				RentEpoch:  acc.RentEpoch,
+				Slot:       res.Context.Slot,
+			}
+			info.Kind = accountKind(info, head)
+			out = append(out, info)
+		}
+	}
+	return out, time.Since(start), nil
+}
+
+func (cl *Client) multipleAccounts(ctx context.Context, pubkeys []sol.PublicKey, commitment rpc.CommitmentType, slice *rpc.DataSlice) (*rawMultipleAccounts, error) {
+	opts := map[string]interface{}{
+		"encoding":   sol.EncodingBase64,
+		"commitment": cl.commitmentOr(commitment),
+	}
+	if slice != nil {
+		opts["dataSlice"] = slice
+	}
+	var out rawMultipleAccounts
+	if err := cl.c.RPCCallForInto(ctx, &out, "getMultipleAccounts", []interface{}{pubkeys, opts}); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// accountKind classifies an existing account from its metadata and the
+// head of its data.
+func accountKind(info AccountInfo, head []byte) string {
+	switch {
+	case info.Executable:
+		return KindProgram
+	case info.Owner.Equals(sol.SystemProgramID) && info.DataLen == 0:
+		return KindWallet
+	case info.Owner.Equals(sol.TokenProgramID) || info.Owner.Equals(Token2022ProgramID):
+		switch {
+		case info.DataLen == tokenAccountSize:
+			return KindTokenAccount
+		case info.DataLen == tokenMintSize:
+			return KindMint
+		case info.DataLen > tokenAccountSize && len(head) > tokenAccountTypeAt:
+			switch head[tokenAccountTypeAt] {
+			case tokenTypeAccount:
+				return KindTokenAccount
+			case tokenTypeMint:
+				return KindMint
+			}
+		}
+	}
+	return KindData
+}
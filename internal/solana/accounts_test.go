@@ -0,0 +1,65 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	sol "github.com/gagliardetto/solana-go"
+)
+
+func TestRentExemptMinimum(t *testing.T) {
+	// the well-known minimums for an empty account and an SPL token account
+	if got := RentExemptMinimum(0); got != 890_880 { t.Fatalf("empty=%d", got) }
+	if got := RentExemptMinimum(165); got != 2_039_280 { t.Fatalf("token account=%d", got) }
+}
+
+func TestClient_GetAccountInfosClassifiesAccounts(t *testing.T) {
+	t22Mint := make([]byte, 166)
+	t22Mint[165] = 1
+	accounts := map[int]map[string]any{
+		0: {"lamports": 5, "owner": sol.SystemProgramID.String(), "space": 0, "data": []string{"", "base64"}},
+		1: {"lamports": 1, "owner": "BPFLoaderUpgradeab1e11111111111111111111111", "executable": true, "space": 36, "data": []string{"", "base64"}},
+		2: {"lamports": 2_039_280, "owner": sol.TokenProgramID.String(), "space": 165, "data": []string{"", "base64"}},
+		3: {"lamports": 9, "owner": Token2022ProgramID.String(), "space": 234, "data": []string{base64.StdEncoding.EncodeToString(t22Mint), "base64"}},
+		// an older node without space: data is re-read in full
+		5: {"lamports": 9, "owner": "Stake11111111111111111111111111111111111111", "rentEpoch": 361, "data": []string{"", "base64"}},
+	}
+	var sliced []any
+	stub := newRPCStub(t, map[string]func([]json.RawMessage) any{
+		"getMultipleAccounts": func(params []json.RawMessage) any {
+			var keys []string
+			_ = json.Unmarshal(params[0], &keys)
+			var opts map[string]any
+			_ = json.Unmarshal(params[1], &opts)
+			sliced = append(sliced, opts["dataSlice"])
+			if opts["dataSlice"] == nil {
+				full := map[string]any{"lamports": 9, "owner": "Stake11111111111111111111111111111111111111", "data": []string{base64.StdEncoding.EncodeToString(make([]byte, 200)), "base64"}}
+				return map[string]any{"context": map[string]any{"slot": 8}, "value": []any{full}}
+			}
+			values := make([]any, len(keys))
+			for i := range keys {
+				if acc, ok := accounts[i]; ok {
+					values[i] = acc
+				}
+			}
+			return map[string]any{"context": map[string]any{"slot": 7}, "value": values}
+		},
+	})
+
+	pks := make([]sol.PublicKey, 6)
+	for i := range pks {
+		pks[i] = sol.NewWallet().PublicKey()
+	}
+	infos, _, err := NewClient(stub.URL, "").GetAccountInfos(context.Background(), pks, "")
+	if err != nil { t.Fatalf("GetAccountInfos: %v", err) }
+	if len(infos) != 6 || len(sliced) != 2 || sliced[0] == nil { t.Fatalf("infos=%d calls=%v", len(infos), sliced) }
+	want := []string{KindWallet, KindProgram, KindTokenAccount, KindMint, "", KindData}
+	for i, info := range infos {
+		if info.Kind != want[i] { t.Fatalf("infos[%d].Kind=%q want %q", i, info.Kind, want[i]) }
+	}
+	if infos[4].Exists || infos[4].Slot != 7 { t.Fatalf("missing account=%+v", infos[4]) }
+	if !infos[3].Exists || infos[3].DataLen != 234 || !infos[3].Owner.Equals(Token2022ProgramID) { t.Fatalf("t22 mint=%+v", infos[3]) }
+	if infos[5].DataLen != 200 || infos[5].RentEpoch != 361 { t.Fatalf("unsized account=%+v", infos[5]) }
+}
@@ -0,0 +1,118 @@
+package solana
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Batcher is a BalanceFetcher that coalesces individual GetBalance calls from
+// concurrent callers into batched upstream calls. Keys are collected until the
+// window elapses or maxKeys distinct keys are pending, whichever comes first.
+// Each commitment level is batched separately.
+type Batcher struct {
+	inner   BatchBalanceFetcher
+	window  time.Duration
+	maxKeys int
+	timeout time.Duration
+
+	mu      sync.Mutex
+	pending map[rpc.CommitmentType]*pendingBatch
+}
+
+// pendingBatch collects the keys and waiters for one commitment level.
+type pendingBatch struct {
+	order   []sol.PublicKey
+	waiters map[sol.PublicKey][]chan batchResult
+	timer   *time.Timer
+}
+
+type batchResult struct {
+	bal     Balance
+	latency time.Duration
+	err     error
+}
+
+// NewBatcher wraps inner. timeout bounds each upstream batch call, since a
+// batch outlives any single caller's context.
+func NewBatcher(inner BatchBalanceFetcher, window time.Duration, maxKeys int, timeout time.Duration) *Batcher {
+	if maxKeys <= 0 || maxKeys > MaxMultipleAccounts {
+		maxKeys = MaxMultipleAccounts
+	}
+	return &Batcher{
+		inner:   inner,
+		window:  window,
+		maxKeys: maxKeys,
+		timeout: timeout,
+		pending: make(map[rpc.CommitmentType]*pendingBatch),
+	}
+}
+
+// GetBalance enqueues pubkey into the current batch for commitment and waits
+// for its result.
+func (b *Batcher) GetBalance(ctx context.Context, pubkey sol.PublicKey, commitment rpc.CommitmentType) (Balance, time.Duration, error) {
+	ch := make(chan batchResult, 1)
+	b.enqueue(pubkey, commitment, ch)
+	select {
+	case res := <-ch:
+		return res.bal, res.latency, res.err
+	case <-ctx.Done():
+		return Balance{}, 0, ctx.Err()
+	}
+}
+
+func (b *Batcher) enqueue(pubkey sol.PublicKey, commitment rpc.CommitmentType, ch chan batchResult) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	pb, ok := b.pending[commitment]
+	if !ok {
+		pb = &pendingBatch{waiters: make(map[sol.PublicKey][]chan batchResult)}
+		b.pending[commitment] = pb
+		pb.timer = time.AfterFunc(b.window, func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			// a size-triggered flush may already have taken this batch
+			if b.pending[commitment] == pb {
+				b.flushLocked(commitment)
+			}
+		})
+	}
+	if _, ok := pb.waiters[pubkey]; !ok {
+		pb.order = append(pb.order, pubkey)
+	}
+	pb.waiters[pubkey] = append(pb.waiters[pubkey], ch)
+	if len(pb.order) >= b.maxKeys {
+		b.flushLocked(commitment)
+	}
+}
+
+// flushLocked hands the pending batch for commitment to a goroutine. Caller
+// holds b.mu.
+func (b *Batcher) flushLocked(commitment rpc.CommitmentType) {
+	pb, ok := b.pending[commitment]
+	if !ok {
+		return
+	}
+	pb.timer.Stop()
+	delete(b.pending, commitment)
+	go b.run(commitment, pb)
+}
+
+func (b *Batcher) run(commitment rpc.CommitmentType, pb *pendingBatch) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+	defer cancel()
+	results, latency, err := b.inner.GetBalances(ctx, pb.order, commitment)
+	for i, pk := range pb.order {
+		res := batchResult{latency: latency, err: err}
+		if err == nil {
+			res.bal = Balance{Lamports: results[i].Lamports, Slot: results[i].Slot, Exists: results[i].Exists}
+			res.err = results[i].Err
+		}
+		for _, ch := range pb.waiters[pk] {
+			ch <- res
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+type countingBatch struct {
+	mu    sync.Mutex
+	calls [][]sol.PublicKey
+	fail  sol.PublicKey
+
+	byCommitment map[rpc.CommitmentType][]sol.PublicKey
+}
+
+func (f *countingBatch) GetBalance(context.Context, sol.PublicKey, rpc.CommitmentType) (Balance, time.Duration, error) {
+	return Balance{}, 0, errors.New("not used")
+}
+
+func (f *countingBatch) GetBalances(_ context.Context, pks []sol.PublicKey, cm rpc.CommitmentType) ([]BalanceResult, time.Duration, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, pks)
+	if f.byCommitment == nil {
+		f.byCommitment = make(map[rpc.CommitmentType][]sol.PublicKey)
+	}
+	f.byCommitment[cm] = append(f.byCommitment[cm], pks...)
+	f.mu.Unlock()
+	out := make([]BalanceResult, len(pks))
+	for i, pk := range pks {
+		if pk == f.fail {
+			out[i].Err = errors.New("bad account")
+			continue
+		}
+		out[i].Lamports = uint64(pk[0])
+	}
+	return out, time.Millisecond, nil
+}
+
+func TestBatcher_CoalescesWithinWindow(t *testing.T) {
+	inner := &countingBatch{}
+	b := NewBatcher(inner, 20*time.Millisecond, 100, time.Second)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var pk sol.PublicKey
+			pk[0] = byte(i%5) + 1 // 5 distinct keys, each asked twice
+			got, _, err := b.GetBalance(context.Background(), pk, "")
+			if err != nil || got.Lamports != uint64(i%5)+1 {
+				t.Errorf("key %d: got=%+v err=%v", i%5, got, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(inner.calls) != 1 || len(inner.calls[0]) != 5 {
+		t.Fatalf("calls=%v", inner.calls)
+	}
+}
+
+func TestBatcher_FlushesAtMaxKeysAndIsolatesErrors(t *testing.T) {
+	var bad sol.PublicKey
+	bad[0] = 2
+	inner := &countingBatch{fail: bad}
+	b := NewBatcher(inner, time.Hour, 3, time.Second)
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var pk sol.PublicKey
+			pk[0] = byte(i + 1)
+			_, _, errs[i] = b.GetBalance(context.Background(), pk, "")
+		}(i)
+	}
+	wg.Wait()
+	if len(inner.calls) != 1 { t.Fatalf("calls=%d", len(inner.calls)) }
+	if errs[0] != nil || errs[1] == nil || errs[2] != nil { t.Fatalf("errs=%v", errs) }
+}
+
+func TestBatcher_CallerContextCancel(t *testing.T) {
+	b := NewBatcher(&countingBatch{}, time.Hour, 100, time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := b.GetBalance(ctx, sol.PublicKey{}, ""); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestBatcher_SeparatesCommitments(t *testing.T) {
+	inner := &countingBatch{}
+	b := NewBatcher(inner, 20*time.Millisecond, 100, time.Second)
+	var wg sync.WaitGroup
+	for _, cm := range []rpc.CommitmentType{rpc.CommitmentConfirmed, rpc.CommitmentFinalized, rpc.CommitmentConfirmed} {
+		wg.Add(1)
+		go func(cm rpc.CommitmentType) {
+			defer wg.Done()
+			if _, _, err := b.GetBalance(context.Background(), sol.PublicKey{1}, cm); err != nil { t.Errorf("%s: %v", cm, err) }
+		}(cm)
+	}
+	wg.Wait()
+	if len(inner.calls) != 2 { t.Fatalf("calls=%v", inner.calls) }
+	for cm, keys := range inner.byCommitment {
+		if len(keys) != 1 { t.Fatalf("%s batch=%v", cm, keys) }
+	}
+}
@@ -0,0 +1,179 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ErrCircuitOpen is returned without calling upstream while the breaker is open.
+var ErrCircuitOpen = errors.New("solana: circuit breaker open, upstream unavailable")
+
+// Breaker states.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// BreakerOptions configures a Breaker. Zero values take the defaults.
+type BreakerOptions struct {
+	// FailureThreshold consecutive failures open the breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before letting probes
+	// through in the half-open state.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is how many calls may be in flight while half-open; the
+	// first success closes the breaker and any failure reopens it.
+	HalfOpenProbes int
+}
+
+const (
+	defaultBreakerFailures = 5
+	defaultBreakerOpen     = 10 * time.Second
+)
+
+// BreakerStatus is a snapshot of a Breaker for health reporting.
+type BreakerStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+}
+
+// Breaker is a circuit breaker for balance fetchers. While open, calls fail
+// fast with ErrCircuitOpen instead of each waiting out its timeout against an
+// upstream that is down.
+type Breaker struct {
+	opts BreakerOptions
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+func NewBreaker(opts BreakerOptions) *Breaker {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = defaultBreakerFailures
+	}
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = defaultBreakerOpen
+	}
+	if opts.HalfOpenProbes <= 0 {
+		opts.HalfOpenProbes = 1
+	}
+	return &Breaker{opts: opts, state: BreakerClosed}
+}
+
+// Wrap guards f with the breaker. The result is a BatchBalanceFetcher when f
+// is one, so callers keep their batched path.
+func (b *Breaker) Wrap(f BalanceFetcher) BalanceFetcher {
+	if bf, ok := f.(BatchBalanceFetcher); ok {
+		return &breakerBatchFetcher{breakerFetcher{b: b, inner: f}, bf}
+	}
+	return &breakerFetcher{b: b, inner: f}
+}
+
+// Status reports the current state.
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advanceLocked(time.Now())
+	st := BreakerStatus{State: b.state, ConsecutiveFailures: b.failures}
+	if b.state != BreakerClosed {
+		at := b.openedAt
+		st.OpenedAt = &at
+	}
+	return st
+}
+
+// advanceLocked moves an open breaker to half-open once OpenTimeout passed.
+func (b *Breaker) advanceLocked(now time.Time) {
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.opts.OpenTimeout {
+		b.state = BreakerHalfOpen
+		b.probes = 0
+		log.Printf("event=breaker_state state=%s", b.state)
+	}
+}
+
+// allow reports whether a call may go upstream and whether it is a half-open
+// probe. An allowed call must be followed by exactly one done.
+func (b *Breaker) allow() (probe, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advanceLocked(time.Now())
+	switch b.state {
+	case BreakerOpen:
+		return false, false
+	case BreakerHalfOpen:
+		if b.probes >= b.opts.HalfOpenProbes {
+			return false, false
+		}
+		b.probes++
+		return true, true
+	}
+	return false, true
+}
+
+// done records the outcome of an allowed call.
+func (b *Breaker) done(ctx context.Context, probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe && b.state == BreakerHalfOpen {
+		b.probes--
+	}
+	// a caller that went away says nothing about upstream health; a timeout does
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		return
+	}
+	if err == nil {
+		if b.state != BreakerClosed {
+			log.Printf("event=breaker_state state=%s", BreakerClosed)
+		}
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.opts.FailureThreshold) {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+		log.Printf("event=breaker_state state=%s failures=%d", b.state, b.failures)
+	}
+}
+
+type breakerFetcher struct {
+	b     *Breaker
+	inner BalanceFetcher
+}
+
+func (f *breakerFetcher) GetBalance(ctx context.Context, pubkey sol.PublicKey, commitment rpc.CommitmentType) (Balance, time.Duration, error) {
+	probe, ok := f.b.allow()
+	if !ok {
+		return Balance{}, 0, ErrCircuitOpen
+	}
+	bal, lat, err := f.inner.GetBalance(ctx, pubkey, commitment)
+	f.b.done(ctx, probe, err)
+	return bal, lat, err
+}
+
+type breakerBatchFetcher struct {
+	breakerFetcher
+	batch BatchBalanceFetcher
+}
+
+func (f *breakerBatchFetcher) GetBalances(ctx context.Context, pubkeys []sol.PublicKey, commitment rpc.CommitmentType) ([]BalanceResult, time.Duration, error) {
+	probe, ok := f.b.allow()
+	if !ok {
+		return nil, 0, ErrCircuitOpen
+	}
+	res, lat, err := f.batch.GetBalances(ctx, pubkeys, commitment)
+	f.b.done(ctx, probe, err)
+	return res, lat, err
+}
@@ -0,0 +1,73 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+type flakyFetcher struct {
+	err   error
+	calls int
+}
+
+func (f *flakyFetcher) GetBalance(context.Context, sol.PublicKey, rpc.CommitmentType) (Balance, time.Duration, error) {
+	f.calls++
+	return Balance{Lamports: 1}, 0, f.err
+}
+
+func TestBreaker_OpensHalfOpensAndCloses(t *testing.T) {
+	inner := &flakyFetcher{err: errors.New("upstream down")}
+	br := NewBreaker(BreakerOptions{FailureThreshold: 3, OpenTimeout: 30 * time.Millisecond})
+	f := br.Wrap(inner)
+	if _, ok := f.(BatchBalanceFetcher); ok { t.Fatalf("single fetcher should not gain GetBalances") }
+	ctx := context.Background()
+	for i := 0; i < 3; i++ { _, _, _ = f.GetBalance(ctx, sol.PublicKey{}, "") }
+	if st := br.Status(); st.State != BreakerOpen || st.ConsecutiveFailures != 3 || st.OpenedAt == nil { t.Fatalf("status=%+v", st) }
+	if _, _, err := f.GetBalance(ctx, sol.PublicKey{}, ""); !errors.Is(err, ErrCircuitOpen) || inner.calls != 3 { t.Fatalf("err=%v calls=%d", err, inner.calls) }
+
+	// a failed probe reopens immediately
+	time.Sleep(40 * time.Millisecond)
+	if st := br.Status(); st.State != BreakerHalfOpen { t.Fatalf("state=%s", st.State) }
+	_, _, _ = f.GetBalance(ctx, sol.PublicKey{}, "")
+	if st := br.Status(); st.State != BreakerOpen || inner.calls != 4 { t.Fatalf("status=%+v calls=%d", st, inner.calls) }
+
+	time.Sleep(40 * time.Millisecond)
+	inner.err = nil
+	if _, _, err := f.GetBalance(ctx, sol.PublicKey{}, ""); err != nil { t.Fatalf("probe: %v", err) }
+	if st := br.Status(); st.State != BreakerClosed || st.ConsecutiveFailures != 0 || st.OpenedAt != nil { t.Fatalf("status=%+v", st) }
+}
+
+func TestBreaker_HalfOpenLimitsProbes(t *testing.T) {
+	br := NewBreaker(BreakerOptions{FailureThreshold: 1, OpenTimeout: time.Millisecond})
+	br.done(context.Background(), false, errors.New("x"))
+	time.Sleep(5 * time.Millisecond)
+	if probe, ok := br.allow(); !ok || !probe { t.Fatalf("first probe should pass") }
+	if _, ok := br.allow(); ok { t.Fatalf("second concurrent probe should be rejected") }
+}
+
+func TestBreaker_CallerCancelIsNeutral(t *testing.T) {
+	br := NewBreaker(BreakerOptions{FailureThreshold: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f := br.Wrap(&flakyFetcher{err: context.Canceled})
+	_, _, _ = f.GetBalance(ctx, sol.PublicKey{}, "")
+	if st := br.Status(); st.State != BreakerClosed || st.ConsecutiveFailures != 0 { t.Fatalf("status=%+v", st) }
+
+	// a deadline is the upstream being slow and does count
+	dctx, dcancel := context.WithTimeout(context.Background(), 0)
+	defer dcancel()
+	_, _, _ = br.Wrap(&flakyFetcher{err: context.DeadlineExceeded}).GetBalance(dctx, sol.PublicKey{}, "")
+	if st := br.Status(); st.State != BreakerOpen { t.Fatalf("state=%s", st.State) }
+}
+
+func TestBreaker_WrapKeepsBatching(t *testing.T) {
+	br := NewBreaker(BreakerOptions{FailureThreshold: 1})
+	bf, ok := br.Wrap(&countingBatch{}).(BatchBalanceFetcher)
+	if !ok { t.Fatalf("batch fetcher lost GetBalances") }
+	if _, _, err := bf.GetBalances(context.Background(), []sol.PublicKey{{1}}, ""); err != nil { t.Fatalf("GetBalances: %v", err) }
+}
@@ -2,15 +2,55 @@ package solana
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"time"
 
 	sol "github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
 )
 
-// BalanceFetcher abstracts fetching balances for a wallet.
+// MaxMultipleAccounts is the largest number of keys getMultipleAccounts accepts per call.
+const MaxMultipleAccounts = 100
+
+// Balance is a wallet's lamports together with the slot the node read them at.
+type Balance struct {
+	Lamports uint64
+	Slot     uint64
+	// Exists is false when no account is at the address, as opposed to an
+	// account holding nothing.
+	Exists bool
+}
+
+// BalanceFetcher abstracts fetching balances for a wallet. An empty
+// commitment means the fetcher's default.
 type BalanceFetcher interface {
-	GetBalance(ctx context.Context, pubkey sol.PublicKey) (lamports uint64, latency time.Duration, err error)
+	GetBalance(ctx context.Context, pubkey sol.PublicKey, commitment rpc.CommitmentType) (bal Balance, latency time.Duration, err error)
+}
+
+// BalanceResult is the outcome for one wallet of a batched lookup.
+type BalanceResult struct {
+	Lamports uint64
+	Slot     uint64
+	Exists   bool
+	Err      error
+}
+
+// BatchBalanceFetcher resolves many wallets per upstream call. Results are
+// returned in the same order as pubkeys; a non-nil error fails the whole batch.
+type BatchBalanceFetcher interface {
+	BalanceFetcher
+	GetBalances(ctx context.Context, pubkeys []sol.PublicKey, commitment rpc.CommitmentType) ([]BalanceResult, time.Duration, error)
+}
+
+// ParseCommitment validates a commitment level supplied by a caller.
+func ParseCommitment(s string) (rpc.CommitmentType, bool) {
+	switch cm := rpc.CommitmentType(s); cm {
+	case rpc.CommitmentProcessed, rpc.CommitmentConfirmed, rpc.CommitmentFinalized:
+		return cm, true
+	}
+	return "", false
 }
 
 type Client struct {
@@ -23,15 +63,81 @@ func NewClient(rpcURL string, commitment string) *Client {
 	if cm == "" {
 		cm = rpc.CommitmentFinalized
 	}
-	return &Client{c: rpc.New(rpcURL), commitment: cm}
+	// the default transport, with 429s surfaced as *RateLimitedError
+	httpClient := &http.Client{Transport: &rateLimitTransport{base: http.DefaultTransport}}
+	rc := rpc.NewWithCustomRPCClient(jsonrpc.NewClientWithOpts(rpcURL, &jsonrpc.RPCClientOpts{HTTPClient: httpClient}))
+	return &Client{c: rc, commitment: cm}
 }
 
-func (cl *Client) GetBalance(ctx context.Context, pubkey sol.PublicKey) (uint64, time.Duration, error) {
+// GetBalance reads pubkey's lamports via getAccountInfo rather than
+// getBalance, which reports 0 for accounts that do not exist.
+func (cl *Client) GetBalance(ctx context.Context, pubkey sol.PublicKey, commitment rpc.CommitmentType) (Balance, time.Duration, error) {
 	start := time.Now()
-	res, err := cl.c.GetBalance(ctx, pubkey, cl.commitment)
+	var res struct {
+		Context struct {
+			Slot uint64 `json:"slot"`
+		} `json:"context"`
+		Value *rawAccount `json:"value"`
+	}
+	err := cl.c.RPCCallForInto(ctx, &res, "getAccountInfo", []interface{}{pubkey, map[string]interface{}{
+		"encoding":   sol.EncodingBase64,
+		"commitment": cl.commitmentOr(commitment),
+		// lamports are all we need; skip the account data
+		"dataSlice": &rpc.DataSlice{Offset: uint64Ptr(0), Length: uint64Ptr(0)},
+	}})
 	lat := time.Since(start)
 	if err != nil {
-		return 0, lat, err
+		return Balance{}, lat, err
 	}
-	return uint64(res.Value), lat, nil
+	if res.Value == nil {
+		return Balance{Slot: res.Context.Slot}, lat, nil
+	}
+	return Balance{Lamports: res.Value.Lamports, Slot: res.Context.Slot, Exists: true}, lat, nil
+}
+
+// GetBalances looks up lamports for pubkeys via getMultipleAccounts, splitting
+// into chunks of MaxMultipleAccounts. Accounts that do not exist report 0
+// with Exists unset.
+// Each result carries the slot of the chunk it was read in.
+func (cl *Client) GetBalances(ctx context.Context, pubkeys []sol.PublicKey, commitment rpc.CommitmentType) ([]BalanceResult, time.Duration, error) {
+	start := time.Now()
+	out := make([]BalanceResult, 0, len(pubkeys))
+	for i := 0; i < len(pubkeys); i += MaxMultipleAccounts {
+		end := i + MaxMultipleAccounts
+		if end > len(pubkeys) {
+			end = len(pubkeys)
+		}
+		chunk := pubkeys[i:end]
+		res, err := cl.c.GetMultipleAccountsWithOpts(ctx, chunk, &rpc.GetMultipleAccountsOpts{
+			Encoding:   sol.EncodingBase64,
+			Commitment: cl.commitmentOr(commitment),
+			// lamports are all we need; skip the account data
+			DataSlice: &rpc.DataSlice{Offset: uint64Ptr(0), Length: uint64Ptr(0)},
+		})
+		if err != nil {
+			return nil, time.Since(start), err
+		}
+		for j := range chunk {
+			if j >= len(res.Value) {
+				out = append(out, BalanceResult{Err: errors.New("missing account in getMultipleAccounts response")})
+				continue
+			}
+			if acc := res.Value[j]; acc != nil {
+				out = append(out, BalanceResult{Lamports: acc.Lamports, Slot: res.Context.Slot, Exists: true})
+				continue
+			}
+			out = append(out, BalanceResult{Slot: res.Context.Slot})
+		}
+	}
+	return out, time.Since(start), nil
 }
+
+// commitmentOr returns cm, or the client's configured commitment when empty.
+func (cl *Client) commitmentOr(cm rpc.CommitmentType) rpc.CommitmentType {
+	if cm == "" {
+		return cl.commitment
+	}
+	return cm
+}
+
+func uint64Ptr(v uint64) *uint64 { return &v }
@@ -2,8 +2,12 @@ package solana
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
 )
 
 // We'll just exercise constructor and ensure GetBalance propagates errors when RPC URL is invalid.
@@ -13,8 +17,72 @@ func TestClient_NewAndErrorPropagation(t *testing.T) {
 	defer cancel()
 	// random pubkey (32 zero bytes is not valid, but this just ensures call executes and returns error)
 	var pk [32]byte
-	_, _, err := cl.GetBalance(ctx, pk)
+	_, _, err := cl.GetBalance(ctx, pk, "")
 	if err == nil {
 		t.Fatalf("expected error from RPC call")
 	}
 }
+
+func TestClient_GetBalancesUsesGetMultipleAccounts(t *testing.T) {
+	stub := newRPCStub(t, map[string]func([]json.RawMessage) any{
+		"getMultipleAccounts": func(params []json.RawMessage) any {
+			var keys []string
+			_ = json.Unmarshal(params[0], &keys)
+			var opts struct{ Commitment string }
+			_ = json.Unmarshal(params[1], &opts)
+			if opts.Commitment != "finalized" {
+				return &rpcStubError{Code: -32602, Message: "commitment " + opts.Commitment}
+			}
+			values := make([]any, len(keys))
+			for i := range keys {
+				if i == 1 {
+					continue // second account does not exist
+				}
+				values[i] = map[string]any{
+					"lamports": 1000 * (i + 1), "owner": "11111111111111111111111111111111",
+					"data": []string{"", "base64"}, "executable": false, "rentEpoch": 0,
+				}
+			}
+			return map[string]any{"context": map[string]any{"slot": 1}, "value": values}
+		},
+	})
+
+	cl := NewClient(stub.URL, "confirmed")
+	pks := make([]sol.PublicKey, MaxMultipleAccounts+3)
+	for i := range pks {
+		pks[i] = sol.NewWallet().PublicKey()
+	}
+	res, _, err := cl.GetBalances(context.Background(), pks, rpc.CommitmentFinalized)
+	if err != nil { t.Fatalf("GetBalances: %v", err) }
+	if n := stub.count("getMultipleAccounts"); n != 2 { t.Fatalf("rpc calls=%d want 2", n) }
+	if len(res) != len(pks) { t.Fatalf("results=%d", len(res)) }
+	if res[0].Slot != 1 || res[1].Slot != 1 { t.Fatalf("slot not carried: %+v", res[:2]) }
+	if res[0].Lamports != 1000 || res[1].Lamports != 0 || res[2].Lamports != 3000 { t.Fatalf("unexpected results: %+v", res[:3]) }
+	if !res[0].Exists || res[1].Exists || !res[2].Exists { t.Fatalf("exists not reported: %+v", res[:3]) }
+	if res[MaxMultipleAccounts].Lamports != 1000 { t.Fatalf("second chunk not reset: %+v", res[MaxMultipleAccounts]) }
+}
+
+func TestClient_GetBalanceReturnsSlot(t *testing.T) {
+	funded := sol.NewWallet().PublicKey()
+	stub := newRPCStub(t, map[string]func([]json.RawMessage) any{
+		"getAccountInfo": func(params []json.RawMessage) any {
+			var key string
+			_ = json.Unmarshal(params[0], &key)
+			if key != funded.String() {
+				return map[string]any{"context": map[string]any{"slot": 4243}, "value": nil}
+			}
+			return map[string]any{"context": map[string]any{"slot": 4242}, "value": map[string]any{
+				"lamports": 77, "owner": "11111111111111111111111111111111", "data": []string{"", "base64"}, "executable": false, "rentEpoch": 0,
+			}}
+		},
+	})
+	cl := NewClient(stub.URL, "")
+	bal, _, err := cl.GetBalance(context.Background(), funded, "")
+	if err != nil { t.Fatalf("GetBalance: %v", err) }
+	if bal.Lamports != 77 || bal.Slot != 4242 || !bal.Exists { t.Fatalf("bal=%+v", bal) }
+
+	// an address with no account is reported as missing, not as an error
+	bal, _, err = cl.GetBalance(context.Background(), sol.NewWallet().PublicKey(), "")
+	if err != nil { t.Fatalf("GetBalance missing: %v", err) }
+	if bal.Lamports != 0 || bal.Slot != 4243 || bal.Exists { t.Fatalf("missing bal=%+v", bal) }
+}
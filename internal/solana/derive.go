@@ -0,0 +1,28 @@
+package solana
+
+import (
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// MaxUserSeeds is how many seeds a caller may pass to FindProgramAddress;
+// the bump seed takes the last of the runtime's sol.MaxSeeds.
+const MaxUserSeeds = sol.MaxSeeds - 1
+
+// TokenProgram resolves a token program named by label (ProgramSPLToken or
+// ProgramSPLToken2022) or by ID. Empty means SPL Token.
+func TokenProgram(s string) (sol.PublicKey, bool) {
+	switch s {
+	case "", ProgramSPLToken, sol.TokenProgramID.String():
+		return sol.TokenProgramID, true
+	case ProgramSPLToken2022, Token2022ProgramID.String():
+		return Token2022ProgramID, true
+	}
+	return sol.PublicKey{}, false
+}
+
+// AssociatedTokenAddress derives owner's associated token account for mint
+// under tokenProgram, with its bump seed. sol.FindAssociatedTokenAddress only
+// covers SPL Token.
+func AssociatedTokenAddress(owner, mint, tokenProgram sol.PublicKey) (sol.PublicKey, uint8, error) {
+	return sol.FindProgramAddress([][]byte{owner[:], tokenProgram[:], mint[:]}, sol.SPLAssociatedTokenAccountProgramID)
+}
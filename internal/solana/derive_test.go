@@ -0,0 +1,30 @@
+package solana
+
+import (
+	"testing"
+
+	sol "github.com/gagliardetto/solana-go"
+)
+
+func TestTokenProgram(t *testing.T) {
+	for _, s := range []string{"", "spl-token", sol.TokenProgramID.String()} {
+		if pk, ok := TokenProgram(s); !ok || !pk.Equals(sol.TokenProgramID) { t.Fatalf("%q -> %s %v", s, pk, ok) }
+	}
+	for _, s := range []string{"spl-token-2022", Token2022ProgramID.String()} {
+		if pk, ok := TokenProgram(s); !ok || !pk.Equals(Token2022ProgramID) { t.Fatalf("%q -> %s %v", s, pk, ok) }
+	}
+	if _, ok := TokenProgram(sol.SystemProgramID.String()); ok { t.Fatalf("system program accepted") }
+}
+
+func TestAssociatedTokenAddress(t *testing.T) {
+	owner := sol.MustPublicKeyFromBase58("9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM")
+	mint := sol.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	want, wantBump, err := sol.FindAssociatedTokenAddress(owner, mint)
+	if err != nil { t.Fatalf("reference: %v", err) }
+	got, bump, err := AssociatedTokenAddress(owner, mint, sol.TokenProgramID)
+	if err != nil || !got.Equals(want) || bump != wantBump { t.Fatalf("got %s/%d want %s/%d err=%v", got, bump, want, wantBump, err) }
+	if got.IsOnCurve() { t.Fatalf("ATA %s is on the curve", got) }
+
+	t22, _, err := AssociatedTokenAddress(owner, mint, Token2022ProgramID)
+	if err != nil || t22.Equals(want) || t22.IsOnCurve() { t.Fatalf("token-2022 ATA=%s err=%v", t22, err) }
+}
@@ -0,0 +1,72 @@
+package solana
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// latencySamples is how many recent successful calls drive the hedge
+	// threshold.
+	latencySamples = 256
+	// hedgeMinSamples avoids hedging on a threshold computed from too few
+	// calls, e.g. right after startup.
+	hedgeMinSamples = 20
+	// hedgeBurst bounds how many unused hedges can be saved up.
+	hedgeBurst = 10
+)
+
+// latencyWindow is a ring buffer of recent call latencies.
+type latencyWindow struct {
+	samples [latencySamples]time.Duration
+	n       int
+	next    int
+}
+
+func (w *latencyWindow) add(d time.Duration) {
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % latencySamples
+	if w.n < latencySamples {
+		w.n++
+	}
+}
+
+// quantile returns the q-th quantile of the recorded samples.
+func (w *latencyWindow) quantile(q float64) time.Duration {
+	s := make([]time.Duration, w.n)
+	copy(s, w.samples[:w.n])
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	i := int(q * float64(w.n))
+	if i >= w.n {
+		i = w.n - 1
+	}
+	return s[i]
+}
+
+// hedgeDelay reports how long an attempt may run before it is hedged, and
+// whether a hedge may be sent at all. Every call earns HedgeBudget of a hedge,
+// so hedges stay within that fraction of calls.
+func (p *Pool) hedgeDelay() (time.Duration, bool) {
+	if p.opts.HedgePercentile <= 0 || p.opts.HedgePercentile >= 1 || p.opts.HedgeBudget <= 0 {
+		return 0, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.hedgeTokens = min(p.hedgeTokens+p.opts.HedgeBudget, hedgeBurst)
+	if p.latencies.n < hedgeMinSamples || p.hedgeTokens < 1 {
+		return 0, false
+	}
+	return p.latencies.quantile(p.opts.HedgePercentile), true
+}
+
+// takeHedge spends one hedge from the budget, reporting false when none is
+// left because other calls hedged in the meantime.
+func (p *Pool) takeHedge() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.hedgeTokens < 1 {
+		return false
+	}
+	p.hedgeTokens--
+	return true
+}
@@ -0,0 +1,172 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	// signaturesPageSize is the getSignaturesForAddress maximum.
+	signaturesPageSize = 1000
+	// maxHistoryPages bounds how far back a historical lookup walks.
+	maxHistoryPages = 20
+)
+
+// ErrHistoryTooDeep is returned when the target lies further back than
+// maxHistoryPages of signatures.
+var ErrHistoryTooDeep = errors.New("target is beyond the searchable transaction history")
+
+// HistoryPoint selects a point in the past: Slot when non-zero, else Time.
+type HistoryPoint struct {
+	Slot uint64
+	Time time.Time
+}
+
+func (p HistoryPoint) covers(s *rpc.TransactionSignature) bool {
+	if p.Slot != 0 {
+		return s.Slot <= p.Slot
+	}
+	return s.BlockTime != nil && !s.BlockTime.Time().After(p.Time)
+}
+
+// HistoricalBalance is a wallet's balance after the last transaction at or
+// before a HistoryPoint.
+type HistoricalBalance struct {
+	Lamports  uint64
+	Slot      uint64 // slot of the transaction the balance was read from
+	Signature string
+	BlockTime *time.Time
+	// Found is false when the wallet had no transactions by the target.
+	Found bool
+	// Final is true when history was read at finalized commitment and a
+	// later transaction exists, proving the target is settled; such results
+	// never change.
+	Final bool
+}
+
+// HistoryFetcher reconstructs past balances from transaction history.
+type HistoryFetcher interface {
+	GetBalanceAt(ctx context.Context, wallet sol.PublicKey, at HistoryPoint) (HistoricalBalance, time.Duration, error)
+}
+
+// txBalances is the subset of a json-encoded getTransaction result needed to
+// read SOL balance changes.
+type txBalances struct {
+	Slot        uint64 `json:"slot"`
+	BlockTime   *int64 `json:"blockTime"`
+	Transaction struct {
+		Message struct {
+			AccountKeys []string `json:"accountKeys"`
+		} `json:"message"`
+	} `json:"transaction"`
+	Meta *struct {
+		Err             any      `json:"err"`
+		Fee             uint64   `json:"fee"`
+		PreBalances     []uint64 `json:"preBalances"`
+		PostBalances    []uint64 `json:"postBalances"`
+		LoadedAddresses struct {
+			Writable []string `json:"writable"`
+			Readonly []string `json:"readonly"`
+		} `json:"loadedAddresses"`
+	} `json:"meta"`
+}
+
+// accountIndex returns the balance index of account: static keys first, then
+// addresses loaded from lookup tables (writable, then readonly).
+func (t *txBalances) accountIndex(account string) int {
+	keys := t.Transaction.Message.AccountKeys
+	if t.Meta != nil {
+		keys = append(append(keys[:len(keys):len(keys)], t.Meta.LoadedAddresses.Writable...), t.Meta.LoadedAddresses.Readonly...)
+	}
+	for i, k := range keys {
+		if k == account {
+			return i
+		}
+	}
+	return -1
+}
+
+// getTransactionBalances fetches sig with json encoding for its balance metadata.
+func (cl *Client) getTransactionBalances(ctx context.Context, sig sol.Signature) (*txBalances, error) {
+	var out *txBalances
+	err := cl.c.RPCCallForInto(ctx, &out, "getTransaction", []interface{}{sig, map[string]interface{}{
+		"encoding":                       sol.EncodingJSON,
+		"commitment":                     cl.historyCommitment(),
+		"maxSupportedTransactionVersion": 0,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	if out == nil || out.Meta == nil {
+		return nil, fmt.Errorf("transaction %s not found", sig)
+	}
+	return out, nil
+}
+
+// historyCommitment maps the client commitment onto what history methods
+// accept; "processed" is not supported there.
+func (cl *Client) historyCommitment() rpc.CommitmentType {
+	if cl.commitment == rpc.CommitmentProcessed {
+		return rpc.CommitmentConfirmed
+	}
+	return cl.commitment
+}
+
+// GetBalanceAt walks the wallet's signatures newest-first until the first one
+// at or before at, then reads the wallet's post-balance from that transaction.
+func (cl *Client) GetBalanceAt(ctx context.Context, wallet sol.PublicKey, at HistoryPoint) (HistoricalBalance, time.Duration, error) {
+	start := time.Now()
+	limit := signaturesPageSize
+	var before sol.Signature
+	cm := cl.historyCommitment()
+	// below finalized, even the newer transaction could still roll back
+	finalized := cm == rpc.CommitmentFinalized
+	newer := false
+	for page := 0; page < maxHistoryPages; page++ {
+		sigs, err := cl.c.GetSignaturesForAddressWithOpts(ctx, wallet, &rpc.GetSignaturesForAddressOpts{
+			Limit:      &limit,
+			Before:     before,
+			Commitment: cm,
+		})
+		if err != nil {
+			return HistoricalBalance{}, time.Since(start), err
+		}
+		for _, s := range sigs {
+			if !at.covers(s) {
+				newer = true
+				continue
+			}
+			tx, err := cl.getTransactionBalances(ctx, s.Signature)
+			if err != nil {
+				return HistoricalBalance{}, time.Since(start), err
+			}
+			idx := tx.accountIndex(wallet.String())
+			if idx < 0 || idx >= len(tx.Meta.PostBalances) {
+				return HistoricalBalance{}, time.Since(start), fmt.Errorf("wallet not found in transaction %s", s.Signature)
+			}
+			hb := HistoricalBalance{
+				Lamports:  tx.Meta.PostBalances[idx],
+				Slot:      s.Slot,
+				Signature: s.Signature.String(),
+				Found:     true,
+				Final:     newer && finalized,
+			}
+			if s.BlockTime != nil {
+				bt := s.BlockTime.Time().UTC()
+				hb.BlockTime = &bt
+			}
+			return hb, time.Since(start), nil
+		}
+		if len(sigs) < limit {
+			// history exhausted: the wallet had no transactions by the target
+			return HistoricalBalance{Final: newer && finalized}, time.Since(start), nil
+		}
+		before = sigs[len(sigs)-1].Signature
+	}
+	return HistoricalBalance{}, time.Since(start), ErrHistoryTooDeep
+}
@@ -0,0 +1,87 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+)
+
+func sigFixture(slot uint64, blockTime int64) (string, map[string]any) {
+	var s sol.Signature
+	s[0], s[1] = byte(slot), byte(slot>>8)
+	return s.String(), map[string]any{"signature": s.String(), "slot": slot, "blockTime": blockTime, "err": nil, "memo": nil, "confirmationStatus": "finalized"}
+}
+
+func txFixture(slot uint64, keys []string, post []uint64) map[string]any {
+	pre := make([]uint64, len(post))
+	return map[string]any{
+		"slot": slot, "blockTime": 1700000000 + int64(slot),
+		"transaction": map[string]any{"signatures": []string{"x"}, "message": map[string]any{"accountKeys": keys}},
+		"meta": map[string]any{"err": nil, "fee": 5000, "preBalances": pre, "postBalances": post,
+			"loadedAddresses": map[string]any{"writable": []string{}, "readonly": []string{}}},
+	}
+}
+
+func historyStub(t *testing.T, wallet string) *rpcStub {
+	sig300, s300 := sigFixture(300, 1_700_000_300)
+	sig200, s200 := sigFixture(200, 1_700_000_200)
+	sig100, s100 := sigFixture(100, 1_700_000_100)
+	txs := map[string]map[string]any{
+		sig300: txFixture(300, []string{"payer", wallet}, []uint64{1, 3_000}),
+		sig200: txFixture(200, []string{wallet}, []uint64{2_000}),
+		sig100: txFixture(100, []string{"payer", "other", wallet}, []uint64{1, 1, 1_000}),
+	}
+	return newRPCStub(t, map[string]func([]json.RawMessage) any{
+		"getSignaturesForAddress": func([]json.RawMessage) any { return []any{s300, s200, s100} },
+		"getTransaction": func(params []json.RawMessage) any {
+			var sig string
+			_ = json.Unmarshal(params[0], &sig)
+			return txs[sig]
+		},
+	})
+}
+
+func TestClient_GetBalanceAt(t *testing.T) {
+	wallet := sol.NewWallet().PublicKey()
+	cl := NewClient(historyStub(t, wallet.String()).URL, "finalized")
+	ctx := context.Background()
+
+	hb, _, err := cl.GetBalanceAt(ctx, wallet, HistoryPoint{Slot: 250})
+	if err != nil { t.Fatalf("by slot: %v", err) }
+	if !hb.Found || !hb.Final || hb.Lamports != 2_000 || hb.Slot != 200 { t.Fatalf("by slot=%+v", hb) }
+
+	hb, _, err = cl.GetBalanceAt(ctx, wallet, HistoryPoint{Time: time.Unix(1_700_000_150, 0)})
+	if err != nil { t.Fatalf("by time: %v", err) }
+	if hb.Lamports != 1_000 || hb.Slot != 100 || hb.BlockTime == nil { t.Fatalf("by time=%+v", hb) }
+
+	// target after the newest transaction: value may still change
+	hb, _, _ = cl.GetBalanceAt(ctx, wallet, HistoryPoint{Slot: 10_000})
+	if hb.Lamports != 3_000 || hb.Final { t.Fatalf("tip=%+v", hb) }
+
+	// target before the first transaction: nothing found, settled
+	hb, _, _ = cl.GetBalanceAt(ctx, wallet, HistoryPoint{Slot: 50})
+	if hb.Found || hb.Lamports != 0 || !hb.Final { t.Fatalf("pre-history=%+v", hb) }
+
+	// below finalized commitment the newer transaction may still roll back
+	confirmed := NewClient(historyStub(t, wallet.String()).URL, "confirmed")
+	hb, _, err = confirmed.GetBalanceAt(ctx, wallet, HistoryPoint{Slot: 250})
+	if err != nil || !hb.Found || hb.Final { t.Fatalf("confirmed=%+v err=%v", hb, err) }
+}
+
+func TestClient_GetBalanceAtTooDeep(t *testing.T) {
+	full := make([]any, signaturesPageSize)
+	for i := range full {
+		_, full[i] = sigFixture(uint64(1_000_000-i), 0)
+	}
+	stub := newRPCStub(t, map[string]func([]json.RawMessage) any{
+		"getSignaturesForAddress": func([]json.RawMessage) any { return full },
+	})
+	cl := NewClient(stub.URL, "finalized")
+	_, _, err := cl.GetBalanceAt(context.Background(), sol.NewWallet().PublicKey(), HistoryPoint{Slot: 1})
+	if !errors.Is(err, ErrHistoryTooDeep) { t.Fatalf("err=%v", err) }
+	if n := stub.count("getSignaturesForAddress"); n != maxHistoryPages { t.Fatalf("pages=%d", n) }
+}
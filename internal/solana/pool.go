@@ -0,0 +1,292 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Endpoint is one upstream RPC node in a Pool.
+type Endpoint struct {
+	// Name identifies the endpoint in logs; defaults to the URL host so that
+	// API keys in the path or query are never logged.
+	Name string
+	URL  string
+	// Priority orders tiers, lowest first: a tier only takes traffic when
+	// every endpoint in the tiers before it is ejected or has failed.
+	Priority int
+	// Weight splits traffic between endpoints of the same priority.
+	Weight int
+}
+
+// PoolOptions tunes passive health scoring. Zero values take the defaults.
+type PoolOptions struct {
+	// FailThreshold consecutive failures eject an endpoint for Cooldown.
+	FailThreshold int
+	Cooldown      time.Duration
+	// HedgePercentile, in (0, 1), enables hedging: an attempt still running
+	// after this percentile of recent call latency gets a parallel attempt
+	// on another endpoint. Zero disables hedging.
+	HedgePercentile float64
+	// HedgeBudget caps hedges to this fraction of calls, e.g. 0.1 for at
+	// most one extra upstream request per ten.
+	HedgeBudget float64
+}
+
+const (
+	defaultFailThreshold = 3
+	defaultCooldown      = 15 * time.Second
+	// healthAlpha is the EWMA smoothing factor for error rate and latency.
+	healthAlpha = 0.2
+	// latencyRef is the average latency at which an endpoint's weight halves.
+	latencyRef = 250 * time.Millisecond
+	// minHealthFactor keeps a failing endpoint's share above zero so that
+	// its score can recover.
+	minHealthFactor = 0.05
+)
+
+// EndpointHealth is a snapshot of one endpoint's passive health score.
+type EndpointHealth struct {
+	Name      string        `json:"name"`
+	Priority  int           `json:"priority"`
+	Weight    int           `json:"weight"`
+	ErrorRate float64       `json:"error_rate"`
+	Latency   time.Duration `json:"latency_ns"`
+	Ejected   bool          `json:"ejected"`
+}
+
+type poolMember struct {
+	ep     Endpoint
+	client *Client
+
+	// guarded by Pool.mu
+	errRate      float64
+	latency      float64 // EWMA of successful call latency, in ns
+	consecFails  int
+	ejectedUntil time.Time
+}
+
+// Pool is a BatchBalanceFetcher spread over several RPC endpoints. Each call
+// goes to a healthy endpoint chosen by priority and health-adjusted weight,
+// and fails over to the next candidate on error. Health is scored passively
+// from the error rate and latency of the calls the pool already makes.
+type Pool struct {
+	members []*poolMember
+	opts    PoolOptions
+
+	mu          sync.Mutex
+	latencies   latencyWindow
+	hedgeTokens float64
+}
+
+// NewPool builds a client per endpoint with the given default commitment.
+func NewPool(endpoints []Endpoint, commitment string, opts PoolOptions) *Pool {
+	if opts.FailThreshold <= 0 {
+		opts.FailThreshold = defaultFailThreshold
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = defaultCooldown
+	}
+	p := &Pool{opts: opts}
+	for i, ep := range endpoints {
+		if ep.Weight <= 0 {
+			ep.Weight = 1
+		}
+		if ep.Name == "" {
+			ep.Name = endpointName(ep.URL, i)
+		}
+		p.members = append(p.members, &poolMember{ep: ep, client: NewClient(ep.URL, commitment)})
+	}
+	return p
+}
+
+func endpointName(rawURL string, i int) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return fmt.Sprintf("endpoint-%d", i)
+}
+
+func (p *Pool) GetBalance(ctx context.Context, pubkey sol.PublicKey, commitment rpc.CommitmentType) (Balance, time.Duration, error) {
+	return poolDo(ctx, p, func(ctx context.Context, c *Client) (Balance, time.Duration, error) {
+		return c.GetBalance(ctx, pubkey, commitment)
+	})
+}
+
+func (p *Pool) GetBalances(ctx context.Context, pubkeys []sol.PublicKey, commitment rpc.CommitmentType) ([]BalanceResult, time.Duration, error) {
+	return poolDo(ctx, p, func(ctx context.Context, c *Client) ([]BalanceResult, time.Duration, error) {
+		return c.GetBalances(ctx, pubkeys, commitment)
+	})
+}
+
+// Health reports the current score of every endpoint, in configuration order.
+func (p *Pool) Health() []EndpointHealth {
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]EndpointHealth, 0, len(p.members))
+	for _, m := range p.members {
+		out = append(out, EndpointHealth{
+			Name:      m.ep.Name,
+			Priority:  m.ep.Priority,
+			Weight:    m.ep.Weight,
+			ErrorRate: m.errRate,
+			Latency:   time.Duration(m.latency),
+			Ejected:   now.Before(m.ejectedUntil),
+		})
+	}
+	return out
+}
+
+type attempt[T any] struct {
+	m       *poolMember
+	val     T
+	latency time.Duration
+	err     error
+}
+
+// poolDo runs call against candidates in order until one succeeds, failing
+// over on error. When hedging is enabled and the attempt in flight outlives
+// the adaptive threshold, one extra attempt goes to the next candidate; the
+// first success wins and the other is cancelled. The returned latency spans
+// all attempts.
+func poolDo[T any](ctx context.Context, p *Pool, call func(context.Context, *Client) (T, time.Duration, error)) (T, time.Duration, error) {
+	start := time.Now()
+	var zero T
+	cands := p.order()
+	if len(cands) == 0 {
+		return zero, 0, errors.New("rpc pool has no endpoints")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	// cancels the losing attempt, if any
+	defer cancel()
+
+	results := make(chan attempt[T], len(cands))
+	next := 0
+	launch := func() {
+		m := cands[next]
+		next++
+		go func() {
+			val, lat, err := call(ctx, m.client)
+			results <- attempt[T]{m: m, val: val, latency: lat, err: err}
+		}()
+	}
+	launch()
+	inflight := 1
+
+	var hedgeC <-chan time.Time
+	if delay, ok := p.hedgeDelay(); ok && len(cands) > 1 {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		hedgeC = t.C
+	}
+
+	var lastErr error
+	for inflight > 0 {
+		select {
+		case <-hedgeC:
+			hedgeC = nil
+			if next < len(cands) && p.takeHedge() {
+				log.Printf("event=rpc_hedge endpoint=%s elapsed_ms=%d", cands[next].ep.Name, time.Since(start).Milliseconds())
+				launch()
+				inflight++
+			}
+		case r := <-results:
+			inflight--
+			if r.err == nil {
+				p.observe(r.m, r.latency, nil)
+				return r.val, time.Since(start), nil
+			}
+			if ctx.Err() != nil {
+				// the caller gave up; not the endpoint's fault
+				return zero, time.Since(start), r.err
+			}
+			p.observe(r.m, r.latency, r.err)
+			log.Printf("event=rpc_endpoint_error endpoint=%s err=%q", r.m.ep.Name, r.err)
+			lastErr = r.err
+			if inflight == 0 && next < len(cands) {
+				launch()
+				inflight++
+			}
+		}
+	}
+	return zero, time.Since(start), lastErr
+}
+
+// order returns every member in the sequence to try: endpoints in service
+// before ejected ones (kept as a last resort), then by priority, and within a
+// tier a weighted random shuffle using health-adjusted weights.
+func (p *Pool) order() []*poolMember {
+	type candidate struct {
+		m       *poolMember
+		ejected bool
+		key     float64
+	}
+	now := time.Now()
+	p.mu.Lock()
+	cands := make([]candidate, len(p.members))
+	for i, m := range p.members {
+		// Efraimidis-Spirakis: sorting by u^(1/w) draws without replacement
+		// proportionally to w
+		cands[i] = candidate{m: m, ejected: now.Before(m.ejectedUntil), key: math.Pow(rand.Float64(), 1/m.weight())}
+	}
+	p.mu.Unlock()
+	sort.Slice(cands, func(i, j int) bool {
+		a, b := cands[i], cands[j]
+		if a.ejected != b.ejected {
+			return !a.ejected
+		}
+		if a.m.ep.Priority != b.m.ep.Priority {
+			return a.m.ep.Priority < b.m.ep.Priority
+		}
+		return a.key > b.key
+	})
+	out := make([]*poolMember, len(cands))
+	for i, c := range cands {
+		out[i] = c.m
+	}
+	return out
+}
+
+// weight is the configured weight scaled down by error rate and latency.
+// Caller holds Pool.mu.
+func (m *poolMember) weight() float64 {
+	health := math.Max(1-m.errRate, minHealthFactor)
+	speed := float64(latencyRef) / (float64(latencyRef) + m.latency)
+	return float64(m.ep.Weight) * health * speed
+}
+
+// observe folds one call outcome into m's score. Latency is only sampled on
+// success, since failures are often timeouts.
+func (p *Pool) observe(m *poolMember, latency time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil {
+		p.latencies.add(latency)
+		m.errRate -= healthAlpha * m.errRate
+		if m.latency == 0 {
+			m.latency = float64(latency)
+		} else {
+			m.latency += healthAlpha * (float64(latency) - m.latency)
+		}
+		m.consecFails = 0
+		m.ejectedUntil = time.Time{}
+		return
+	}
+	m.errRate += healthAlpha * (1 - m.errRate)
+	m.consecFails++
+	if m.consecFails >= p.opts.FailThreshold {
+		m.ejectedUntil = time.Now().Add(p.opts.Cooldown)
+		log.Printf("event=rpc_endpoint_ejected endpoint=%s failures=%d cooldown_ms=%d", m.ep.Name, m.consecFails, p.opts.Cooldown.Milliseconds())
+	}
+}
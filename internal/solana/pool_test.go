@@ -0,0 +1,145 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+)
+
+func balanceStub(t *testing.T, lamports uint64) *rpcStub {
+	return newRPCStub(t, map[string]func([]json.RawMessage) any{
+		"getAccountInfo": func([]json.RawMessage) any {
+			return map[string]any{"context": map[string]any{"slot": 10}, "value": map[string]any{
+				"lamports": lamports, "owner": "11111111111111111111111111111111", "data": []string{"", "base64"}, "executable": false, "rentEpoch": 0,
+			}}
+		},
+	})
+}
+
+// downStub answers every request with HTTP 503 and counts hits.
+func downStub(t *testing.T, hits *int32) *httptest.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestPool_FailsOverAndEjects(t *testing.T) {
+	var downHits int32
+	down := downStub(t, &downHits)
+	backup := balanceStub(t, 42)
+	p := NewPool([]Endpoint{{URL: down.URL, Priority: 0}, {URL: backup.URL, Priority: 1}}, "finalized", PoolOptions{FailThreshold: 2, Cooldown: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		bal, _, err := p.GetBalance(context.Background(), sol.PublicKey{}, "")
+		if err != nil || bal.Lamports != 42 { t.Fatalf("call %d: bal=%+v err=%v", i, bal, err) }
+	}
+	// after FailThreshold failures the primary is skipped
+	if n := atomic.LoadInt32(&downHits); n != 2 { t.Fatalf("down endpoint hit %d times, want 2", n) }
+	h := p.Health()
+	if !h[0].Ejected || h[0].ErrorRate == 0 || h[1].Ejected || h[1].Latency == 0 { t.Fatalf("health=%+v", h) }
+	if h[0].Name == "" || h[0].Name == down.URL { t.Fatalf("name should be the host, got %q", h[0].Name) }
+}
+
+func TestPool_PrefersLowerPriority(t *testing.T) {
+	primary, secondary := balanceStub(t, 1), balanceStub(t, 2)
+	p := NewPool([]Endpoint{{URL: secondary.URL, Priority: 1, Weight: 100}, {URL: primary.URL, Priority: 0}}, "", PoolOptions{})
+	for i := 0; i < 10; i++ {
+		if bal, _, _ := p.GetBalance(context.Background(), sol.PublicKey{}, ""); bal.Lamports != 1 { t.Fatalf("served by priority 1: %+v", bal) }
+	}
+	if secondary.count("getAccountInfo") != 0 { t.Fatalf("secondary used while primary healthy") }
+}
+
+func TestPool_SplitsByWeight(t *testing.T) {
+	heavy, light := balanceStub(t, 1), balanceStub(t, 2)
+	p := NewPool([]Endpoint{{URL: heavy.URL, Weight: 3}, {URL: light.URL, Weight: 1}}, "", PoolOptions{})
+	for i := 0; i < 400; i++ {
+		if _, _, err := p.GetBalance(context.Background(), sol.PublicKey{}, ""); err != nil { t.Fatalf("GetBalance: %v", err) }
+	}
+	// latency scoring shifts the split a little; the order of magnitude holds
+	share := float64(heavy.count("getAccountInfo")) / 400
+	if share < 0.55 || share > 0.92 { t.Fatalf("heavy share=%.2f want ~0.75", share) }
+}
+
+func TestPool_AllDownReturnsLastError(t *testing.T) {
+	var hits int32
+	a, b := downStub(t, &hits), downStub(t, &hits)
+	p := NewPool([]Endpoint{{URL: a.URL}, {URL: b.URL}}, "", PoolOptions{})
+	if _, _, err := p.GetBalances(context.Background(), []sol.PublicKey{{}}, ""); err == nil { t.Fatalf("expected error") }
+	if hits != 2 { t.Fatalf("hits=%d want one per endpoint", hits) }
+}
+
+func TestPool_CallerCancelDoesNotPenalize(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+	p := NewPool([]Endpoint{{URL: slow.URL}}, "", PoolOptions{FailThreshold: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := p.GetBalance(ctx, sol.PublicKey{}, ""); err == nil { t.Fatalf("expected timeout") }
+	if h := p.Health(); h[0].Ejected || h[0].ErrorRate != 0 { t.Fatalf("health=%+v", h) }
+}
+
+func TestPool_HedgesSlowCallAndCancelsLoser(t *testing.T) {
+	var slowMode atomic.Bool
+	loserCancelled := make(chan struct{}, 1)
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ ID json.RawMessage }
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_, _ = io.Copy(io.Discard, r.Body)
+		if slowMode.Load() {
+			select {
+			case <-r.Context().Done():
+				loserCancelled <- struct{}{}
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": map[string]any{"context": map[string]any{"slot": 1}, "value": map[string]any{"lamports": 1, "owner": "11111111111111111111111111111111", "data": []string{"", "base64"}}}})
+	}))
+	defer primary.Close()
+	backup := balanceStub(t, 2)
+	p := NewPool([]Endpoint{{URL: primary.URL}, {URL: backup.URL, Priority: 1}}, "", PoolOptions{HedgePercentile: 0.9, HedgeBudget: 1})
+
+	for i := 0; i < hedgeMinSamples; i++ {
+		if _, _, err := p.GetBalance(context.Background(), sol.PublicKey{}, ""); err != nil { t.Fatalf("warmup: %v", err) }
+	}
+	if backup.count("getAccountInfo") != 0 { t.Fatalf("hedged before threshold was known") }
+
+	slowMode.Store(true)
+	start := time.Now()
+	bal, _, err := p.GetBalance(context.Background(), sol.PublicKey{}, "")
+	if err != nil || bal.Lamports != 2 { t.Fatalf("bal=%+v err=%v", bal, err) }
+	if el := time.Since(start); el > time.Second { t.Fatalf("hedge did not cut latency: %v", el) }
+	select {
+	case <-loserCancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("slow attempt was not cancelled")
+	}
+}
+
+func TestPool_HedgeBudget(t *testing.T) {
+	p := NewPool(nil, "", PoolOptions{HedgePercentile: 0.5, HedgeBudget: 0.25})
+	for i := 0; i < hedgeMinSamples; i++ { p.latencies.add(time.Duration(i) * time.Millisecond) }
+	hedges := 0
+	for i := 0; i < 100; i++ {
+		if d, ok := p.hedgeDelay(); ok && p.takeHedge() {
+			if d != 10*time.Millisecond { t.Fatalf("threshold=%v", d) }
+			hedges++
+		}
+	}
+	if hedges != 25 { t.Fatalf("hedges=%d want 25", hedges) }
+	if _, ok := NewPool(nil, "", PoolOptions{}).hedgeDelay(); ok { t.Fatalf("hedging should be off by default") }
+}
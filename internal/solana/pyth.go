@@ -0,0 +1,81 @@
+package solana
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Byte offsets into a Pyth v2 price account.
+const (
+	pythMagic           = 0xa1b2c3d4
+	pythAccountPrice    = 3
+	pythOffsetType      = 8
+	pythOffsetExponent  = 20
+	pythOffsetTimestamp = 96
+	pythOffsetAggPrice  = 208
+	pythOffsetAggConf   = 216
+	pythOffsetAggStatus = 224
+	pythPriceSize       = 240
+	pythStatusTrading   = 1
+)
+
+// ErrPriceNotTrading is returned for a Pyth feed whose aggregate price is
+// not currently valid, e.g. while the market is halted.
+var ErrPriceNotTrading = errors.New("pyth price is not trading")
+
+// PythPrice is the aggregate price of a Pyth feed.
+type PythPrice struct {
+	Price       float64
+	Confidence  float64
+	PublishedAt time.Time
+}
+
+// PythPriceFetcher abstracts reading a Pyth price account.
+type PythPriceFetcher interface {
+	GetPythPrice(ctx context.Context, account sol.PublicKey) (price PythPrice, latency time.Duration, err error)
+}
+
+// GetPythPrice reads and decodes a Pyth v2 price account.
+func (cl *Client) GetPythPrice(ctx context.Context, account sol.PublicKey) (PythPrice, time.Duration, error) {
+	start := time.Now()
+	res, err := cl.c.GetAccountInfoWithOpts(ctx, account, &rpc.GetAccountInfoOpts{
+		Encoding:   sol.EncodingBase64,
+		Commitment: cl.commitment,
+		DataSlice:  &rpc.DataSlice{Offset: uint64Ptr(0), Length: uint64Ptr(pythPriceSize)},
+	})
+	lat := time.Since(start)
+	if err != nil {
+		return PythPrice{}, lat, err
+	}
+	if res == nil || res.Value == nil {
+		return PythPrice{}, lat, fmt.Errorf("pyth price account %s not found", account)
+	}
+	p, err := decodePythPrice(res.Value.Data.GetBinary())
+	return p, lat, err
+}
+
+func decodePythPrice(data []byte) (PythPrice, error) {
+	if len(data) < pythPriceSize {
+		return PythPrice{}, fmt.Errorf("pyth price account too short: %d bytes", len(data))
+	}
+	le := binary.LittleEndian
+	if le.Uint32(data) != pythMagic || le.Uint32(data[pythOffsetType:]) != pythAccountPrice {
+		return PythPrice{}, errors.New("not a pyth price account")
+	}
+	if le.Uint32(data[pythOffsetAggStatus:]) != pythStatusTrading {
+		return PythPrice{}, ErrPriceNotTrading
+	}
+	scale := math.Pow10(int(int32(le.Uint32(data[pythOffsetExponent:]))))
+	return PythPrice{
+		Price:       float64(int64(le.Uint64(data[pythOffsetAggPrice:]))) * scale,
+		Confidence:  float64(le.Uint64(data[pythOffsetAggConf:])) * scale,
+		PublishedAt: time.Unix(int64(le.Uint64(data[pythOffsetTimestamp:])), 0).UTC(),
+	}, nil
+}
@@ -0,0 +1,60 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// pythData builds a Pyth v2 price account with the given aggregate.
+func pythData(price int64, conf uint64, expo int32, status uint32, ts int64) []byte {
+	b := make([]byte, pythPriceSize)
+	le := binary.LittleEndian
+	le.PutUint32(b[0:], pythMagic)
+	le.PutUint32(b[4:], 2)
+	le.PutUint32(b[pythOffsetType:], pythAccountPrice)
+	le.PutUint32(b[pythOffsetExponent:], uint32(expo))
+	le.PutUint64(b[pythOffsetTimestamp:], uint64(ts))
+	le.PutUint64(b[pythOffsetAggPrice:], uint64(price))
+	le.PutUint64(b[pythOffsetAggConf:], conf)
+	le.PutUint32(b[pythOffsetAggStatus:], status)
+	return b
+}
+
+func TestDecodePythPrice(t *testing.T) {
+	p, err := decodePythPrice(pythData(14_523_000_000, 7_000_000, -8, pythStatusTrading, 1_700_000_000))
+	if err != nil { t.Fatalf("decode: %v", err) }
+	if math.Abs(p.Price-145.23) > 1e-9 || math.Abs(p.Confidence-0.07) > 1e-9 { t.Fatalf("price=%v conf=%v", p.Price, p.Confidence) }
+	if !p.PublishedAt.Equal(time.Unix(1_700_000_000, 0)) { t.Fatalf("published=%v", p.PublishedAt) }
+
+	if _, err := decodePythPrice(pythData(1, 1, -8, 0, 1)); !errors.Is(err, ErrPriceNotTrading) { t.Fatalf("halted err=%v", err) }
+	bad := pythData(1, 1, -8, pythStatusTrading, 1)
+	binary.LittleEndian.PutUint32(bad, 0)
+	if _, err := decodePythPrice(bad); err == nil { t.Fatalf("expected error for bad magic") }
+	if _, err := decodePythPrice(bad[:100]); err == nil { t.Fatalf("expected error for short data") }
+}
+
+func TestClient_GetPythPrice(t *testing.T) {
+	feed := sol.NewWallet().PublicKey()
+	stub := newRPCStub(t, map[string]func([]json.RawMessage) any{
+		"getAccountInfo": func(params []json.RawMessage) any {
+			var pk string
+			_ = json.Unmarshal(params[0], &pk)
+			if pk != feed.String() { t.Errorf("account=%s", pk) }
+			return map[string]any{"context": map[string]any{"slot": 1}, "value": map[string]any{
+				"lamports": 1, "owner": sol.SystemProgramID.String(), "executable": false, "rentEpoch": 0,
+				"data": []string{base64.StdEncoding.EncodeToString(pythData(2_000_000, 0, -5, pythStatusTrading, 1_700_000_000)), "base64"},
+			}}
+		},
+	})
+	p, _, err := NewClient(stub.URL, "confirmed").GetPythPrice(context.Background(), feed)
+	if err != nil { t.Fatalf("GetPythPrice: %v", err) }
+	if math.Abs(p.Price-20) > 1e-9 { t.Fatalf("price=%v", p.Price) }
+}
@@ -0,0 +1,212 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// rpcNodeBehind is the JSON-RPC error code a node returns while it lags the
+// cluster; another attempt (possibly on another node) usually succeeds.
+const rpcNodeBehind = -32005
+
+// RateLimitedError is returned for an HTTP 429 from upstream. RetryAfter is
+// taken from the Retry-After header, zero when absent.
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rpc rate limited (HTTP 429), retry after %s", e.RetryAfter)
+	}
+	return "rpc rate limited (HTTP 429)"
+}
+
+// rateLimitTransport surfaces 429 responses as *RateLimitedError, since the
+// JSON-RPC client drops response headers and with them Retry-After.
+type rateLimitTransport struct {
+	base http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return nil, &RateLimitedError{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+}
+
+// parseRetryAfter accepts delay-seconds or an HTTP date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(v); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// Retryable reports whether err is transient: rate limiting, a 5xx, a lagging
+// node or a dropped connection. Cancellation, deadlines and anything the node
+// rejected on its merits are permanent.
+func Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	var rl *RateLimitedError
+	if errors.As(err, &rl) {
+		return true
+	}
+	var he *jsonrpc.HTTPError
+	if errors.As(err, &he) {
+		return he.Code == http.StatusTooManyRequests || he.Code >= 500
+	}
+	var re *jsonrpc.RPCError
+	if errors.As(err, &re) {
+		return re.Code == rpcNodeBehind
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// RetryError wraps the final error of a retried call with the number of
+// attempts made. Its message is the underlying error's.
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string { return e.Err.Error() }
+func (e *RetryError) Unwrap() error { return e.Err }
+
+// Attempts reports how many upstream attempts produced err: the count from a
+// RetryError, 0 when the circuit breaker refused the call, otherwise 1.
+func Attempts(err error) int {
+	var re *RetryError
+	if errors.As(err, &re) {
+		return re.Attempts
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return 0
+	}
+	return 1
+}
+
+// RetryOptions configures WithRetry. Zero values take the defaults.
+type RetryOptions struct {
+	MaxAttempts int
+	// BaseDelay is the backoff cap before the first retry; it doubles per
+	// attempt up to MaxDelay, and each wait is drawn uniformly below the cap.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+const (
+	defaultRetryAttempts = 3
+	defaultRetryBase     = 100 * time.Millisecond
+	defaultRetryMax      = time.Second
+)
+
+// WithRetry retries f's transient failures with jittered exponential
+// backoff, never waiting past the caller's deadline. A 429's Retry-After is
+// honoured as the minimum wait. The result is a BatchBalanceFetcher when f is.
+func WithRetry(f BalanceFetcher, opts RetryOptions) BalanceFetcher {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultRetryAttempts
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = defaultRetryBase
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = defaultRetryMax
+	}
+	rf := &retryFetcher{inner: f, opts: opts}
+	if bf, ok := f.(BatchBalanceFetcher); ok {
+		return &retryBatchFetcher{rf, bf}
+	}
+	return rf
+}
+
+type retryFetcher struct {
+	inner BalanceFetcher
+	opts  RetryOptions
+}
+
+func (f *retryFetcher) GetBalance(ctx context.Context, pubkey sol.PublicKey, commitment rpc.CommitmentType) (Balance, time.Duration, error) {
+	return withRetry(ctx, f.opts, func() (Balance, time.Duration, error) {
+		return f.inner.GetBalance(ctx, pubkey, commitment)
+	})
+}
+
+type retryBatchFetcher struct {
+	*retryFetcher
+	batch BatchBalanceFetcher
+}
+
+func (f *retryBatchFetcher) GetBalances(ctx context.Context, pubkeys []sol.PublicKey, commitment rpc.CommitmentType) ([]BalanceResult, time.Duration, error) {
+	return withRetry(ctx, f.opts, func() ([]BalanceResult, time.Duration, error) {
+		return f.batch.GetBalances(ctx, pubkeys, commitment)
+	})
+}
+
+// withRetry runs call until it succeeds, fails permanently, runs out of
+// attempts or the next wait would overrun ctx. The latency spans all attempts.
+func withRetry[T any](ctx context.Context, opts RetryOptions, call func() (T, time.Duration, error)) (T, time.Duration, error) {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		val, _, err := call()
+		if err == nil {
+			return val, time.Since(start), nil
+		}
+		if attempt >= opts.MaxAttempts || !Retryable(err) {
+			return val, time.Since(start), &RetryError{Attempts: attempt, Err: err}
+		}
+		wait := backoff(opts, attempt)
+		var rl *RateLimitedError
+		if errors.As(err, &rl) && rl.RetryAfter > wait {
+			wait = rl.RetryAfter
+		}
+		if dl, ok := ctx.Deadline(); ok && time.Until(dl) < wait {
+			return val, time.Since(start), &RetryError{Attempts: attempt, Err: err}
+		}
+		log.Printf("event=rpc_retry attempt=%d wait_ms=%d err=%q", attempt, wait.Milliseconds(), err)
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return val, time.Since(start), &RetryError{Attempts: attempt, Err: err}
+		}
+	}
+}
+
+// backoff draws the wait before retry number attempt (1-based) with full jitter.
+func backoff(opts RetryOptions, attempt int) time.Duration {
+	ceiling := opts.MaxDelay
+	if shift := attempt - 1; shift < 30 && opts.BaseDelay<<shift < ceiling {
+		ceiling = opts.BaseDelay << shift
+	}
+	return rand.N(ceiling) + 1
+}
@@ -0,0 +1,109 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+func TestRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&RateLimitedError{}, true},
+		{fmt.Errorf("rpc call getBalance(): %w", &url.Error{Op: "Post", URL: "x", Err: &RateLimitedError{RetryAfter: time.Second}}), true},
+		{jsonrpc.NewHTTPError(503, errors.New("bad gateway")), true},
+		{jsonrpc.NewHTTPError(400, errors.New("bad request")), false},
+		{&jsonrpc.RPCError{Code: -32005, Message: "Node is behind by 42 slots"}, true},
+		{&jsonrpc.RPCError{Code: -32602, Message: "Invalid param"}, false},
+		{fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{context.DeadlineExceeded, false},
+		{context.Canceled, false},
+		{ErrCircuitOpen, false},
+		{errors.New("invalid public key"), false},
+	}
+	for _, c := range cases {
+		if got := Retryable(c.err); got != c.want { t.Errorf("Retryable(%v)=%v want %v", c.err, got, c.want) }
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if d := parseRetryAfter("2", now); d != 2*time.Second { t.Fatalf("seconds=%v", d) }
+	if d := parseRetryAfter(now.Add(5*time.Second).Format(http.TimeFormat), now); d != 5*time.Second { t.Fatalf("date=%v", d) }
+	if d := parseRetryAfter("soon", now); d != 0 { t.Fatalf("garbage=%v", d) }
+}
+
+func TestClient_SurfacesRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	_, _, err := NewClient(srv.URL, "").GetBalance(context.Background(), sol.PublicKey{}, "")
+	var rl *RateLimitedError
+	if !errors.As(err, &rl) || rl.RetryAfter != 3*time.Second { t.Fatalf("err=%v", err) }
+	if !Retryable(err) { t.Fatalf("429 should be retryable") }
+}
+
+type scriptedFetcher struct {
+	errs  []error
+	calls int
+}
+
+func (f *scriptedFetcher) GetBalance(context.Context, sol.PublicKey, rpc.CommitmentType) (Balance, time.Duration, error) {
+	f.calls++
+	if f.calls <= len(f.errs) { return Balance{}, 0, f.errs[f.calls-1] }
+	return Balance{Lamports: 5}, 0, nil
+}
+
+func TestWithRetry_RetriesTransientErrors(t *testing.T) {
+	inner := &scriptedFetcher{errs: []error{jsonrpc.NewHTTPError(502, errors.New("bad gateway")), &jsonrpc.RPCError{Code: -32005}}}
+	f := WithRetry(inner, RetryOptions{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	bal, _, err := f.GetBalance(context.Background(), sol.PublicKey{}, "")
+	if err != nil || bal.Lamports != 5 || inner.calls != 3 { t.Fatalf("bal=%+v err=%v calls=%d", bal, err, inner.calls) }
+
+	inner = &scriptedFetcher{errs: []error{errors.New("a"), errors.New("b"), errors.New("c")}}
+	_, _, err = WithRetry(inner, RetryOptions{}).GetBalance(context.Background(), sol.PublicKey{}, "")
+	if Attempts(err) != 1 || inner.calls != 1 || err.Error() != "a" { t.Fatalf("permanent: err=%v attempts=%d calls=%d", err, Attempts(err), inner.calls) }
+
+	down := jsonrpc.NewHTTPError(503, errors.New("unavailable"))
+	inner = &scriptedFetcher{errs: []error{down, down, down, down}}
+	_, _, err = WithRetry(inner, RetryOptions{MaxAttempts: 3, BaseDelay: time.Millisecond}).GetBalance(context.Background(), sol.PublicKey{}, "")
+	if Attempts(err) != 3 || !errors.Is(err, down) { t.Fatalf("exhausted: err=%v attempts=%d", err, Attempts(err)) }
+}
+
+func TestWithRetry_HonoursRetryAfterWithinDeadline(t *testing.T) {
+	inner := &scriptedFetcher{errs: []error{&RateLimitedError{RetryAfter: 50 * time.Millisecond}}}
+	start := time.Now()
+	if _, _, err := WithRetry(inner, RetryOptions{BaseDelay: time.Millisecond}).GetBalance(context.Background(), sol.PublicKey{}, ""); err != nil { t.Fatalf("err=%v", err) }
+	if el := time.Since(start); el < 50*time.Millisecond { t.Fatalf("Retry-After ignored: %v", el) }
+
+	// a wait past the deadline gives up at once instead of sleeping in vain
+	inner = &scriptedFetcher{errs: []error{&RateLimitedError{RetryAfter: time.Minute}}}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start = time.Now()
+	_, _, err := WithRetry(inner, RetryOptions{}).GetBalance(ctx, sol.PublicKey{}, "")
+	if Attempts(err) != 1 || time.Since(start) > 100*time.Millisecond { t.Fatalf("err=%v attempts=%d", err, Attempts(err)) }
+}
+
+func TestBackoffBounds(t *testing.T) {
+	opts := RetryOptions{BaseDelay: 10 * time.Millisecond, MaxDelay: 25 * time.Millisecond}
+	for i := 0; i < 100; i++ {
+		if d := backoff(opts, 1); d <= 0 || d > 10*time.Millisecond { t.Fatalf("attempt 1 wait=%v", d) }
+		if d := backoff(opts, 5); d > 25*time.Millisecond { t.Fatalf("attempt 5 wait=%v", d) }
+	}
+	if !errors.Is(&RetryError{Attempts: 2, Err: ErrCircuitOpen}, ErrCircuitOpen) || Attempts(ErrCircuitOpen) != 0 || Attempts(errors.New("x")) != 1 { t.Fatalf("Attempts helpers") }
+}
@@ -0,0 +1,61 @@
+package solana
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// rpcStub is a local JSON-RPC stand-in. Each method maps to a func returning
+// the "result" payload, or an *rpcStubError to reply with a JSON-RPC error.
+type rpcStub struct {
+	*httptest.Server
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+type rpcStubError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func newRPCStub(t *testing.T, methods map[string]func(params []json.RawMessage) any) *rpcStub {
+	t.Helper()
+	s := &rpcStub{calls: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.calls[req.Method]++
+		s.mu.Unlock()
+		fn, ok := methods[req.Method]
+		if !ok {
+			t.Errorf("unexpected rpc method %s", req.Method)
+			_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "error": rpcStubError{Code: -32601, Message: "method not found"}})
+			return
+		}
+		res := fn(req.Params)
+		if e, ok := res.(*rpcStubError); ok {
+			_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "error": e})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": res})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *rpcStub) count(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
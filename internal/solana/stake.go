@@ -0,0 +1,146 @@
+package solana
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"golang.org/x/sync/errgroup"
+)
+
+// Stake activation states, as reported per stake account.
+const (
+	StakeActive       = "active"
+	StakeActivating   = "activating"
+	StakeDeactivating = "deactivating"
+	StakeInactive     = "inactive"
+)
+
+// Byte offsets into a stake account (StakeStateV2, bincode layout).
+const (
+	stakeAccountSize      = 200
+	stakeOffsetStaker     = 12  // after u32 state tag + u64 rent_exempt_reserve
+	stakeOffsetWithdrawer = 44  // staker + 32
+	stakeOffsetDelegation = 124 // after Meta (authorized + lockup)
+	stakeStateDelegated   = 2
+)
+
+// StakeAccount is a stake account where the wallet is staker or withdrawer.
+// Delegated lamports are in State; the remainder of Lamports is inactive.
+type StakeAccount struct {
+	Address   string
+	Lamports  uint64
+	Delegated uint64
+	State     string
+	Voter     string
+}
+
+// StakeFetcher abstracts listing the stake accounts controlled by a wallet.
+type StakeFetcher interface {
+	GetStakeAccounts(ctx context.Context, wallet sol.PublicKey) (accounts []StakeAccount, latency time.Duration, err error)
+}
+
+// GetStakeAccounts finds stake accounts whose staker or withdrawer authority
+// is wallet via getProgramAccounts memcmp filters, and classifies each against
+// the current epoch. Warmup/cooldown rate limiting is not modelled: a
+// delegation is reported fully activating (or deactivating) during the epoch
+// it changes and fully active (or inactive) afterwards.
+func (cl *Client) GetStakeAccounts(ctx context.Context, wallet sol.PublicKey) ([]StakeAccount, time.Duration, error) {
+	start := time.Now()
+	var byStaker, byWithdrawer rpc.GetProgramAccountsResult
+	var epoch uint64
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		byStaker, err = cl.stakeAccountsByAuthority(gctx, wallet, stakeOffsetStaker)
+		return err
+	})
+	g.Go(func() (err error) {
+		byWithdrawer, err = cl.stakeAccountsByAuthority(gctx, wallet, stakeOffsetWithdrawer)
+		return err
+	})
+	g.Go(func() error {
+		info, err := cl.c.GetEpochInfo(gctx, cl.commitment)
+		if err != nil {
+			return err
+		}
+		epoch = info.Epoch
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return nil, time.Since(start), err
+	}
+
+	seen := make(map[sol.PublicKey]struct{})
+	out := make([]StakeAccount, 0, len(byStaker)+len(byWithdrawer))
+	for _, ka := range append(byStaker, byWithdrawer...) {
+		if ka == nil || ka.Account == nil || ka.Account.Data == nil {
+			continue
+		}
+		if _, ok := seen[ka.Pubkey]; ok {
+			continue
+		}
+		seen[ka.Pubkey] = struct{}{}
+		acc, err := decodeStakeAccount(ka.Pubkey, ka.Account.Lamports, ka.Account.Data.GetBinary(), epoch)
+		if err != nil {
+			return nil, time.Since(start), err
+		}
+		out = append(out, acc)
+	}
+	return out, time.Since(start), nil
+}
+
+func (cl *Client) stakeAccountsByAuthority(ctx context.Context, wallet sol.PublicKey, offset uint64) (rpc.GetProgramAccountsResult, error) {
+	return cl.c.GetProgramAccountsWithOpts(ctx, sol.StakeProgramID, &rpc.GetProgramAccountsOpts{
+		Commitment: cl.commitment,
+		Encoding:   sol.EncodingBase64,
+		Filters: []rpc.RPCFilter{
+			{DataSize: stakeAccountSize},
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: offset, Bytes: wallet[:]}},
+		},
+	})
+}
+
+// decodeStakeAccount reads the state tag and delegation of a stake account.
+func decodeStakeAccount(address sol.PublicKey, lamports uint64, data []byte, epoch uint64) (StakeAccount, error) {
+	acc := StakeAccount{Address: address.String(), Lamports: lamports, State: StakeInactive}
+	if len(data) < 4 {
+		return acc, fmt.Errorf("stake account %s: short data (%d bytes)", address, len(data))
+	}
+	if binary.LittleEndian.Uint32(data[:4]) != stakeStateDelegated {
+		// Initialized (or anything else): nothing is delegated
+		return acc, nil
+	}
+	if len(data) < stakeOffsetDelegation+56 {
+		return acc, fmt.Errorf("stake account %s: short delegation (%d bytes)", address, len(data))
+	}
+	d := data[stakeOffsetDelegation:]
+	acc.Voter = sol.PublicKeyFromBytes(d[:32]).String()
+	acc.Delegated = binary.LittleEndian.Uint64(d[32:40])
+	activation := binary.LittleEndian.Uint64(d[40:48])
+	deactivation := binary.LittleEndian.Uint64(d[48:56])
+	acc.State = stakeState(activation, deactivation, epoch)
+	return acc, nil
+}
+
+func stakeState(activation, deactivation, epoch uint64) string {
+	if deactivation != math.MaxUint64 {
+		switch {
+		case activation == deactivation:
+			// deactivated in the epoch it was delegated; never became active
+			return StakeInactive
+		case deactivation >= epoch:
+			return StakeDeactivating
+		default:
+			return StakeInactive
+		}
+	}
+	// bootstrap stakes use activation_epoch == u64::MAX
+	if activation != math.MaxUint64 && activation >= epoch {
+		return StakeActivating
+	}
+	return StakeActive
+}
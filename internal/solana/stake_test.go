@@ -0,0 +1,111 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"testing"
+
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// stakeData builds a 200-byte stake account. state 1 = initialized, 2 = delegated.
+func stakeData(state uint32, staker, withdrawer sol.PublicKey, delegated, activation, deactivation uint64) string {
+	b := make([]byte, stakeAccountSize)
+	binary.LittleEndian.PutUint32(b[0:], state)
+	binary.LittleEndian.PutUint64(b[4:], 2_282_880)
+	copy(b[stakeOffsetStaker:], staker[:])
+	copy(b[stakeOffsetWithdrawer:], withdrawer[:])
+	d := b[stakeOffsetDelegation:]
+	copy(d, sol.NewWallet().PublicKey().Bytes())
+	binary.LittleEndian.PutUint64(d[32:], delegated)
+	binary.LittleEndian.PutUint64(d[40:], activation)
+	binary.LittleEndian.PutUint64(d[48:], deactivation)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func keyedStake(pk sol.PublicKey, lamports uint64, data string) map[string]any {
+	return map[string]any{"pubkey": pk.String(), "account": map[string]any{
+		"lamports": lamports, "owner": sol.StakeProgramID.String(), "executable": false, "rentEpoch": 0,
+		"data": []string{data, "base64"},
+	}}
+}
+
+func TestClient_GetStakeAccounts(t *testing.T) {
+	wallet := sol.NewWallet().PublicKey()
+	other := sol.NewWallet().PublicKey()
+	active := sol.NewWallet().PublicKey()
+	both := sol.NewWallet().PublicKey()
+	activating := sol.NewWallet().PublicKey()
+	deactivating := sol.NewWallet().PublicKey()
+	initialized := sol.NewWallet().PublicKey()
+	const epoch = 600
+	max := uint64(math.MaxUint64)
+
+	stub := newRPCStub(t, map[string]func([]json.RawMessage) any{
+		"getEpochInfo": func([]json.RawMessage) any {
+			return map[string]any{"absoluteSlot": 1, "blockHeight": 1, "epoch": epoch, "slotIndex": 0, "slotsInEpoch": 432000}
+		},
+		"getProgramAccounts": func(params []json.RawMessage) any {
+			var opts struct {
+				Filters []struct {
+					DataSize uint64 `json:"dataSize"`
+					Memcmp   *struct {
+						Offset uint64 `json:"offset"`
+						Bytes  string `json:"bytes"`
+					} `json:"memcmp"`
+				} `json:"filters"`
+			}
+			_ = json.Unmarshal(params[1], &opts)
+			if len(opts.Filters) != 2 || opts.Filters[0].DataSize != stakeAccountSize || opts.Filters[1].Memcmp.Bytes != wallet.String() {
+				t.Errorf("unexpected filters: %+v", opts.Filters)
+			}
+			if opts.Filters[1].Memcmp.Offset == stakeOffsetStaker {
+				return []any{
+					keyedStake(active, 5_002_282_880, stakeData(2, wallet, other, 5_000_000_000, 10, max)),
+					keyedStake(both, 1_002_282_880, stakeData(2, wallet, wallet, 1_000_000_000, 100, max)),
+					keyedStake(activating, 2_002_282_880, stakeData(2, wallet, other, 2_000_000_000, epoch, max)),
+				}
+			}
+			return []any{
+				keyedStake(both, 1_002_282_880, stakeData(2, wallet, wallet, 1_000_000_000, 100, max)),
+				keyedStake(deactivating, 3_002_282_880, stakeData(2, other, wallet, 3_000_000_000, 100, epoch)),
+				keyedStake(initialized, 2_282_880, stakeData(1, other, wallet, 0, 0, 0)),
+			}
+		},
+	})
+
+	cl := NewClient(stub.URL, "finalized")
+	accounts, _, err := cl.GetStakeAccounts(context.Background(), wallet)
+	if err != nil { t.Fatalf("GetStakeAccounts: %v", err) }
+	if len(accounts) != 5 { t.Fatalf("accounts=%d (duplicates not merged?)", len(accounts)) }
+	want := map[string]string{
+		active.String(): StakeActive, both.String(): StakeActive, activating.String(): StakeActivating,
+		deactivating.String(): StakeDeactivating, initialized.String(): StakeInactive,
+	}
+	for _, a := range accounts {
+		if a.State != want[a.Address] { t.Fatalf("%s state=%s want %s", a.Address, a.State, want[a.Address]) }
+	}
+}
+
+func TestStakeState(t *testing.T) {
+	max := uint64(math.MaxUint64)
+	cases := []struct {
+		activation, deactivation, epoch uint64
+		want                            string
+	}{
+		{max, max, 10, StakeActive},       // bootstrap stake
+		{5, max, 10, StakeActive},
+		{10, max, 10, StakeActivating},
+		{5, 10, 10, StakeDeactivating},
+		{5, 9, 10, StakeInactive},
+		{7, 7, 10, StakeInactive},
+	}
+	for _, c := range cases {
+		if got := stakeState(c.activation, c.deactivation, c.epoch); got != c.want {
+			t.Fatalf("stakeState(%d,%d,%d)=%s want %s", c.activation, c.deactivation, c.epoch, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/gorilla/websocket"
+)
+
+// SubscriberOptions tunes a Subscriber. Zero values take the defaults.
+type SubscriberOptions struct {
+	// ReconnectBase and ReconnectMax bound the jittered backoff between
+	// reconnect attempts.
+	ReconnectBase time.Duration
+	ReconnectMax  time.Duration
+	// PingInterval keeps the connection alive through idle proxies.
+	PingInterval time.Duration
+}
+
+const (
+	defaultReconnectBase = 500 * time.Millisecond
+	defaultReconnectMax  = 30 * time.Second
+	defaultPingInterval  = 30 * time.Second
+	wsWriteTimeout       = 10 * time.Second
+)
+
+// SubscriberStatus is a snapshot of a Subscriber for health reporting.
+type SubscriberStatus struct {
+	Connected     bool `json:"connected"`
+	Watched       int  `json:"watched"`
+	Subscriptions int  `json:"subscriptions"`
+	Reconnects    int  `json:"reconnects"`
+}
+
+// Subscriber holds one WebSocket connection to a Solana node and multiplexes
+// accountSubscribe over it for every watched wallet, handing each balance
+// change to OnUpdate. It reconnects with backoff and resubscribes all watched
+// wallets when the connection drops. Changes made while disconnected are not
+// replayed, so consumers should keep a bounded TTL on what they store.
+type Subscriber struct {
+	url        string
+	commitment rpc.CommitmentType
+	onUpdate   func(sol.PublicKey, rpc.CommitmentType, Balance)
+	opts       SubscriberOptions
+
+	mu         sync.Mutex
+	watched    map[sol.PublicKey]int // reference counts
+	conn       *websocket.Conn
+	nextID     uint64
+	pending    map[uint64]sol.PublicKey // subscribe request id -> wallet
+	subs       map[uint64]sol.PublicKey // subscription id -> wallet
+	subIDs     map[sol.PublicKey]uint64
+	reconnects int
+}
+
+// NewSubscriber prepares a subscriber for the node at wsURL; Run connects it.
+// An empty commitment means finalized.
+func NewSubscriber(wsURL string, commitment rpc.CommitmentType, onUpdate func(sol.PublicKey, rpc.CommitmentType, Balance), opts SubscriberOptions) *Subscriber {
+	if commitment == "" {
+		commitment = rpc.CommitmentFinalized
+	}
+	if opts.ReconnectBase <= 0 {
+		opts.ReconnectBase = defaultReconnectBase
+	}
+	if opts.ReconnectMax <= 0 {
+		opts.ReconnectMax = defaultReconnectMax
+	}
+	if opts.PingInterval <= 0 {
+		opts.PingInterval = defaultPingInterval
+	}
+	return &Subscriber{
+		url:        wsURL,
+		commitment: commitment,
+		onUpdate:   onUpdate,
+		opts:       opts,
+		watched:    make(map[sol.PublicKey]int),
+	}
+}
+
+// Watch starts receiving updates for pubkey. Calls are reference counted:
+// each Watch must be paired with an Unwatch.
+func (s *Subscriber) Watch(pubkey sol.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watched[pubkey]++
+	if s.watched[pubkey] == 1 && s.conn != nil {
+		s.subscribeLocked(pubkey)
+	}
+}
+
+// Unwatch drops one reference to pubkey, unsubscribing after the last.
+func (s *Subscriber) Unwatch(pubkey sol.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, ok := s.watched[pubkey]
+	if !ok {
+		return
+	}
+	if n > 1 {
+		s.watched[pubkey] = n - 1
+		return
+	}
+	delete(s.watched, pubkey)
+	// a subscribe still awaiting its id is cancelled when the id arrives
+	if id, ok := s.subIDs[pubkey]; ok {
+		s.unsubscribeLocked(id)
+	}
+}
+
+// Status reports the connection state.
+func (s *Subscriber) Status() SubscriberStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SubscriberStatus{Connected: s.conn != nil, Watched: len(s.watched), Subscriptions: len(s.subs), Reconnects: s.reconnects}
+}
+
+// Run keeps the connection up until ctx is done.
+func (s *Subscriber) Run(ctx context.Context) error {
+	backoffOpts := RetryOptions{BaseDelay: s.opts.ReconnectBase, MaxDelay: s.opts.ReconnectMax}
+	failures := 0
+	for {
+		connected, err := s.session(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if connected {
+			failures = 0
+		}
+		failures++
+		wait := backoff(backoffOpts, failures)
+		log.Printf("event=ws_disconnected err=%q retry_ms=%d", err, wait.Milliseconds())
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+		s.mu.Lock()
+		s.reconnects++
+		s.mu.Unlock()
+	}
+}
+
+// wsMessage is either a response to one of our requests or a notification.
+type wsMessage struct {
+	ID     *uint64           `json:"id"`
+	Result json.RawMessage   `json:"result"`
+	Error  *jsonrpc.RPCError `json:"error"`
+	Method string            `json:"method"`
+	Params *struct {
+		Result struct {
+			Context struct {
+				Slot uint64 `json:"slot"`
+			} `json:"context"`
+			Value *struct {
+				Lamports uint64 `json:"lamports"`
+			} `json:"value"`
+		} `json:"result"`
+		Subscription uint64 `json:"subscription"`
+	} `json:"params"`
+}
+
+// session dials, resubscribes every watched wallet and reads until the
+// connection fails. connected reports whether the dial succeeded.
+func (s *Subscriber) session(ctx context.Context) (connected bool, err error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, s.url, nil)
+	if err != nil {
+		return false, err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	s.mu.Lock()
+	s.conn = conn
+	s.pending = make(map[uint64]sol.PublicKey)
+	s.subs = make(map[uint64]sol.PublicKey)
+	s.subIDs = make(map[sol.PublicKey]uint64)
+	for pk := range s.watched {
+		s.subscribeLocked(pk)
+	}
+	n := len(s.watched)
+	s.mu.Unlock()
+	log.Printf("event=ws_connected subscriptions=%d", n)
+
+	done := make(chan struct{})
+	go s.ping(conn, done)
+	defer func() {
+		close(done)
+		s.mu.Lock()
+		s.conn, s.pending, s.subs, s.subIDs = nil, nil, nil, nil
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		var msg wsMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return true, err
+		}
+		s.handle(&msg)
+	}
+}
+
+func (s *Subscriber) ping(conn *websocket.Conn, done <-chan struct{}) {
+	t := time.NewTicker(s.opts.PingInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				conn.Close()
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+func (s *Subscriber) handle(msg *wsMessage) {
+	if msg.Method == "accountNotification" && msg.Params != nil {
+		s.mu.Lock()
+		pk, ok := s.subs[msg.Params.Subscription]
+		s.mu.Unlock()
+		if !ok {
+			return
+		}
+		bal := Balance{Slot: msg.Params.Result.Context.Slot}
+		if v := msg.Params.Result.Value; v != nil {
+			// a closed account is notified with no lamports left
+			bal.Lamports, bal.Exists = v.Lamports, v.Lamports > 0
+		}
+		s.onUpdate(pk, s.commitment, bal)
+		return
+	}
+	if msg.ID == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pk, ok := s.pending[*msg.ID]
+	if !ok {
+		// an unsubscribe acknowledgement
+		return
+	}
+	delete(s.pending, *msg.ID)
+	if msg.Error != nil {
+		log.Printf("event=ws_subscribe_error wallet=%s err=%q", pk, msg.Error.Message)
+		return
+	}
+	var subID uint64
+	if err := json.Unmarshal(msg.Result, &subID); err != nil {
+		log.Printf("event=ws_subscribe_error wallet=%s err=%q", pk, err)
+		return
+	}
+	if _, still := s.watched[pk]; !still {
+		s.unsubscribeLocked(subID)
+		return
+	}
+	// unwatching and rewatching while a subscribe was pending sends a second
+	// one; keep the newest subscription so one change is one update
+	if old, ok := s.subIDs[pk]; ok && old != subID {
+		s.unsubscribeLocked(old)
+	}
+	s.subs[subID] = pk
+	s.subIDs[pk] = subID
+}
+
+// subscribeLocked sends accountSubscribe for pubkey. Caller holds s.mu with
+// a live connection.
+func (s *Subscriber) subscribeLocked(pubkey sol.PublicKey) {
+	s.nextID++
+	s.pending[s.nextID] = pubkey
+	s.writeLocked(s.nextID, "accountSubscribe", []any{pubkey.String(), map[string]string{"encoding": "base64", "commitment": string(s.commitment)}})
+}
+
+// unsubscribeLocked sends accountUnsubscribe and forgets subID. Caller holds
+// s.mu with a live connection.
+func (s *Subscriber) unsubscribeLocked(subID uint64) {
+	if pk, ok := s.subs[subID]; ok {
+		delete(s.subs, subID)
+		delete(s.subIDs, pk)
+	}
+	s.nextID++
+	s.writeLocked(s.nextID, "accountUnsubscribe", []any{subID})
+}
+
+// writeLocked sends one request. A failed write closes the connection so the
+// read loop reconnects, which resubscribes from scratch.
+func (s *Subscriber) writeLocked(id uint64, method string, params []any) {
+	_ = s.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	err := s.conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	if err != nil {
+		log.Printf("event=ws_write_error method=%s err=%q", method, err)
+		s.conn.Close()
+	}
+}
@@ -0,0 +1,211 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gorilla/websocket"
+)
+
+// wsStub is a local stand-in for a node's WebSocket endpoint. It answers
+// accountSubscribe with increasing subscription ids and records every request.
+type wsStub struct {
+	*httptest.Server
+	mu     sync.Mutex
+	conn   *websocket.Conn
+	nextID uint64
+	subs   map[string]uint64 // wallet -> live subscription id
+	events chan string       // "sub <wallet>" or "unsub <id>"
+	// while hold is set, subscribe acknowledgements queue in held
+	hold bool
+	held []map[string]any
+}
+
+func newWSStub(t *testing.T) *wsStub {
+	t.Helper()
+	s := &wsStub{subs: make(map[string]uint64), events: make(chan string, 64)}
+	up := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := up.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conn = conn
+		s.subs = make(map[string]uint64)
+		s.mu.Unlock()
+		for {
+			var req struct {
+				ID     uint64            `json:"id"`
+				Method string            `json:"method"`
+				Params []json.RawMessage `json:"params"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			s.mu.Lock()
+			switch req.Method {
+			case "accountSubscribe":
+				var wallet string
+				_ = json.Unmarshal(req.Params[0], &wallet)
+				s.nextID++
+				s.subs[wallet] = s.nextID
+				ack := map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": s.nextID}
+				if s.hold {
+					s.held = append(s.held, ack)
+				} else {
+					_ = conn.WriteJSON(ack)
+				}
+				s.events <- "sub " + wallet
+			case "accountUnsubscribe":
+				_ = conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": true})
+				s.events <- "unsub " + string(req.Params[0])
+			}
+			s.mu.Unlock()
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *wsStub) url() string { return "ws" + strings.TrimPrefix(s.URL, "http") }
+
+func (s *wsStub) notify(wallet string, slot, lamports uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "method": "accountNotification", "params": map[string]any{
+		"subscription": s.subs[wallet],
+		"result":       map[string]any{"context": map[string]any{"slot": slot}, "value": map[string]any{"lamports": lamports, "owner": "11111111111111111111111111111111"}},
+	}})
+}
+
+// holdAcks delays subscribe acknowledgements until releaseAcks.
+func (s *wsStub) holdAcks() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hold = true
+}
+
+func (s *wsStub) releaseAcks() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ack := range s.held {
+		_ = s.conn.WriteJSON(ack)
+	}
+	s.hold, s.held = false, nil
+}
+
+// drop closes the live connection as a node restart would.
+func (s *wsStub) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conn.Close()
+}
+
+func (s *wsStub) expect(t *testing.T, want string) {
+	t.Helper()
+	select {
+	case got := <-s.events:
+		if got != want { t.Fatalf("event=%q want %q", got, want) }
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for %q", want)
+	}
+}
+
+type update struct {
+	pk  sol.PublicKey
+	cm  rpc.CommitmentType
+	bal Balance
+}
+
+func startSubscriber(t *testing.T, url string) (*Subscriber, chan update) {
+	t.Helper()
+	updates := make(chan update, 16)
+	s := NewSubscriber(url, rpc.CommitmentConfirmed, func(pk sol.PublicKey, cm rpc.CommitmentType, bal Balance) {
+		updates <- update{pk, cm, bal}
+	}, SubscriberOptions{ReconnectBase: 5 * time.Millisecond, ReconnectMax: 20 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { _ = s.Run(ctx); close(done) }()
+	t.Cleanup(func() { cancel(); <-done })
+	return s, updates
+}
+
+func nextUpdate(t *testing.T, updates <-chan update) update {
+	t.Helper()
+	select {
+	case u := <-updates:
+		return u
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no update")
+	}
+	return update{}
+}
+
+func TestSubscriber_MultiplexesAndUnsubscribes(t *testing.T) {
+	stub := newWSStub(t)
+	a, b := sol.NewWallet().PublicKey(), sol.NewWallet().PublicKey()
+	s, updates := startSubscriber(t, stub.url())
+	s.Watch(a)
+	s.Watch(a) // second reference shares the subscription
+	stub.expect(t, "sub "+a.String())
+	s.Watch(b)
+	stub.expect(t, "sub "+b.String())
+
+	stub.notify(b.String(), 42, 7)
+	if u := nextUpdate(t, updates); u.pk != b || u.cm != rpc.CommitmentConfirmed || u.bal != (Balance{Lamports: 7, Slot: 42, Exists: true}) { t.Fatalf("update=%+v", u) }
+
+	s.Unwatch(a)
+	if st := s.Status(); !st.Connected || st.Watched != 2 || st.Subscriptions != 2 { t.Fatalf("status=%+v", st) }
+	s.Unwatch(a)
+	stub.expect(t, "unsub 1")
+	if st := s.Status(); st.Watched != 1 || st.Subscriptions != 1 { t.Fatalf("status=%+v", st) }
+}
+
+func TestSubscriber_ResubscribesAfterDrop(t *testing.T) {
+	stub := newWSStub(t)
+	a := sol.NewWallet().PublicKey()
+	s, updates := startSubscriber(t, stub.url())
+	s.Watch(a)
+	stub.expect(t, "sub "+a.String())
+
+	stub.drop()
+	stub.expect(t, "sub "+a.String())
+	stub.notify(a.String(), 9, 1_000)
+	if u := nextUpdate(t, updates); u.pk != a || u.bal.Lamports != 1_000 { t.Fatalf("update=%+v", u) }
+	if st := s.Status(); st.Reconnects != 1 || st.Subscriptions != 1 { t.Fatalf("status=%+v", st) }
+}
+
+func TestSubscriber_RewatchWhileSubscribePendingKeepsOneSubscription(t *testing.T) {
+	stub := newWSStub(t)
+	a := sol.NewWallet().PublicKey()
+	s, _ := startSubscriber(t, stub.url())
+	deadline := time.Now().Add(2 * time.Second)
+	for !s.Status().Connected {
+		if time.Now().After(deadline) { t.Fatalf("never connected") }
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	stub.holdAcks()
+	s.Watch(a)
+	stub.expect(t, "sub "+a.String())
+	s.Unwatch(a)
+	s.Watch(a)
+	stub.expect(t, "sub "+a.String())
+	// both acks arrive while a is watched again; the older one is cancelled
+	stub.releaseAcks()
+	stub.expect(t, "unsub 1")
+	if st := s.Status(); st.Watched != 1 || st.Subscriptions != 1 { t.Fatalf("status=%+v", st) }
+
+	s.Unwatch(a)
+	stub.expect(t, "unsub 2")
+	if st := s.Status(); st.Watched != 0 || st.Subscriptions != 0 { t.Fatalf("status=%+v", st) }
+}
@@ -0,0 +1,289 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"golang.org/x/sync/errgroup"
+)
+
+// Token2022ProgramID is the SPL Token-2022 (token extensions) program.
+var Token2022ProgramID = sol.MustPublicKeyFromBase58("TokenzQdBNbLqP5VEhdkAS6EPFLC1PHnBqCXEpPxuEb")
+
+// Program labels reported for token accounts, matching the RPC's jsonParsed names.
+const (
+	ProgramSPLToken     = "spl-token"
+	ProgramSPLToken2022 = "spl-token-2022"
+)
+
+// TokenAccount is an SPL token account held by an owner.
+type TokenAccount struct {
+	Address        string
+	Mint           string
+	Program        string // ProgramSPLToken or ProgramSPLToken2022
+	Amount         string // raw base-unit amount; u64 as a decimal string
+	Decimals       uint8
+	UIAmount       float64
+	UIAmountString string
+	// Extensions is set for Token-2022 accounts and merges mint- and
+	// account-level extensions.
+	Extensions *types.TokenExtensions
+}
+
+// TokenFetcher abstracts listing the SPL token accounts of an owner.
+type TokenFetcher interface {
+	GetTokenAccounts(ctx context.Context, owner sol.PublicKey) (accounts []TokenAccount, latency time.Duration, err error)
+}
+
+// parsedTokenAccount mirrors the jsonParsed layout of a token account.
+type parsedTokenAccount struct {
+	Program string `json:"program"`
+	Parsed  struct {
+		Info struct {
+			Mint        string `json:"mint"`
+			TokenAmount struct {
+				Amount         string   `json:"amount"`
+				Decimals       uint8    `json:"decimals"`
+				UIAmount       *float64 `json:"uiAmount"`
+				UIAmountString string   `json:"uiAmountString"`
+			} `json:"tokenAmount"`
+			Extensions []parsedExtension `json:"extensions"`
+		} `json:"info"`
+	} `json:"parsed"`
+}
+
+// parsedMint mirrors the jsonParsed layout of a mint, keeping only extensions.
+type parsedMint struct {
+	Parsed struct {
+		Info struct {
+			Extensions []parsedExtension `json:"extensions"`
+		} `json:"info"`
+	} `json:"parsed"`
+}
+
+type parsedExtension struct {
+	Extension string          `json:"extension"`
+	State     json.RawMessage `json:"state"`
+}
+
+type parsedTransferFee struct {
+	Epoch                  uint64 `json:"epoch"`
+	MaximumFee             uint64 `json:"maximumFee"`
+	TransferFeeBasisPoints uint16 `json:"transferFeeBasisPoints"`
+}
+
+func (f parsedTransferFee) toType() types.TransferFee {
+	return types.TransferFee{Epoch: f.Epoch, MaximumFee: f.MaximumFee, BasisPoints: f.TransferFeeBasisPoints}
+}
+
+// GetTokenAccounts lists the owner's accounts under both the SPL Token and
+// Token-2022 programs using getTokenAccountsByOwner with jsonParsed encoding.
+// Token-2022 mints are then fetched to decode their extensions.
+func (cl *Client) GetTokenAccounts(ctx context.Context, owner sol.PublicKey) ([]TokenAccount, time.Duration, error) {
+	start := time.Now()
+	var legacy, t22 []TokenAccount
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		legacy, err = cl.tokenAccountsByProgram(gctx, owner, sol.TokenProgramID)
+		return err
+	})
+	g.Go(func() (err error) {
+		t22, err = cl.tokenAccountsByProgram(gctx, owner, Token2022ProgramID)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, time.Since(start), err
+	}
+	if err := cl.attachMintExtensions(ctx, t22); err != nil {
+		return nil, time.Since(start), err
+	}
+	return append(legacy, t22...), time.Since(start), nil
+}
+
+func (cl *Client) tokenAccountsByProgram(ctx context.Context, owner, program sol.PublicKey) ([]TokenAccount, error) {
+	res, err := cl.c.GetTokenAccountsByOwner(ctx, owner,
+		&rpc.GetTokenAccountsConfig{ProgramId: program.ToPointer()},
+		&rpc.GetTokenAccountsOpts{Commitment: cl.commitment, Encoding: sol.EncodingJSONParsed},
+	)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]TokenAccount, 0, len(res.Value))
+	for _, ta := range res.Value {
+		if ta == nil || ta.Account.Data == nil {
+			continue
+		}
+		acc, err := decodeTokenAccount(ta.Pubkey, ta.Account.Data.GetRawJSON())
+		if err != nil {
+			return nil, err
+		}
+		if program.Equals(Token2022ProgramID) {
+			acc.Program = ProgramSPLToken2022
+		} else {
+			acc.Program = ProgramSPLToken
+		}
+		out = append(out, acc)
+	}
+	return out, nil
+}
+
+// attachMintExtensions fetches the distinct mints of Token-2022 accounts and
+// merges their extensions into each account.
+func (cl *Client) attachMintExtensions(ctx context.Context, accounts []TokenAccount) error {
+	if len(accounts) == 0 {
+		return nil
+	}
+	seen := make(map[string]struct{})
+	var mints []sol.PublicKey
+	for _, a := range accounts {
+		if _, ok := seen[a.Mint]; ok {
+			continue
+		}
+		seen[a.Mint] = struct{}{}
+		pk, err := sol.PublicKeyFromBase58(a.Mint)
+		if err != nil {
+			return fmt.Errorf("token account %s has invalid mint: %w", a.Address, err)
+		}
+		mints = append(mints, pk)
+	}
+	byMint := make(map[string]*types.TokenExtensions, len(mints))
+	for i := 0; i < len(mints); i += MaxMultipleAccounts {
+		end := i + MaxMultipleAccounts
+		if end > len(mints) {
+			end = len(mints)
+		}
+		res, err := cl.c.GetMultipleAccountsWithOpts(ctx, mints[i:end], &rpc.GetMultipleAccountsOpts{
+			Encoding:   sol.EncodingJSONParsed,
+			Commitment: cl.commitment,
+		})
+		if err != nil {
+			return err
+		}
+		for j, acc := range res.Value {
+			if acc == nil || acc.Data == nil || i+j >= end {
+				continue
+			}
+			var m parsedMint
+			if err := json.Unmarshal(acc.Data.GetRawJSON(), &m); err != nil {
+				return fmt.Errorf("decode mint %s: %w", mints[i+j], err)
+			}
+			ext := &types.TokenExtensions{}
+			if err := applyExtensions(ext, m.Parsed.Info.Extensions); err != nil {
+				return fmt.Errorf("decode mint %s: %w", mints[i+j], err)
+			}
+			byMint[mints[i+j].String()] = ext
+		}
+	}
+	for i := range accounts {
+		if accounts[i].Extensions == nil {
+			accounts[i].Extensions = &types.TokenExtensions{}
+		}
+		if mintExt := byMint[accounts[i].Mint]; mintExt != nil {
+			mergeExtensions(accounts[i].Extensions, mintExt)
+		}
+	}
+	return nil
+}
+
+func decodeTokenAccount(address sol.PublicKey, raw json.RawMessage) (TokenAccount, error) {
+	var p parsedTokenAccount
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return TokenAccount{}, fmt.Errorf("decode token account %s: %w", address, err)
+	}
+	info := p.Parsed.Info
+	acc := TokenAccount{
+		Address:        address.String(),
+		Mint:           info.Mint,
+		Amount:         info.TokenAmount.Amount,
+		Decimals:       info.TokenAmount.Decimals,
+		UIAmountString: info.TokenAmount.UIAmountString,
+	}
+	if info.TokenAmount.UIAmount != nil {
+		acc.UIAmount = *info.TokenAmount.UIAmount
+	}
+	if len(info.Extensions) > 0 {
+		acc.Extensions = &types.TokenExtensions{}
+		if err := applyExtensions(acc.Extensions, info.Extensions); err != nil {
+			return TokenAccount{}, fmt.Errorf("decode token account %s: %w", address, err)
+		}
+	}
+	return acc, nil
+}
+
+// applyExtensions decodes the extensions we report; others are ignored.
+func applyExtensions(dst *types.TokenExtensions, exts []parsedExtension) error {
+	for _, e := range exts {
+		switch e.Extension {
+		case "transferFeeConfig":
+			var st struct {
+				Older          parsedTransferFee `json:"olderTransferFee"`
+				Newer          parsedTransferFee `json:"newerTransferFee"`
+				WithheldAmount uint64            `json:"withheldAmount"`
+			}
+			if err := json.Unmarshal(e.State, &st); err != nil {
+				return fmt.Errorf("transferFeeConfig: %w", err)
+			}
+			if dst.TransferFee == nil {
+				dst.TransferFee = &types.TransferFeeConfig{}
+			}
+			dst.TransferFee.Older = st.Older.toType()
+			dst.TransferFee.Newer = st.Newer.toType()
+			dst.TransferFee.MintWithheldAmount = st.WithheldAmount
+		case "transferFeeAmount":
+			var st struct {
+				WithheldAmount uint64 `json:"withheldAmount"`
+			}
+			if err := json.Unmarshal(e.State, &st); err != nil {
+				return fmt.Errorf("transferFeeAmount: %w", err)
+			}
+			if dst.TransferFee == nil {
+				dst.TransferFee = &types.TransferFeeConfig{}
+			}
+			dst.TransferFee.AccountWithheldAmount = st.WithheldAmount
+		case "interestBearingConfig":
+			var st struct {
+				CurrentRate             int16 `json:"currentRate"`
+				PreUpdateAverageRate    int16 `json:"preUpdateAverageRate"`
+				InitializationTimestamp int64 `json:"initializationTimestamp"`
+				LastUpdateTimestamp     int64 `json:"lastUpdateTimestamp"`
+			}
+			if err := json.Unmarshal(e.State, &st); err != nil {
+				return fmt.Errorf("interestBearingConfig: %w", err)
+			}
+			dst.InterestBearing = &types.InterestBearingConfig{
+				CurrentRate:             st.CurrentRate,
+				PreUpdateAverageRate:    st.PreUpdateAverageRate,
+				InitializationTimestamp: st.InitializationTimestamp,
+				LastUpdateTimestamp:     st.LastUpdateTimestamp,
+			}
+		case "nonTransferable", "nonTransferableAccount":
+			dst.NonTransferable = true
+		case "confidentialTransferMint", "confidentialTransferAccount":
+			dst.ConfidentialTransfer = true
+		}
+	}
+	return nil
+}
+
+// mergeExtensions folds mint-level extensions into an account's extensions.
+func mergeExtensions(dst, mint *types.TokenExtensions) {
+	if mint.TransferFee != nil {
+		withheld := uint64(0)
+		if dst.TransferFee != nil {
+			withheld = dst.TransferFee.AccountWithheldAmount
+		}
+		tf := *mint.TransferFee
+		tf.AccountWithheldAmount = withheld
+		dst.TransferFee = &tf
+	}
+	if mint.InterestBearing != nil {
+		dst.InterestBearing = mint.InterestBearing
+	}
+	dst.NonTransferable = dst.NonTransferable || mint.NonTransferable
+	dst.ConfidentialTransfer = dst.ConfidentialTransfer || mint.ConfidentialTransfer
+}
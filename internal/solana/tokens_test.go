@@ -0,0 +1,101 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	sol "github.com/gagliardetto/solana-go"
+)
+
+func tokenAccountFixture(pubkey, mint, amount string, decimals int, ui float64) map[string]any {
+	return map[string]any{
+		"pubkey": pubkey,
+		"account": map[string]any{
+			"lamports": 2039280, "owner": sol.TokenProgramID.String(), "executable": false, "rentEpoch": 0,
+			"data": map[string]any{
+				"program": "spl-token", "space": 165,
+				"parsed": map[string]any{
+					"type": "account",
+					"info": map[string]any{
+						"mint": mint, "owner": "owner", "state": "initialized", "isNative": false,
+						"tokenAmount": map[string]any{"amount": amount, "decimals": decimals, "uiAmount": ui, "uiAmountString": amount},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestClient_GetTokenAccounts(t *testing.T) {
+	acct := sol.NewWallet().PublicKey().String()
+	mint := sol.NewWallet().PublicKey().String()
+	acct22 := sol.NewWallet().PublicKey().String()
+	mint22 := sol.NewWallet().PublicKey().String()
+	stub := newRPCStub(t, map[string]func([]json.RawMessage) any{
+		"getTokenAccountsByOwner": func(params []json.RawMessage) any {
+			var filter struct{ ProgramID string `json:"programId"` }
+			_ = json.Unmarshal(params[1], &filter)
+			var value []any
+			switch filter.ProgramID {
+			case sol.TokenProgramID.String():
+				value = []any{tokenAccountFixture(acct, mint, "1500000", 6, 1.5)}
+			case Token2022ProgramID.String():
+				fx := tokenAccountFixture(acct22, mint22, "1000", 2, 10.5) // interest-bearing: ui != raw/10^2
+				info := fx["account"].(map[string]any)["data"].(map[string]any)["parsed"].(map[string]any)["info"].(map[string]any)
+				info["extensions"] = []any{
+					map[string]any{"extension": "transferFeeAmount", "state": map[string]any{"withheldAmount": 7}},
+					map[string]any{"extension": "immutableOwner"},
+				}
+				value = []any{fx}
+			default:
+				t.Errorf("unexpected programId %s", filter.ProgramID)
+			}
+			return map[string]any{"context": map[string]any{"slot": 1}, "value": value}
+		},
+		"getMultipleAccounts": func(params []json.RawMessage) any {
+			var keys []string
+			_ = json.Unmarshal(params[0], &keys)
+			if len(keys) != 1 || keys[0] != mint22 {
+				t.Errorf("mint lookup keys=%v", keys)
+			}
+			return map[string]any{"context": map[string]any{"slot": 1}, "value": []any{map[string]any{
+				"lamports": 1, "owner": Token2022ProgramID.String(), "executable": false, "rentEpoch": 0,
+				"data": map[string]any{"program": "spl-token-2022", "space": 300, "parsed": map[string]any{
+					"type": "mint",
+					"info": map[string]any{"decimals": 2, "extensions": []any{
+						map[string]any{"extension": "transferFeeConfig", "state": map[string]any{
+							"newerTransferFee": map[string]any{"epoch": 500, "maximumFee": uint64(18446744073709551615), "transferFeeBasisPoints": 50},
+							"olderTransferFee": map[string]any{"epoch": 0, "maximumFee": 0, "transferFeeBasisPoints": 0},
+							"withheldAmount":   3,
+						}},
+						map[string]any{"extension": "interestBearingConfig", "state": map[string]any{
+							"currentRate": 500, "preUpdateAverageRate": 250, "initializationTimestamp": 1700000000, "lastUpdateTimestamp": 1710000000,
+						}},
+						map[string]any{"extension": "nonTransferable"},
+						map[string]any{"extension": "confidentialTransferMint", "state": map[string]any{"autoApproveNewAccounts": true}},
+					}},
+				}},
+			}}}
+		},
+	})
+
+	cl := NewClient(stub.URL, "finalized")
+	accounts, _, err := cl.GetTokenAccounts(context.Background(), sol.NewWallet().PublicKey())
+	if err != nil { t.Fatalf("GetTokenAccounts: %v", err) }
+	if stub.count("getTokenAccountsByOwner") != 2 { t.Fatalf("expected one call per token program") }
+	if len(accounts) != 2 { t.Fatalf("accounts=%d", len(accounts)) }
+	a := accounts[0]
+	if a.Address != acct || a.Mint != mint || a.Program != ProgramSPLToken || a.Amount != "1500000" || a.Decimals != 6 || a.UIAmount != 1.5 || a.Extensions != nil {
+		t.Fatalf("legacy account=%+v", a)
+	}
+	b := accounts[1]
+	if b.Program != ProgramSPLToken2022 || b.UIAmount != 10.5 || b.Extensions == nil { t.Fatalf("token-2022 account=%+v", b) }
+	ext := b.Extensions
+	if ext.TransferFee == nil || ext.TransferFee.Newer.BasisPoints != 50 || ext.TransferFee.Newer.MaximumFee != 18446744073709551615 ||
+		ext.TransferFee.MintWithheldAmount != 3 || ext.TransferFee.AccountWithheldAmount != 7 {
+		t.Fatalf("transfer fee=%+v", ext.TransferFee)
+	}
+	if ext.InterestBearing == nil || ext.InterestBearing.CurrentRate != 500 || ext.InterestBearing.PreUpdateAverageRate != 250 { t.Fatalf("interest=%+v", ext.InterestBearing) }
+	if !ext.NonTransferable || !ext.ConfidentialTransfer { t.Fatalf("flags=%+v", ext) }
+}
@@ -0,0 +1,354 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/mr-tron/base58"
+)
+
+// SignaturePage selects a page of a wallet's signatures, newest first.
+// Before and Until are exclusive signature cursors; zero values are unset.
+type SignaturePage struct {
+	Before sol.Signature
+	Until  sol.Signature
+	Limit  int
+}
+
+// SignatureInfo is one entry of getSignaturesForAddress.
+type SignatureInfo struct {
+	Signature          string
+	Slot               uint64
+	BlockTime          *time.Time
+	Err                any // nil when the transaction succeeded
+	ConfirmationStatus string
+}
+
+// Finalized reports whether the transaction can no longer be rolled back.
+func (s SignatureInfo) Finalized() bool {
+	return s.ConfirmationStatus == string(rpc.ConfirmationStatusFinalized)
+}
+
+// WalletDelta is how a transaction changed one wallet's SOL balance.
+type WalletDelta struct {
+	Fee          uint64
+	PreLamports  uint64
+	PostLamports uint64
+}
+
+// Delta returns the signed lamport change.
+func (d WalletDelta) Delta() int64 { return int64(d.PostLamports) - int64(d.PreLamports) }
+
+// TransactionFetcher pages through a wallet's transactions.
+type TransactionFetcher interface {
+	GetSignatures(ctx context.Context, wallet sol.PublicKey, page SignaturePage) ([]SignatureInfo, time.Duration, error)
+	GetWalletDelta(ctx context.Context, sig sol.Signature, wallet sol.PublicKey) (WalletDelta, time.Duration, error)
+}
+
+// GetSignatures returns one page of getSignaturesForAddress.
+func (cl *Client) GetSignatures(ctx context.Context, wallet sol.PublicKey, page SignaturePage) ([]SignatureInfo, time.Duration, error) {
+	start := time.Now()
+	opts := &rpc.GetSignaturesForAddressOpts{
+		Before:     page.Before,
+		Until:      page.Until,
+		Commitment: cl.historyCommitment(),
+	}
+	if page.Limit > 0 {
+		opts.Limit = &page.Limit
+	}
+	sigs, err := cl.c.GetSignaturesForAddressWithOpts(ctx, wallet, opts)
+	lat := time.Since(start)
+	if err != nil {
+		return nil, lat, err
+	}
+	out := make([]SignatureInfo, 0, len(sigs))
+	for _, s := range sigs {
+		si := SignatureInfo{
+			Signature:          s.Signature.String(),
+			Slot:               s.Slot,
+			Err:                s.Err,
+			ConfirmationStatus: string(s.ConfirmationStatus),
+		}
+		if s.BlockTime != nil {
+			bt := s.BlockTime.Time().UTC()
+			si.BlockTime = &bt
+		}
+		out = append(out, si)
+	}
+	return out, lat, nil
+}
+
+// GetWalletDelta fetches sig and reads wallet's pre/post balances and the fee.
+func (cl *Client) GetWalletDelta(ctx context.Context, sig sol.Signature, wallet sol.PublicKey) (WalletDelta, time.Duration, error) {
+	start := time.Now()
+	tx, err := cl.getTransactionBalances(ctx, sig)
+	lat := time.Since(start)
+	if err != nil {
+		return WalletDelta{}, lat, err
+	}
+	idx := tx.accountIndex(wallet.String())
+	if idx < 0 || idx >= len(tx.Meta.PreBalances) || idx >= len(tx.Meta.PostBalances) {
+		return WalletDelta{}, lat, fmt.Errorf("wallet not found in transaction %s", sig)
+	}
+	return WalletDelta{
+		Fee:          tx.Meta.Fee,
+		PreLamports:  tx.Meta.PreBalances[idx],
+		PostLamports: tx.Meta.PostBalances[idx],
+	}, lat, nil
+}
+
+// ErrTransactionNotFound is returned when the node has no record of a
+// transaction at the client's commitment.
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// TransactionDetail is a transaction with its instructions resolved against
+// its account keys, ready to decode.
+type TransactionDetail struct {
+	Signature    string
+	Slot         uint64
+	BlockTime    *time.Time
+	Version      string // "legacy" or "0"
+	Err          any    // nil when the transaction succeeded
+	Fee          uint64
+	ComputeUnits *uint64
+	// Accounts lists static keys then addresses loaded from lookup tables
+	// (writable, then readonly), the order balances are reported in.
+	Accounts          []TxAccount
+	Instructions      []TxInstruction
+	PreBalances       []uint64
+	PostBalances      []uint64
+	PreTokenBalances  []TxTokenBalance
+	PostTokenBalances []TxTokenBalance
+	Logs              []string
+	// Finalized is set when the transaction was read at finalized
+	// commitment and can no longer change.
+	Finalized bool
+}
+
+// TxAccount is one account of a transaction.
+type TxAccount struct {
+	Address  sol.PublicKey
+	Signer   bool
+	Writable bool
+}
+
+// TxInstruction is an instruction with its program and accounts resolved.
+// Inner holds the instructions it invoked, for top-level instructions only.
+type TxInstruction struct {
+	ProgramID sol.PublicKey
+	Accounts  []sol.PublicKey
+	Data      []byte
+	Inner     []TxInstruction
+}
+
+// TxTokenBalance is a token account's balance before or after a transaction.
+type TxTokenBalance struct {
+	AccountIndex int
+	Mint         string
+	Owner        string
+	ProgramID    string
+	Amount       string // raw base units
+	Decimals     uint8
+}
+
+// TransactionDetailFetcher loads a single transaction for decoding.
+type TransactionDetailFetcher interface {
+	GetTransaction(ctx context.Context, sig sol.Signature) (TransactionDetail, time.Duration, error)
+}
+
+type rawInstruction struct {
+	ProgramIDIndex int    `json:"programIdIndex"`
+	Accounts       []int  `json:"accounts"`
+	Data           string `json:"data"` // base58
+}
+
+type rawTokenBalance struct {
+	AccountIndex  int    `json:"accountIndex"`
+	Mint          string `json:"mint"`
+	Owner         string `json:"owner"`
+	ProgramID     string `json:"programId"`
+	UITokenAmount struct {
+		Amount   string `json:"amount"`
+		Decimals uint8  `json:"decimals"`
+	} `json:"uiTokenAmount"`
+}
+
+// rawTransaction is a json-encoded getTransaction result.
+type rawTransaction struct {
+	Slot        uint64 `json:"slot"`
+	BlockTime   *int64 `json:"blockTime"`
+	Version     any    `json:"version"`
+	Transaction struct {
+		Signatures []string `json:"signatures"`
+		Message    struct {
+			Header struct {
+				NumRequiredSignatures       int `json:"numRequiredSignatures"`
+				NumReadonlySignedAccounts   int `json:"numReadonlySignedAccounts"`
+				NumReadonlyUnsignedAccounts int `json:"numReadonlyUnsignedAccounts"`
+			} `json:"header"`
+			AccountKeys  []string         `json:"accountKeys"`
+			Instructions []rawInstruction `json:"instructions"`
+		} `json:"message"`
+	} `json:"transaction"`
+	Meta *struct {
+		Err               any               `json:"err"`
+		Fee               uint64            `json:"fee"`
+		PreBalances       []uint64          `json:"preBalances"`
+		PostBalances      []uint64          `json:"postBalances"`
+		PreTokenBalances  []rawTokenBalance `json:"preTokenBalances"`
+		PostTokenBalances []rawTokenBalance `json:"postTokenBalances"`
+		InnerInstructions []struct {
+			Index        int              `json:"index"`
+			Instructions []rawInstruction `json:"instructions"`
+		} `json:"innerInstructions"`
+		LogMessages          []string `json:"logMessages"`
+		ComputeUnitsConsumed *uint64  `json:"computeUnitsConsumed"`
+		LoadedAddresses      struct {
+			Writable []string `json:"writable"`
+			Readonly []string `json:"readonly"`
+		} `json:"loadedAddresses"`
+	} `json:"meta"`
+}
+
+// GetTransaction fetches sig with json encoding and resolves its account
+// keys, including those loaded from address lookup tables.
+func (cl *Client) GetTransaction(ctx context.Context, sig sol.Signature) (TransactionDetail, time.Duration, error) {
+	start := time.Now()
+	cm := cl.historyCommitment()
+	var raw *rawTransaction
+	err := cl.c.RPCCallForInto(ctx, &raw, "getTransaction", []interface{}{sig, map[string]interface{}{
+		"encoding":                       sol.EncodingJSON,
+		"commitment":                     cm,
+		"maxSupportedTransactionVersion": 0,
+	}})
+	lat := time.Since(start)
+	if err != nil {
+		return TransactionDetail{}, lat, err
+	}
+	if raw == nil || raw.Meta == nil {
+		return TransactionDetail{}, lat, ErrTransactionNotFound
+	}
+	tx, err := raw.detail(sig)
+	if err != nil {
+		return TransactionDetail{}, lat, fmt.Errorf("transaction %s: %w", sig, err)
+	}
+	tx.Finalized = cm == rpc.CommitmentFinalized
+	return tx, lat, nil
+}
+
+func (raw *rawTransaction) detail(sig sol.Signature) (TransactionDetail, error) {
+	msg, meta := raw.Transaction.Message, raw.Meta
+	tx := TransactionDetail{
+		Signature:    sig.String(),
+		Slot:         raw.Slot,
+		Version:      "legacy",
+		Err:          meta.Err,
+		Fee:          meta.Fee,
+		ComputeUnits: meta.ComputeUnitsConsumed,
+		PreBalances:  meta.PreBalances,
+		PostBalances: meta.PostBalances,
+		Logs:         meta.LogMessages,
+	}
+	if v, ok := raw.Version.(float64); ok {
+		tx.Version = fmt.Sprint(v)
+	}
+	if raw.BlockTime != nil {
+		bt := time.Unix(*raw.BlockTime, 0).UTC()
+		tx.BlockTime = &bt
+	}
+
+	h := msg.Header
+	static := len(msg.AccountKeys)
+	add := func(keys []string, writable func(i int) bool, signers int) error {
+		for i, k := range keys {
+			pk, err := sol.PublicKeyFromBase58(k)
+			if err != nil {
+				return fmt.Errorf("account key %q: %w", k, err)
+			}
+			tx.Accounts = append(tx.Accounts, TxAccount{Address: pk, Signer: i < signers, Writable: writable(i)})
+		}
+		return nil
+	}
+	if err := add(msg.AccountKeys, func(i int) bool {
+		if i < h.NumRequiredSignatures {
+			return i < h.NumRequiredSignatures-h.NumReadonlySignedAccounts
+		}
+		return i < static-h.NumReadonlyUnsignedAccounts
+	}, h.NumRequiredSignatures); err != nil {
+		return TransactionDetail{}, err
+	}
+	if err := add(meta.LoadedAddresses.Writable, func(int) bool { return true }, 0); err != nil {
+		return TransactionDetail{}, err
+	}
+	if err := add(meta.LoadedAddresses.Readonly, func(int) bool { return false }, 0); err != nil {
+		return TransactionDetail{}, err
+	}
+
+	inner := make(map[int][]rawInstruction, len(meta.InnerInstructions))
+	for _, ii := range meta.InnerInstructions {
+		inner[ii.Index] = ii.Instructions
+	}
+	for i, ri := range msg.Instructions {
+		ix, err := tx.instruction(ri)
+		if err != nil {
+			return TransactionDetail{}, fmt.Errorf("instruction %d: %w", i, err)
+		}
+		for j, rj := range inner[i] {
+			in, err := tx.instruction(rj)
+			if err != nil {
+				return TransactionDetail{}, fmt.Errorf("instruction %d.%d: %w", i, j+1, err)
+			}
+			ix.Inner = append(ix.Inner, in)
+		}
+		tx.Instructions = append(tx.Instructions, ix)
+	}
+	tx.PreTokenBalances = tokenBalances(meta.PreTokenBalances)
+	tx.PostTokenBalances = tokenBalances(meta.PostTokenBalances)
+	return tx, nil
+}
+
+// instruction resolves ri's account indexes against tx.Accounts.
+func (tx *TransactionDetail) instruction(ri rawInstruction) (TxInstruction, error) {
+	account := func(i int) (sol.PublicKey, error) {
+		if i < 0 || i >= len(tx.Accounts) {
+			return sol.PublicKey{}, fmt.Errorf("account index %d out of range", i)
+		}
+		return tx.Accounts[i].Address, nil
+	}
+	program, err := account(ri.ProgramIDIndex)
+	if err != nil {
+		return TxInstruction{}, err
+	}
+	var data []byte
+	if ri.Data != "" { // base58.Decode rejects the empty string
+		if data, err = base58.Decode(ri.Data); err != nil {
+			return TxInstruction{}, fmt.Errorf("data: %w", err)
+		}
+	}
+	ix := TxInstruction{ProgramID: program, Accounts: make([]sol.PublicKey, len(ri.Accounts)), Data: data}
+	for j, a := range ri.Accounts {
+		if ix.Accounts[j], err = account(a); err != nil {
+			return TxInstruction{}, err
+		}
+	}
+	return ix, nil
+}
+
+func tokenBalances(raw []rawTokenBalance) []TxTokenBalance {
+	out := make([]TxTokenBalance, 0, len(raw))
+	for _, b := range raw {
+		out = append(out, TxTokenBalance{
+			AccountIndex: b.AccountIndex,
+			Mint:         b.Mint,
+			Owner:        b.Owner,
+			ProgramID:    b.ProgramID,
+			Amount:       b.UITokenAmount.Amount,
+			Decimals:     b.UITokenAmount.Decimals,
+		})
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	sol "github.com/gagliardetto/solana-go"
+)
+
+func TestClient_GetSignaturesPassesCursors(t *testing.T) {
+	beforeStr, s1 := sigFixture(5, 1_700_000_005)
+	untilStr, _ := sigFixture(1, 0)
+	var got map[string]any
+	stub := newRPCStub(t, map[string]func([]json.RawMessage) any{
+		"getSignaturesForAddress": func(params []json.RawMessage) any {
+			_ = json.Unmarshal(params[1], &got)
+			s1["err"] = map[string]any{"InstructionError": []any{0, "Custom"}}
+			return []any{s1}
+		},
+	})
+	cl := NewClient(stub.URL, "processed")
+	before, _ := sol.SignatureFromBase58(beforeStr)
+	until, _ := sol.SignatureFromBase58(untilStr)
+	sigs, _, err := cl.GetSignatures(context.Background(), sol.NewWallet().PublicKey(), SignaturePage{Before: before, Until: until, Limit: 10})
+	if err != nil { t.Fatalf("GetSignatures: %v", err) }
+	if got["before"] != beforeStr || got["until"] != untilStr || got["limit"] != float64(10) { t.Fatalf("params=%v", got) }
+	if got["commitment"] != "confirmed" { t.Fatalf("processed should map to confirmed, got %v", got["commitment"]) }
+	if len(sigs) != 1 || sigs[0].Err == nil || !sigs[0].Finalized() || sigs[0].BlockTime == nil { t.Fatalf("sigs=%+v", sigs) }
+}
+
+func TestClient_GetWalletDeltaWithLoadedAddresses(t *testing.T) {
+	wallet := sol.NewWallet().PublicKey()
+	stub := newRPCStub(t, map[string]func([]json.RawMessage) any{
+		"getTransaction": func([]json.RawMessage) any {
+			tx := txFixture(9, []string{"payer", "program"}, []uint64{10, 1, 7_000})
+			meta := tx["meta"].(map[string]any)
+			meta["preBalances"] = []uint64{20, 1, 2_000}
+			meta["loadedAddresses"] = map[string]any{"writable": []string{wallet.String()}, "readonly": []string{}}
+			return tx
+		},
+	})
+	cl := NewClient(stub.URL, "finalized")
+	d, _, err := cl.GetWalletDelta(context.Background(), sol.Signature{1}, wallet)
+	if err != nil { t.Fatalf("GetWalletDelta: %v", err) }
+	if d.PreLamports != 2_000 || d.PostLamports != 7_000 || d.Delta() != 5_000 || d.Fee != 5000 { t.Fatalf("delta=%+v", d) }
+}
+
+func TestClient_GetTransactionResolvesAccounts(t *testing.T) {
+	payer, dest, loaded := sol.NewWallet().PublicKey(), sol.NewWallet().PublicKey(), sol.NewWallet().PublicKey()
+	stub := newRPCStub(t, map[string]func([]json.RawMessage) any{
+		"getTransaction": func(params []json.RawMessage) any {
+			var sig string
+			_ = json.Unmarshal(params[0], &sig)
+			if sig != (sol.Signature{1}).String() { return nil }
+			tx := txFixture(9, []string{payer.String(), dest.String(), sol.SystemProgramID.String()}, []uint64{10, 20, 1, 30})
+			tx["version"] = 0
+			msg := tx["transaction"].(map[string]any)["message"].(map[string]any)
+			msg["header"] = map[string]any{"numRequiredSignatures": 1, "numReadonlySignedAccounts": 0, "numReadonlyUnsignedAccounts": 1}
+			msg["instructions"] = []any{map[string]any{"programIdIndex": 2, "accounts": []int{0, 3}, "data": "3Bxs4h24hBtQy9rw"}}
+			meta := tx["meta"].(map[string]any)
+			meta["loadedAddresses"] = map[string]any{"writable": []string{loaded.String()}, "readonly": []string{}}
+			meta["innerInstructions"] = []any{map[string]any{"index": 0, "instructions": []any{map[string]any{"programIdIndex": 2, "accounts": []int{1}, "data": ""}}}}
+			meta["postTokenBalances"] = []any{map[string]any{"accountIndex": 3, "mint": "m", "owner": "o", "programId": "p", "uiTokenAmount": map[string]any{"amount": "42", "decimals": 6}}}
+			return tx
+		},
+	})
+	cl := NewClient(stub.URL, "finalized")
+	tx, _, err := cl.GetTransaction(context.Background(), sol.Signature{1})
+	if err != nil { t.Fatalf("GetTransaction: %v", err) }
+	if tx.Version != "0" || !tx.Finalized || tx.BlockTime == nil || tx.Fee != 5000 || len(tx.Accounts) != 4 { t.Fatalf("tx=%+v", tx) }
+	if a := tx.Accounts; !a[0].Signer || !a[0].Writable || a[1].Signer || !a[1].Writable || a[2].Writable || !a[3].Address.Equals(loaded) || !a[3].Writable { t.Fatalf("accounts=%+v", a) }
+	if len(tx.Instructions) != 1 { t.Fatalf("instructions=%+v", tx.Instructions) }
+	ix := tx.Instructions[0]
+	if !ix.ProgramID.Equals(sol.SystemProgramID) || len(ix.Accounts) != 2 || !ix.Accounts[1].Equals(loaded) || len(ix.Data) != 12 { t.Fatalf("ix=%+v", ix) }
+	if len(ix.Inner) != 1 || !ix.Inner[0].Accounts[0].Equals(dest) || len(ix.Inner[0].Data) != 0 { t.Fatalf("inner=%+v", ix.Inner) }
+	if len(tx.PostTokenBalances) != 1 || tx.PostTokenBalances[0].Amount != "42" || tx.PostTokenBalances[0].Decimals != 6 { t.Fatalf("token balances=%+v", tx.PostTokenBalances) }
+
+	if _, _, err := cl.GetTransaction(context.Background(), sol.Signature{2}); !errors.Is(err, ErrTransactionNotFound) { t.Fatalf("missing tx err=%v", err) }
+}
@@ -1,25 +1,107 @@
 package types
 
-import "time"
+import (
+	"time"
+
+	"github.com/example/solapi/pkg/amount"
+)
 
 // GetBalanceRequest represents the incoming payload for balance lookups.
 type GetBalanceRequest struct {
 	Wallets []string `json:"wallets"`
+	// IncludeStake adds the wallet's stake accounts to each balance.
+	IncludeStake bool `json:"include_stake,omitempty"`
+	// Commitment overrides the server's default commitment level:
+	// "processed", "confirmed" or "finalized".
+	Commitment string `json:"commitment,omitempty"`
+	// Quote values balances in a fiat currency: "usd" or "eur".
+	Quote string `json:"quote,omitempty"`
+	// Units selects how amounts are rendered: UnitsFloat (the default),
+	// UnitsLamports or UnitsSolString.
+	Units string `json:"units,omitempty"`
 }
 
+// Amount renderings selected by GetBalanceRequest.Units, and likewise by
+// the units of historical balance and transaction history requests.
+// Lamports and raw token amounts are always present.
+const (
+	// UnitsFloat adds sol, and ui_amount for tokens, as JSON numbers.
+	UnitsFloat = "float"
+	// UnitsLamports returns base units only.
+	UnitsLamports = "lamports"
+	// UnitsSolString adds sol_string, and ui_amount_string for tokens, as
+	// exact decimal strings.
+	UnitsSolString = "sol-string"
+)
+
 // BalanceEntry represents a single wallet balance response.
 type BalanceEntry struct {
-	Wallet    string  `json:"wallet"`
-	Lamports  uint64  `json:"lamports"`
-	Sol       float64 `json:"sol"`
-	Source    string  `json:"source"`      // "cache" or "rpc"
-	FetchedAt string  `json:"fetched_at"` // RFC3339
+	Wallet    string   `json:"wallet"`
+	Lamports  uint64   `json:"lamports"`
+	// Exists is false when no account is at the address: it was never
+	// funded, or was closed. Lamports is then 0.
+	Exists    bool     `json:"exists"`
+	Sol       *float64 `json:"sol,omitempty"`        // UnitsFloat
+	SolString string   `json:"sol_string,omitempty"` // UnitsSolString
+	Source    string   `json:"source"`               // "cache", "rpc", "stale" or "live"
+	FetchedAt string   `json:"fetched_at"`           // RFC3339
+	// Commitment is the level the balance was read at.
+	Commitment string `json:"commitment,omitempty"`
+	// Slot is the slot the balance was read at, for ordering two readings.
+	Slot uint64 `json:"slot"`
+	// Stake and TotalLamports are only set when include_stake was requested.
+	Stake         *StakeSummary `json:"stake,omitempty"`
+	TotalLamports uint64        `json:"total_lamports,omitempty"` // native + staked
+	// Fiat values Sol in the requested quote currency.
+	Fiat *FiatValue `json:"fiat,omitempty"`
+}
+
+// FiatValue is an amount valued in a fiat currency at the source's price
+// for one whole SOL or token.
+type FiatValue struct {
+	Currency  string  `json:"currency"` // "USD" or "EUR"
+	Price     float64 `json:"price"`
+	PriceTime string  `json:"price_time"` // RFC3339, when the source published the price
+	Value     float64 `json:"value"`
+}
+
+// StakeSummary aggregates lamports across the stake accounts where the wallet
+// is staker or withdrawer.
+type StakeSummary struct {
+	Accounts     int    `json:"accounts"`
+	Active       uint64 `json:"active_lamports"`
+	Activating   uint64 `json:"activating_lamports"`
+	Deactivating uint64 `json:"deactivating_lamports"`
+	Inactive     uint64 `json:"inactive_lamports"`
+	Source       string `json:"source"`     // "cache" or "rpc"
+	FetchedAt    string `json:"fetched_at"` // RFC3339
 }
 
+// Error codes reported in ErrorEntry.Code. They are stable; clients branch
+// on them rather than on the message.
+const (
+	CodeInvalidPubkey       = "invalid_pubkey"
+	CodeUpstreamTimeout     = "upstream_timeout"
+	CodeUpstreamRateLimited = "upstream_rate_limited"
+	CodeUpstreamUnavailable = "upstream_unavailable"
+	CodeCircuitOpen         = "circuit_open"
+	CodeInternal            = "internal"
+	// The balance was read but could not be valued in the quote currency.
+	CodePriceUnavailable = "price_unavailable"
+	// WebSocket API only.
+	CodeInvalidMessage = "invalid_message"
+	CodeLimitExceeded  = "limit_exceeded"
+)
+
 // ErrorEntry captures per-wallet errors that occurred while fetching.
 type ErrorEntry struct {
 	Wallet string `json:"wallet"`
-	Error  string `json:"error"`
+	Code   string `json:"code"`
+	Error  string `json:"error"` // human-readable; never contains upstream details
+	// Retryable reports whether the same request may succeed later.
+	Retryable bool `json:"retryable"`
+	// Attempts is how many upstream calls were made for a failed lookup.
+	Attempts int `json:"attempts,omitempty"`
 }
 
 // GetBalanceResponse is the JSON response for the balance endpoint.
@@ -28,17 +110,379 @@ type GetBalanceResponse struct {
 	Errors   []ErrorEntry   `json:"errors"`
 }
 
+// TokenBalance is a single SPL token account held by a wallet.
+type TokenBalance struct {
+	Account        string   `json:"account"`
+	Mint           string   `json:"mint"`
+	Program        string   `json:"program"` // "spl-token" or "spl-token-2022"
+	Amount         string   `json:"amount"`  // raw base units
+	Decimals       uint8    `json:"decimals"`
+	UIAmount       *float64 `json:"ui_amount,omitempty"`        // UnitsFloat
+	UIAmountString string   `json:"ui_amount_string,omitempty"` // UnitsFloat and UnitsSolString
+	// Extensions is only present for Token-2022 accounts.
+	Extensions *TokenExtensions `json:"extensions,omitempty"`
+	// Fiat values UIAmount in the requested quote currency; absent when no
+	// price is available for the mint.
+	Fiat *FiatValue `json:"fiat,omitempty"`
+}
+
+// TokenExtensions summarizes the Token-2022 extensions of an account and its mint.
+type TokenExtensions struct {
+	TransferFee          *TransferFeeConfig     `json:"transfer_fee,omitempty"`
+	InterestBearing      *InterestBearingConfig `json:"interest_bearing,omitempty"`
+	NonTransferable      bool                   `json:"non_transferable"`
+	ConfidentialTransfer bool                   `json:"confidential_transfer"`
+}
+
+// TransferFeeConfig is the mint's transfer fee schedule. Newer applies from
+// its epoch onwards; Older applies before it.
+type TransferFeeConfig struct {
+	Older                 TransferFee `json:"older"`
+	Newer                 TransferFee `json:"newer"`
+	MintWithheldAmount    uint64      `json:"mint_withheld_amount"`
+	AccountWithheldAmount uint64      `json:"account_withheld_amount"`
+}
+
+// TransferFee is one entry of a transfer fee schedule.
+type TransferFee struct {
+	Epoch       uint64 `json:"epoch"`
+	MaximumFee  uint64 `json:"maximum_fee"`
+	BasisPoints uint16 `json:"basis_points"`
+}
+
+// InterestBearingConfig is the mint's interest rate in basis points. The UI
+// amount of such tokens includes accrued interest, so it differs from
+// Amount scaled by Decimals.
+type InterestBearingConfig struct {
+	CurrentRate             int16 `json:"current_rate_bps"`
+	PreUpdateAverageRate    int16 `json:"pre_update_average_rate_bps"`
+	InitializationTimestamp int64 `json:"initialization_timestamp"`
+	LastUpdateTimestamp     int64 `json:"last_update_timestamp"`
+}
+
+// WalletTokens lists the token accounts owned by one wallet.
+type WalletTokens struct {
+	Wallet    string         `json:"wallet"`
+	Tokens    []TokenBalance `json:"tokens"`
+	Source    string         `json:"source"`     // "cache" or "rpc"
+	FetchedAt string         `json:"fetched_at"` // RFC3339
+}
+
+// GetTokenBalancesResponse is the JSON response for the token balances endpoint.
+// The request body is a GetBalanceRequest.
+type GetTokenBalancesResponse struct {
+	Wallets []WalletTokens `json:"wallets"`
+	Errors  []ErrorEntry   `json:"errors"`
+}
+
+// GetHistoricalBalanceRequest asks for balances at a past slot or time.
+// Exactly one of Slot and Timestamp must be set.
+type GetHistoricalBalanceRequest struct {
+	Wallets   []string `json:"wallets"`
+	Slot      uint64   `json:"slot,omitempty"`
+	Timestamp string   `json:"timestamp,omitempty"` // RFC3339
+	// Units selects how amounts are rendered, as in GetBalanceRequest.
+	Units string `json:"units,omitempty"`
+}
+
+// HistoricalBalanceEntry is a wallet's balance after the last transaction at
+// or before the requested point. Slot is where the value came from; it is 0
+// (with no signature) when the wallet had no transactions by then.
+type HistoricalBalanceEntry struct {
+	Wallet    string   `json:"wallet"`
+	Lamports  uint64   `json:"lamports"`
+	Sol       *float64 `json:"sol,omitempty"`        // UnitsFloat
+	SolString string   `json:"sol_string,omitempty"` // UnitsSolString
+	Slot      uint64   `json:"slot"`
+	Signature string   `json:"signature,omitempty"`
+	BlockTime string   `json:"block_time,omitempty"` // RFC3339
+	Source    string   `json:"source"`               // "cache" or "rpc"
+}
+
+// GetHistoricalBalanceResponse is the JSON response for the historical balance endpoint.
+type GetHistoricalBalanceResponse struct {
+	Balances []HistoricalBalanceEntry `json:"balances"`
+	Errors   []ErrorEntry             `json:"errors"`
+}
+
+// TransactionEntry is one transaction in a wallet's history page.
+type TransactionEntry struct {
+	Signature          string   `json:"signature"`
+	Slot               uint64   `json:"slot"`
+	BlockTime          string   `json:"block_time,omitempty"` // RFC3339
+	Status             string   `json:"status"`               // "success" or "failed"
+	Err                any      `json:"err,omitempty"`        // on-chain error, as returned by the RPC
+	ConfirmationStatus string   `json:"confirmation_status"`
+	Fee                uint64   `json:"fee"`
+	LamportsDelta      int64    `json:"lamports_delta"`             // this wallet's SOL change, fee included
+	SolDelta           *float64 `json:"sol_delta,omitempty"`        // UnitsFloat
+	SolDeltaString     string   `json:"sol_delta_string,omitempty"` // UnitsSolString, signed
+	Source             string   `json:"source"`                     // "cache" or "rpc"
+}
+
+// TransactionError reports a transaction whose details could not be fetched.
+type TransactionError struct {
+	Signature string `json:"signature"`
+	Code      string `json:"code"`
+	Error     string `json:"error"`
+	Retryable bool   `json:"retryable"`
+}
+
+// GetTransactionsResponse is one page of a wallet's transaction history.
+// NextBefore, when set, is the cursor for the following (older) page.
+type GetTransactionsResponse struct {
+	Wallet       string             `json:"wallet"`
+	Transactions []TransactionEntry `json:"transactions"`
+	Errors       []TransactionError `json:"errors"`
+	NextBefore   string             `json:"next_before,omitempty"`
+}
+
+// ExplainedInstruction is one decoded instruction. Type and Info describe
+// what it did; instructions of unknown programs, or whose data could not be
+// decoded, leave Type empty and carry their raw Accounts and Data instead.
+type ExplainedInstruction struct {
+	ProgramID string                 `json:"program_id"`
+	Program   string                 `json:"program,omitempty"` // e.g. "system", "spl-token"
+	Type      string                 `json:"type,omitempty"`    // e.g. "transfer", "transferChecked"
+	Info      map[string]any         `json:"info,omitempty"`
+	Accounts  []string               `json:"accounts,omitempty"`
+	Data      string                 `json:"data,omitempty"` // hex
+	Inner     []ExplainedInstruction `json:"inner_instructions,omitempty"`
+}
+
+// SolChange is an account's SOL balance change in a transaction. The fee
+// payer's change includes the fee.
+type SolChange struct {
+	Account       string `json:"account"`
+	PreLamports   uint64 `json:"pre_lamports"`
+	PostLamports  uint64 `json:"post_lamports"`
+	LamportsDelta int64  `json:"lamports_delta"`
+	SolDelta      string `json:"sol_delta"` // exact decimal, signed
+}
+
+// TokenChange is a token account's balance change in a transaction. Amounts
+// are raw base units; UIDelta is the change in whole tokens.
+type TokenChange struct {
+	Account    string `json:"account"`
+	Owner      string `json:"owner,omitempty"`
+	Mint       string `json:"mint"`
+	ProgramID  string `json:"program_id,omitempty"`
+	Decimals   uint8  `json:"decimals"`
+	PreAmount  string `json:"pre_amount"`
+	PostAmount string `json:"post_amount"`
+	Delta      string `json:"delta"`
+	UIDelta    string `json:"ui_delta"`
+}
+
+// ExplainTransactionResponse is a decoded transaction with a summary of the
+// balance changes it made.
+type ExplainTransactionResponse struct {
+	Signature    string                 `json:"signature"`
+	Slot         uint64                 `json:"slot"`
+	BlockTime    string                 `json:"block_time,omitempty"` // RFC3339
+	Version      string                 `json:"version"`              // "legacy" or "0"
+	Status       string                 `json:"status"`               // "success" or "failed"
+	Err          any                    `json:"err,omitempty"`        // on-chain error, as returned by the RPC
+	Fee          uint64                 `json:"fee"`
+	FeePayer     string                 `json:"fee_payer"`
+	ComputeUnits *uint64                `json:"compute_units,omitempty"`
+	Instructions []ExplainedInstruction `json:"instructions"`
+	SolChanges   []SolChange            `json:"sol_changes"`
+	TokenChanges []TokenChange          `json:"token_changes"`
+	Logs         []string               `json:"logs,omitempty"`
+	Source       string                 `json:"source"` // "cache" or "rpc"
+}
+
+// StreamTokenResponse carries a short-lived token that opens balance streams
+// as ?token=, for clients such as EventSource that cannot set headers.
+type StreamTokenResponse struct {
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at"` // RFC3339
+}
+
+// SocketRequest is a client message on the WebSocket API.
+type SocketRequest struct {
+	Op      string   `json:"op"` // "subscribe" or "unsubscribe"
+	Wallets []string `json:"wallets"`
+}
+
+// SocketMessage is a server message on the WebSocket API. Type selects the
+// field that is set: Balance for "balance", Error for "error", Wallets for
+// "subscribed" and "unsubscribed" acknowledgements.
+type SocketMessage struct {
+	Type    string        `json:"type"`
+	Balance *BalanceEntry `json:"balance,omitempty"`
+	Error   *ErrorEntry   `json:"error,omitempty"`
+	Wallets []string      `json:"wallets,omitempty"`
+}
+
+// WebhookRequest creates or replaces a webhook. With no thresholds every
+// balance change fires; otherwise a change fires when it meets any of them.
+type WebhookRequest struct {
+	URL     string   `json:"url"`
+	Wallets []string `json:"wallets"`
+	// MinDeltaLamports fires on changes of at least this many lamports
+	// either way.
+	MinDeltaLamports uint64 `json:"min_delta_lamports,omitempty"`
+	// FloorLamports fires when a balance falls from at or above the floor
+	// to below it.
+	FloorLamports *uint64 `json:"floor_lamports,omitempty"`
+}
+
+// WebhookEntry describes a registered webhook. Secret, the HMAC key for
+// verifying deliveries, is only returned when the webhook is created.
+type WebhookEntry struct {
+	ID               string   `json:"id"`
+	URL              string   `json:"url"`
+	Wallets          []string `json:"wallets"`
+	MinDeltaLamports uint64   `json:"min_delta_lamports,omitempty"`
+	FloorLamports    *uint64  `json:"floor_lamports,omitempty"`
+	Secret           string   `json:"secret,omitempty"`
+	CreatedAt        string   `json:"created_at"` // RFC3339
+}
+
+// WebhookEvent is the body POSTed to a webhook when a balance changes.
+type WebhookEvent struct {
+	ID            string       `json:"id"`   // stable across retries of one delivery
+	Type          string       `json:"type"` // "balance.changed"
+	WebhookID     string       `json:"webhook_id"`
+	Wallet        string       `json:"wallet"`
+	Previous      BalanceEntry `json:"previous"`
+	Current       BalanceEntry `json:"current"`
+	DeltaLamports int64        `json:"delta_lamports"`
+	CreatedAt     string       `json:"created_at"` // RFC3339
+}
+
+// WatchlistRequest creates a watchlist, or with only Wallets adds wallets
+// to or removes them from one.
+type WatchlistRequest struct {
+	Name    string   `json:"name,omitempty"`
+	Wallets []string `json:"wallets"`
+}
+
+// WatchlistEntry describes a watchlist and its members.
+type WatchlistEntry struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Wallets   []string `json:"wallets"`
+	CreatedAt string   `json:"created_at"` // RFC3339
+}
+
+// WatchlistBalancesResponse is one page of a watchlist's balances, in wallet
+// order. NextAfter, when set, is the cursor for the following page.
+type WatchlistBalancesResponse struct {
+	Watchlist string         `json:"watchlist"`
+	Balances  []BalanceEntry `json:"balances"`
+	Errors    []ErrorEntry   `json:"errors"`
+	NextAfter string         `json:"next_after,omitempty"`
+}
+
+// AccountInfoEntry describes one address. Account is nil when no account
+// exists at the address, which is not the same as a zero balance.
+type AccountInfoEntry struct {
+	Address string `json:"address"`
+	Exists  bool   `json:"exists"`
+	// OnCurve reports whether the address is an ed25519 point, i.e. may have
+	// a private key; program derived addresses are off the curve.
+	OnCurve    bool            `json:"on_curve"`
+	Account    *AccountDetails `json:"account,omitempty"`
+	Source     string          `json:"source"`     // "cache" or "rpc"
+	FetchedAt  string          `json:"fetched_at"` // RFC3339
+	Commitment string          `json:"commitment"`
+	Slot       uint64          `json:"slot"`
+}
+
+// AccountDetails is the on-chain metadata of an existing account.
+type AccountDetails struct {
+	Kind       string `json:"kind"`  // "wallet", "program", "token_account", "mint" or "data"
+	Owner      string `json:"owner"` // owner program
+	Executable bool   `json:"executable"`
+	Lamports   uint64 `json:"lamports"`
+	DataLength uint64 `json:"data_length"`
+	RentEpoch  uint64 `json:"rent_epoch"`
+	// RentExempt reports whether Lamports covers RentExemptMinimum, the
+	// balance at which the account is exempt from rent for its data length.
+	RentExempt        bool   `json:"rent_exempt"`
+	RentExemptMinimum uint64 `json:"rent_exempt_minimum"`
+}
+
+// GetAccountInfoResponse is the JSON response for the account info endpoint.
+// The request body is a GetBalanceRequest; only Wallets and Commitment apply.
+type GetAccountInfoResponse struct {
+	Accounts []AccountInfoEntry `json:"accounts"`
+	Errors   []ErrorEntry       `json:"errors"`
+}
+
+// AssociatedTokenAccountRequest asks for owner's associated token account
+// for mint.
+type AssociatedTokenAccountRequest struct {
+	Owner string `json:"owner"`
+	Mint  string `json:"mint"`
+	// TokenProgram is "spl-token" (the default), "spl-token-2022" or either
+	// program's ID.
+	TokenProgram string `json:"token_program,omitempty"`
+	Commitment   string `json:"commitment,omitempty"`
+}
+
+// AssociatedTokenAccountResponse is the derived account and whether it has
+// been created on-chain. Account is nil when it has not.
+type AssociatedTokenAccountResponse struct {
+	Address      string          `json:"address"`
+	Bump         uint8           `json:"bump"`
+	Owner        string          `json:"owner"`
+	Mint         string          `json:"mint"`
+	TokenProgram string          `json:"token_program"` // program ID
+	Exists       bool            `json:"exists"`
+	Account      *AccountDetails `json:"account,omitempty"`
+	Source       string          `json:"source"` // "cache" or "rpc"
+	Commitment   string          `json:"commitment"`
+	Slot         uint64          `json:"slot"`
+}
+
+// Seed encodings accepted in a ProgramAddressSeed.
+const (
+	SeedUTF8   = "utf8"
+	SeedHex    = "hex"
+	SeedBase58 = "base58"
+)
+
+// ProgramAddressSeed is one seed of a program derived address, at most 32
+// bytes once decoded.
+type ProgramAddressSeed struct {
+	Value string `json:"value"`
+	// Encoding is SeedUTF8 (the default), SeedHex or SeedBase58; public keys
+	// are passed as base58.
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// FindProgramAddressRequest asks for the program derived address of seeds
+// under ProgramID.
+type FindProgramAddressRequest struct {
+	ProgramID string               `json:"program_id"`
+	Seeds     []ProgramAddressSeed `json:"seeds"`
+}
+
+// FindProgramAddressResponse is the first off-curve address found, searching
+// bump seeds down from 255.
+type FindProgramAddressResponse struct {
+	Address   string `json:"address"`
+	Bump      uint8  `json:"bump"`
+	ProgramID string `json:"program_id"`
+}
+
 func NowRFC3339() string { return time.Now().UTC().Format(time.RFC3339) }
 
-// LamportsToSol converts lamports to SOL as a float.
-func LamportsToSol(l uint64) float64 { return float64(l) / 1_000_000_000 }
+// LamportsToSol converts lamports to SOL as the nearest float.
+func LamportsToSol(l uint64) float64 { return amount.Float(l, amount.SolDecimals) }
 
 // NewBalanceEntry creates a BalanceEntry from raw lamports and timestamp.
 func NewBalanceEntry(wallet string, lamports uint64, source string, ts time.Time) BalanceEntry {
+    sol := LamportsToSol(lamports)
     return BalanceEntry{
         Wallet:    wallet,
         Lamports:  lamports,
-        Sol:       LamportsToSol(lamports),
+        Exists:    lamports > 0,
+        Sol:       &sol,
         Source:    source,
         FetchedAt: ts.UTC().Format(time.RFC3339),
     }
@@ -15,7 +15,7 @@ func TestNowRFC3339_Format(t *testing.T) {
 
 func TestGetBalanceResponse_JSON(t *testing.T) {
 	resp := GetBalanceResponse{
-		Balances: []BalanceEntry{{Wallet: "w1", Lamports: 123, Sol: 0.00123, Source: "cache", FetchedAt: NowRFC3339()}},
+		Balances: []BalanceEntry{{Wallet: "w1", Lamports: 123, SolString: "0.000000123", Source: "cache", FetchedAt: NowRFC3339()}},
 		Errors:   []ErrorEntry{{Wallet: "w2", Error: "bad"}},
 	}
 	b, err := json.Marshal(resp)
@@ -33,7 +33,7 @@ func TestLamportsToSol(t *testing.T) {
 func TestNewBalanceEntry(t *testing.T) {
 	ts := time.Now()
 	be := NewBalanceEntry("w", 1_500_000_000, "rpc", ts)
-	if be.Wallet != "w" || be.Sol != 1.5 || be.Source != "rpc" {
+	if be.Wallet != "w" || be.Sol == nil || *be.Sol != 1.5 || be.Source != "rpc" {
 		t.Fatalf("bad entry: %+v", be)
 	}
 }
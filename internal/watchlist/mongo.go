@@ -0,0 +1,170 @@
+package watchlist
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore keeps watchlists in the "watchlists" collection, next to
+// api_keys. Per-owner watchlist counts are kept in "watchlist_counts" so
+// limits can be enforced with a single conditional update.
+type MongoStore struct {
+	coll   *mongo.Collection
+	counts *mongo.Collection
+}
+
+type watchlistDoc struct {
+	ID      string    `bson:"_id"`
+	Owner   string    `bson:"owner"`
+	Name    string    `bson:"name"`
+	Wallets []string  `bson:"wallets"`
+	Created time.Time `bson:"created_at"`
+}
+
+// NewMongoStore sets up the collection and its owner index.
+func NewMongoStore(ctx context.Context, client *mongo.Client, dbName string) (*MongoStore, error) {
+	coll := client.Database(dbName).Collection("watchlists")
+	if _, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "owner", Value: 1}}}); err != nil {
+		return nil, err
+	}
+	return &MongoStore{coll: coll, counts: client.Database(dbName).Collection("watchlist_counts")}, nil
+}
+
+func (d watchlistDoc) watchlist() Watchlist {
+	return Watchlist{ID: d.ID, Owner: d.Owner, Name: d.Name, Wallets: d.Wallets, Created: d.Created}
+}
+
+func (s *MongoStore) Create(ctx context.Context, l Watchlist, limit int) error {
+	if l.Wallets == nil {
+		// $addToSet needs an array to extend
+		l.Wallets = []string{}
+	}
+	if err := s.reserve(ctx, l.Owner, limit); err != nil {
+		return err
+	}
+	if _, err := s.coll.InsertOne(ctx, watchlistDoc{ID: l.ID, Owner: l.Owner, Name: l.Name, Wallets: l.Wallets, Created: l.Created}); err != nil {
+		s.release(ctx, l.Owner)
+		return err
+	}
+	return nil
+}
+
+// reserve counts one more watchlist for owner, or returns ErrLimit if owner
+// already has limit. The limit is part of the update's filter, so two
+// creates cannot both take the last slot.
+func (s *MongoStore) reserve(ctx context.Context, owner string, limit int) error {
+	filter := bson.D{{Key: "_id", Value: owner}}
+	if limit > 0 {
+		filter = append(filter, bson.E{Key: "n", Value: bson.D{{Key: "$lt", Value: limit}}})
+	}
+	inc := bson.D{{Key: "$inc", Value: bson.D{{Key: "n", Value: 1}}}}
+	for seeded := false; ; seeded = true {
+		res, err := s.counts.UpdateOne(ctx, filter, inc)
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount > 0 {
+			return nil
+		}
+		if seeded {
+			return ErrLimit
+		}
+		// no counter yet: start it from the watchlists owner already has; if
+		// another create got there first, its counter stands
+		n, err := s.coll.CountDocuments(ctx, bson.D{{Key: "owner", Value: owner}})
+		if err != nil {
+			return err
+		}
+		if _, err := s.counts.InsertOne(ctx, bson.D{{Key: "_id", Value: owner}, {Key: "n", Value: n}}); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+}
+
+// release gives back a watchlist counted by reserve.
+func (s *MongoStore) release(ctx context.Context, owner string) {
+	_, _ = s.counts.UpdateOne(ctx, bson.D{{Key: "_id", Value: owner}, {Key: "n", Value: bson.D{{Key: "$gt", Value: 0}}}},
+		bson.D{{Key: "$inc", Value: bson.D{{Key: "n", Value: -1}}}})
+}
+
+func (s *MongoStore) Get(ctx context.Context, id string) (Watchlist, error) {
+	var doc watchlistDoc
+	if err := s.coll.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Watchlist{}, ErrNotFound
+		}
+		return Watchlist{}, err
+	}
+	return doc.watchlist(), nil
+}
+
+func (s *MongoStore) List(ctx context.Context, owner string) ([]Watchlist, error) {
+	cur, err := s.coll.Find(ctx, bson.D{{Key: "owner", Value: owner}}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []watchlistDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	out := make([]Watchlist, len(docs))
+	for i, d := range docs {
+		out[i] = d.watchlist()
+	}
+	return out, nil
+}
+
+func (s *MongoStore) Delete(ctx context.Context, id string) error {
+	var doc watchlistDoc
+	if err := s.coll.FindOneAndDelete(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrNotFound
+		}
+		return err
+	}
+	s.release(ctx, doc.Owner)
+	return nil
+}
+
+func (s *MongoStore) AddWallets(ctx context.Context, id string, wallets []string, limit int) error {
+	filter := bson.D{{Key: "_id", Value: id}}
+	if limit > 0 {
+		// the cap is part of the filter, so concurrent additions cannot
+		// overshoot it between a read and the update
+		union := bson.D{{Key: "$setUnion", Value: bson.A{"$wallets", wallets}}}
+		filter = append(filter, bson.E{Key: "$expr", Value: bson.D{{Key: "$lte", Value: bson.A{bson.D{{Key: "$size", Value: union}}, limit}}}})
+	}
+	err := s.update(ctx, filter, bson.D{{Key: "$addToSet", Value: bson.D{{Key: "wallets", Value: bson.D{{Key: "$each", Value: wallets}}}}}})
+	if errors.Is(err, ErrNotFound) && limit > 0 {
+		n, cerr := s.coll.CountDocuments(ctx, bson.D{{Key: "_id", Value: id}})
+		if cerr != nil {
+			return cerr
+		}
+		if n > 0 {
+			return ErrFull
+		}
+	}
+	return err
+}
+
+func (s *MongoStore) RemoveWallets(ctx context.Context, id string, wallets []string) error {
+	return s.update(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$pull", Value: bson.D{{Key: "wallets", Value: bson.D{{Key: "$in", Value: wallets}}}}}})
+}
+
+// update applies update to the watchlist matching filter, returning
+// ErrNotFound when none does.
+func (s *MongoStore) update(ctx context.Context, filter, update bson.D) error {
+	res, err := s.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
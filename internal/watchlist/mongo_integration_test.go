@@ -0,0 +1,92 @@
+package watchlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func connectTestMongo(t *testing.T) (*mongo.Client, func()) {
+	t.Helper()
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		uri = "mongodb://localhost:27017"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	cli, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Skipf("skipping: cannot connect to mongo: %v", err)
+	}
+	if err := cli.Ping(ctx, nil); err != nil {
+		_ = cli.Disconnect(context.Background())
+		t.Skipf("skipping: mongo ping failed: %v", err)
+	}
+	cleanup := func() { _ = cli.Disconnect(context.Background()) }
+	return cli, cleanup
+}
+
+func newTestStore(t *testing.T) *MongoStore {
+	t.Helper()
+	cli, done := connectTestMongo(t)
+	t.Cleanup(done)
+	ctx := context.Background()
+	store, err := NewMongoStore(ctx, cli, "solapi_test")
+	if err != nil { t.Fatalf("new store: %v", err) }
+	_ = store.coll.Drop(ctx)
+	_ = store.counts.Drop(ctx)
+	return store
+}
+
+func TestMongoStore_CreateLimit(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	// a watchlist from before counting began still counts
+	if _, err := store.coll.InsertOne(ctx, watchlistDoc{ID: "old", Owner: "o", Wallets: []string{}}); err != nil { t.Fatalf("seed: %v", err) }
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = store.Create(ctx, Watchlist{ID: fmt.Sprintf("l%d", i), Owner: "o", Name: "n"}, 3)
+		}()
+	}
+	wg.Wait()
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, ErrLimit):
+			t.Fatalf("create: %v", err)
+		}
+	}
+	if lists, _ := store.List(ctx, "o"); created != 2 || len(lists) != 3 { t.Fatalf("created=%d lists=%d, want 2 and 3", created, len(lists)) }
+
+	// deleting frees a slot; other owners are unaffected
+	if err := store.Delete(ctx, "old"); err != nil { t.Fatalf("delete: %v", err) }
+	if err := store.Create(ctx, Watchlist{ID: "again", Owner: "o", Name: "n"}, 3); err != nil { t.Fatalf("create after delete: %v", err) }
+	if err := store.Create(ctx, Watchlist{ID: "over", Owner: "o", Name: "n"}, 3); !errors.Is(err, ErrLimit) { t.Fatalf("over limit err=%v", err) }
+	if err := store.Create(ctx, Watchlist{ID: "other", Owner: "p", Name: "n"}, 3); err != nil { t.Fatalf("other owner: %v", err) }
+}
+
+func TestMongoStore_AddWalletsLimit(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	if err := store.Create(ctx, Watchlist{ID: "l", Owner: "o", Name: "n", Wallets: []string{"a", "b"}}, 0); err != nil { t.Fatalf("create: %v", err) }
+	if err := store.AddWallets(ctx, "l", []string{"b", "c"}, 3); err != nil { t.Fatalf("add within limit: %v", err) }
+	if err := store.AddWallets(ctx, "l", []string{"d"}, 3); !errors.Is(err, ErrFull) { t.Fatalf("over limit err=%v", err) }
+	// re-adding members does not count against the limit
+	if err := store.AddWallets(ctx, "l", []string{"a"}, 3); err != nil { t.Fatalf("re-add: %v", err) }
+	if err := store.AddWallets(ctx, "missing", []string{"a"}, 3); !errors.Is(err, ErrNotFound) { t.Fatalf("missing err=%v", err) }
+	if l, _ := store.Get(ctx, "l"); len(l.Wallets) != 3 { t.Fatalf("wallets=%v", l.Wallets) }
+}
@@ -0,0 +1,54 @@
+// Package watchlist stores named wallet lists owned by API keys.
+package watchlist
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned for an unknown watchlist id.
+var ErrNotFound = errors.New("watchlist: not found")
+
+// ErrFull is returned when adding wallets would take a watchlist over its
+// cap.
+var ErrFull = errors.New("watchlist: wallet limit reached")
+
+// ErrLimit is returned by Store.Create when the owner already has as many
+// watchlists as allowed.
+var ErrLimit = errors.New("watchlist: limit reached")
+
+// Watchlist is a named set of wallets, kept in the order they were added.
+type Watchlist struct {
+	ID      string
+	Owner   string // auth.Owner of the API key that created it
+	Name    string
+	Wallets []string
+	Created time.Time
+}
+
+// Store persists watchlists.
+type Store interface {
+	// Create stores a new watchlist. With limit > 0 it returns ErrLimit,
+	// storing nothing, if l.Owner already has limit watchlists; the check
+	// and the insert cannot be interleaved by concurrent creates.
+	Create(ctx context.Context, l Watchlist, limit int) error
+	Get(ctx context.Context, id string) (Watchlist, error)
+	List(ctx context.Context, owner string) ([]Watchlist, error)
+	Delete(ctx context.Context, id string) error
+	// AddWallets appends wallets not already on the list. With limit > 0 it
+	// changes nothing and returns ErrFull if the list would then hold more
+	// than limit wallets; the check and the change are one atomic update.
+	AddWallets(ctx context.Context, id string, wallets []string, limit int) error
+	// RemoveWallets drops wallets from the list; absent ones are ignored.
+	RemoveWallets(ctx context.Context, id string, wallets []string) error
+}
+
+// NewID returns a random watchlist identifier.
+func NewID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
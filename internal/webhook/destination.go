@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+var (
+	// ErrInvalidURL is returned by CheckURL for anything but an absolute
+	// http(s) URL.
+	ErrInvalidURL = errors.New("webhook: url must be an absolute http(s) URL")
+	// ErrPrivateDestination is returned for destinations on loopback,
+	// private, link-local or unspecified addresses.
+	ErrPrivateDestination = errors.New("webhook: destination is not a public address")
+)
+
+// CheckURL validates a webhook URL at registration. Hosts given as IP
+// literals or as localhost are checked here; other names are not resolved
+// until delivery, where the Sender refuses to dial private addresses, so a
+// name that later resolves to one is still refused.
+func CheckURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidURL
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateDestination
+	}
+	if ip := net.ParseIP(host); ip != nil && !publicIP(ip) {
+		return ErrPrivateDestination
+	}
+	return nil
+}
+
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast()
+}
+
+// dialControl is a net.Dialer Control hook refusing connections to
+// non-public addresses. It sees the address actually being dialled, after
+// DNS resolution.
+func dialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return ErrPrivateDestination
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore keeps webhooks in the "webhooks" collection and their
+// deliveries in "webhook_outbox". It implements Store and Outbox. Per-owner
+// webhook counts are kept in "webhook_counts" so limits can be enforced
+// with a single conditional update.
+type MongoStore struct {
+	hooks  *mongo.Collection
+	outbox *mongo.Collection
+	counts *mongo.Collection
+}
+
+type webhookDoc struct {
+	ID       string    `bson:"_id"`
+	Owner    string    `bson:"owner"`
+	URL      string    `bson:"url"`
+	Secret   string    `bson:"secret"`
+	Wallets  []string  `bson:"wallets"`
+	MinDelta uint64    `bson:"min_delta,omitempty"`
+	Floor    *uint64   `bson:"floor,omitempty"`
+	Created  time.Time `bson:"created_at"`
+}
+
+type deliveryDoc struct {
+	ID          string    `bson:"_id"`
+	WebhookID   string    `bson:"webhook_id"`
+	Payload     []byte    `bson:"payload"`
+	Status      string    `bson:"status"`
+	Attempts    int       `bson:"attempts"`
+	NextAttempt time.Time `bson:"next_attempt"`
+	LastError   string    `bson:"last_error,omitempty"`
+	Created     time.Time `bson:"created_at"`
+}
+
+// NewMongoStore sets up both collections and their indexes.
+func NewMongoStore(ctx context.Context, client *mongo.Client, dbName string) (*MongoStore, error) {
+	db := client.Database(dbName)
+	s := &MongoStore{hooks: db.Collection("webhooks"), outbox: db.Collection("webhook_outbox"), counts: db.Collection("webhook_counts")}
+	if _, err := s.hooks.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "owner", Value: 1}}}); err != nil {
+		return nil, err
+	}
+	_, err := s.outbox.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt", Value: 1}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func toDoc(w Webhook) webhookDoc {
+	return webhookDoc{ID: w.ID, Owner: w.Owner, URL: w.URL, Secret: w.Secret, Wallets: w.Wallets, MinDelta: w.MinDelta, Floor: w.Floor, Created: w.Created}
+}
+
+func (d webhookDoc) webhook() Webhook {
+	return Webhook{ID: d.ID, Owner: d.Owner, URL: d.URL, Secret: d.Secret, Wallets: d.Wallets, MinDelta: d.MinDelta, Floor: d.Floor, Created: d.Created}
+}
+
+func (s *MongoStore) Create(ctx context.Context, w Webhook, limit int) error {
+	if err := s.reserve(ctx, w.Owner, limit); err != nil {
+		return err
+	}
+	if _, err := s.hooks.InsertOne(ctx, toDoc(w)); err != nil {
+		s.release(ctx, w.Owner)
+		return err
+	}
+	return nil
+}
+
+// reserve counts one more webhook for owner, or returns ErrLimit if owner
+// already has limit. The limit is part of the update's filter, so two
+// creates cannot both take the last slot.
+func (s *MongoStore) reserve(ctx context.Context, owner string, limit int) error {
+	filter := bson.D{{Key: "_id", Value: owner}}
+	if limit > 0 {
+		filter = append(filter, bson.E{Key: "n", Value: bson.D{{Key: "$lt", Value: limit}}})
+	}
+	inc := bson.D{{Key: "$inc", Value: bson.D{{Key: "n", Value: 1}}}}
+	for seeded := false; ; seeded = true {
+		res, err := s.counts.UpdateOne(ctx, filter, inc)
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount > 0 {
+			return nil
+		}
+		if seeded {
+			return ErrLimit
+		}
+		// no counter yet: start it from the webhooks owner already has; if
+		// another create got there first, its counter stands
+		n, err := s.hooks.CountDocuments(ctx, bson.D{{Key: "owner", Value: owner}})
+		if err != nil {
+			return err
+		}
+		if _, err := s.counts.InsertOne(ctx, bson.D{{Key: "_id", Value: owner}, {Key: "n", Value: n}}); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+}
+
+// release gives back a webhook counted by reserve.
+func (s *MongoStore) release(ctx context.Context, owner string) {
+	_, _ = s.counts.UpdateOne(ctx, bson.D{{Key: "_id", Value: owner}, {Key: "n", Value: bson.D{{Key: "$gt", Value: 0}}}},
+		bson.D{{Key: "$inc", Value: bson.D{{Key: "n", Value: -1}}}})
+}
+
+func (s *MongoStore) Get(ctx context.Context, id string) (Webhook, error) {
+	var doc webhookDoc
+	if err := s.hooks.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Webhook{}, ErrNotFound
+		}
+		return Webhook{}, err
+	}
+	return doc.webhook(), nil
+}
+
+func (s *MongoStore) List(ctx context.Context, owner string) ([]Webhook, error) {
+	return s.find(ctx, bson.D{{Key: "owner", Value: owner}})
+}
+
+func (s *MongoStore) All(ctx context.Context) ([]Webhook, error) {
+	return s.find(ctx, bson.D{})
+}
+
+func (s *MongoStore) find(ctx context.Context, filter bson.D) ([]Webhook, error) {
+	cur, err := s.hooks.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []webhookDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	out := make([]Webhook, len(docs))
+	for i, d := range docs {
+		out[i] = d.webhook()
+	}
+	return out, nil
+}
+
+func (s *MongoStore) Update(ctx context.Context, w Webhook) error {
+	set := bson.D{{Key: "url", Value: w.URL}, {Key: "wallets", Value: w.Wallets}, {Key: "min_delta", Value: w.MinDelta}, {Key: "floor", Value: w.Floor}}
+	res, err := s.hooks.UpdateOne(ctx, bson.D{{Key: "_id", Value: w.ID}}, bson.D{{Key: "$set", Value: set}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) Delete(ctx context.Context, id string) error {
+	var doc webhookDoc
+	if err := s.hooks.FindOneAndDelete(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrNotFound
+		}
+		return err
+	}
+	s.release(ctx, doc.Owner)
+	return nil
+}
+
+func (s *MongoStore) Enqueue(ctx context.Context, d Delivery) error {
+	_, err := s.outbox.InsertOne(ctx, deliveryDoc{
+		ID: d.ID, WebhookID: d.WebhookID, Payload: d.Payload, Status: d.Status,
+		Attempts: d.Attempts, NextAttempt: d.NextAttempt, LastError: d.LastError, Created: d.Created,
+	})
+	return err
+}
+
+func (s *MongoStore) Claim(ctx context.Context, now time.Time, lease time.Duration) (Delivery, bool, error) {
+	var doc deliveryDoc
+	err := s.outbox.FindOneAndUpdate(ctx,
+		bson.D{{Key: "status", Value: StatusPending}, {Key: "next_attempt", Value: bson.D{{Key: "$lte", Value: now}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "next_attempt", Value: now.Add(lease)}}}},
+		options.FindOneAndUpdate().SetSort(bson.D{{Key: "next_attempt", Value: 1}}).SetReturnDocument(options.After),
+	).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Delivery{}, false, nil
+	}
+	if err != nil {
+		return Delivery{}, false, err
+	}
+	return Delivery{
+		ID: doc.ID, WebhookID: doc.WebhookID, Payload: doc.Payload, Status: doc.Status,
+		Attempts: doc.Attempts, NextAttempt: doc.NextAttempt, LastError: doc.LastError, Created: doc.Created,
+	}, true, nil
+}
+
+func (s *MongoStore) Retry(ctx context.Context, id string, attempts int, next time.Time, lastErr string) error {
+	_, err := s.outbox.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: bson.D{
+		{Key: "attempts", Value: attempts}, {Key: "next_attempt", Value: next}, {Key: "last_error", Value: lastErr},
+	}}})
+	return err
+}
+
+func (s *MongoStore) Finish(ctx context.Context, id, status string, attempts int, lastErr string) error {
+	_, err := s.outbox.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: status}, {Key: "attempts", Value: attempts}, {Key: "last_error", Value: lastErr},
+	}}})
+	return err
+}
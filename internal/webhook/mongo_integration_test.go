@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func connectTestMongo(t *testing.T) (*mongo.Client, func()) {
+	t.Helper()
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		uri = "mongodb://localhost:27017"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	cli, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Skipf("skipping: cannot connect to mongo: %v", err)
+	}
+	if err := cli.Ping(ctx, nil); err != nil {
+		_ = cli.Disconnect(context.Background())
+		t.Skipf("skipping: mongo ping failed: %v", err)
+	}
+	cleanup := func() { _ = cli.Disconnect(context.Background()) }
+	return cli, cleanup
+}
+
+func TestMongoStore_CreateLimit(t *testing.T) {
+	cli, done := connectTestMongo(t)
+	defer done()
+	ctx := context.Background()
+	store, err := NewMongoStore(ctx, cli, "solapi_test")
+	if err != nil { t.Fatalf("new store: %v", err) }
+	_ = store.hooks.Drop(ctx)
+	_ = store.counts.Drop(ctx)
+	// a webhook from before counting began still counts
+	if _, err := store.hooks.InsertOne(ctx, toDoc(Webhook{ID: "old", Owner: "o"})); err != nil { t.Fatalf("seed: %v", err) }
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = store.Create(ctx, Webhook{ID: fmt.Sprintf("w%d", i), Owner: "o"}, 3)
+		}()
+	}
+	wg.Wait()
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, ErrLimit):
+			t.Fatalf("create: %v", err)
+		}
+	}
+	if hooks, _ := store.List(ctx, "o"); created != 2 || len(hooks) != 3 { t.Fatalf("created=%d hooks=%d, want 2 and 3", created, len(hooks)) }
+
+	// deleting frees a slot
+	if err := store.Delete(ctx, "old"); err != nil { t.Fatalf("delete: %v", err) }
+	if err := store.Create(ctx, Webhook{ID: "again", Owner: "o"}, 3); err != nil { t.Fatalf("create after delete: %v", err) }
+	if err := store.Create(ctx, Webhook{ID: "over", Owner: "o"}, 3); !errors.Is(err, ErrLimit) { t.Fatalf("over limit err=%v", err) }
+}
@@ -0,0 +1,192 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+)
+
+// SenderOptions tune delivery. Zero values take the defaults noted.
+type SenderOptions struct {
+	// MaxAttempts per delivery before it is marked failed (default 8).
+	MaxAttempts int
+	// BaseDelay and MaxDelay bound the jittered exponential backoff between
+	// attempts (defaults 5s and 1h).
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Lease hides a claimed delivery from other senders while it is being
+	// attempted (default 1m); it must exceed Timeout.
+	Lease time.Duration
+	// Interval is how often the outbox is polled once drained (default 1s).
+	Interval time.Duration
+	// Timeout bounds one POST (default 10s).
+	Timeout time.Duration
+}
+
+// Sender POSTs outbox deliveries to their webhooks. A 2xx response
+// completes a delivery; anything else is retried with backoff until
+// MaxAttempts. Deliveries for deleted webhooks are failed without sending,
+// and connections to loopback, private and link-local addresses are refused.
+type Sender struct {
+	store  Store
+	outbox Outbox
+	opts   SenderOptions
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewSender(store Store, outbox Outbox, opts SenderOptions) *Sender {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = 5 * time.Second
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = time.Hour
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.Lease <= opts.Timeout {
+		opts.Lease = max(time.Minute, 2*opts.Timeout)
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	return &Sender{
+		store:  store,
+		outbox: outbox,
+		opts:   opts,
+		client: &http.Client{
+			Timeout: opts.Timeout,
+			// no proxy, so the dial below is always to the webhook's own host
+			Transport: &http.Transport{
+				DialContext:         (&net.Dialer{Timeout: opts.Timeout, Control: dialControl}).DialContext,
+				TLSHandshakeTimeout: opts.Timeout,
+				MaxIdleConnsPerHost: 2,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			// a redirect could point deliveries somewhere the owner never registered
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		now: time.Now,
+	}
+}
+
+// Run delivers due deliveries until ctx is cancelled.
+func (s *Sender) Run(ctx context.Context) {
+	t := time.NewTicker(s.opts.Interval)
+	defer t.Stop()
+	for {
+		for s.next(ctx) {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// next attempts one due delivery and reports whether there was one.
+func (s *Sender) next(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	d, ok, err := s.outbox.Claim(ctx, s.now(), s.opts.Lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("event=webhook_outbox_error err=%v", err)
+		}
+		return false
+	}
+	if !ok {
+		return false
+	}
+	s.attempt(ctx, d)
+	return true
+}
+
+func (s *Sender) attempt(ctx context.Context, d Delivery) {
+	attempts := d.Attempts + 1
+	w, err := s.store.Get(ctx, d.WebhookID)
+	if errors.Is(err, ErrNotFound) {
+		s.finish(ctx, d, StatusFailed, d.Attempts, "webhook deleted")
+		return
+	}
+	if err != nil {
+		// the store is unavailable; try again later without using an attempt
+		s.retry(ctx, d, d.Attempts, err.Error())
+		return
+	}
+	start := s.now()
+	err = s.post(ctx, w, d)
+	if err == nil {
+		log.Printf("event=webhook_delivery id=%s webhook=%s attempt=%d status=delivered dur_ms=%d", d.ID, w.ID, attempts, time.Since(start).Milliseconds())
+		s.finish(ctx, d, StatusDelivered, attempts, "")
+		return
+	}
+	if ctx.Err() != nil {
+		// shutting down; the lease expires and the attempt is repeated
+		return
+	}
+	if attempts >= s.opts.MaxAttempts {
+		log.Printf("event=webhook_delivery id=%s webhook=%s attempt=%d status=failed err=%q", d.ID, w.ID, attempts, err)
+		s.finish(ctx, d, StatusFailed, attempts, err.Error())
+		return
+	}
+	log.Printf("event=webhook_delivery id=%s webhook=%s attempt=%d status=retry err=%q", d.ID, w.ID, attempts, err)
+	s.retry(ctx, d, attempts, err.Error())
+}
+
+func (s *Sender) post(ctx context.Context, w Webhook, d Delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "solapi-webhooks/1")
+	req.Header.Set("X-Webhook-ID", d.ID)
+	req.Header.Set(SignatureHeader, Sign(w.Secret, s.now(), d.Payload))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *Sender) retry(ctx context.Context, d Delivery, attempts int, lastErr string) {
+	next := s.now().Add(s.backoff(max(attempts, 1)))
+	if err := s.outbox.Retry(ctx, d.ID, attempts, next, lastErr); err != nil {
+		log.Printf("event=webhook_outbox_error id=%s err=%v", d.ID, err)
+	}
+}
+
+func (s *Sender) finish(ctx context.Context, d Delivery, status string, attempts int, lastErr string) {
+	if err := s.outbox.Finish(ctx, d.ID, status, attempts, lastErr); err != nil {
+		log.Printf("event=webhook_outbox_error id=%s err=%v", d.ID, err)
+	}
+}
+
+// backoff returns a delay drawn uniformly from (0, min(MaxDelay,
+// BaseDelay*2^(attempt-1))], as for upstream retries.
+func (s *Sender) backoff(attempt int) time.Duration {
+	ceiling := s.opts.MaxDelay
+	if shift := attempt - 1; shift < 30 && s.opts.BaseDelay<<shift < ceiling {
+		ceiling = s.opts.BaseDelay << shift
+	}
+	return rand.N(ceiling) + 1
+}
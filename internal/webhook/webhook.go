@@ -0,0 +1,130 @@
+// Package webhook stores balance-change webhooks and delivers their events
+// through a persistent outbox.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound is returned for an unknown webhook id.
+var ErrNotFound = errors.New("webhook: not found")
+
+// ErrLimit is returned by Store.Create when the owner already has as many
+// webhooks as allowed.
+var ErrLimit = errors.New("webhook: limit reached")
+
+// Webhook is a URL notified of balance changes on Wallets. With no
+// thresholds every change fires; otherwise a change fires when it meets any
+// of them.
+type Webhook struct {
+	ID    string
+	Owner string // auth.Owner of the API key that registered it
+	URL   string
+	// Secret signs deliveries; see Sign.
+	Secret   string
+	Wallets  []string
+	MinDelta uint64
+	Floor    *uint64
+	Created  time.Time
+}
+
+// Fires reports whether a change from prev to cur lamports meets w's thresholds.
+func (w Webhook) Fires(prev, cur uint64) bool {
+	if prev == cur {
+		return false
+	}
+	if w.MinDelta == 0 && w.Floor == nil {
+		return true
+	}
+	delta := cur - prev
+	if cur < prev {
+		delta = prev - cur
+	}
+	if w.MinDelta > 0 && delta >= w.MinDelta {
+		return true
+	}
+	return w.Floor != nil && prev >= *w.Floor && cur < *w.Floor
+}
+
+// Delivery states.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// Delivery is one event bound for a webhook. Payload is the JSON body; it is
+// fixed when the delivery is created so retries send identical bytes.
+type Delivery struct {
+	ID          string
+	WebhookID   string
+	Payload     []byte
+	Status      string
+	Attempts    int
+	NextAttempt time.Time
+	LastError   string
+	Created     time.Time
+}
+
+// Store persists webhooks.
+type Store interface {
+	// Create stores a new webhook. With limit > 0 it returns ErrLimit,
+	// storing nothing, if w.Owner already has limit webhooks; the check and
+	// the insert cannot be interleaved by concurrent creates.
+	Create(ctx context.Context, w Webhook, limit int) error
+	Get(ctx context.Context, id string) (Webhook, error)
+	List(ctx context.Context, owner string) ([]Webhook, error)
+	// Update replaces a webhook's URL, wallets and thresholds.
+	Update(ctx context.Context, w Webhook) error
+	Delete(ctx context.Context, id string) error
+	// All returns every webhook, for matching balance changes.
+	All(ctx context.Context) ([]Webhook, error)
+}
+
+// Outbox persists deliveries until they succeed or run out of attempts, so
+// none are lost across restarts.
+type Outbox interface {
+	Enqueue(ctx context.Context, d Delivery) error
+	// Claim takes the pending delivery due earliest at or before now and
+	// hides it from other claims for lease. ok is false when none is due.
+	Claim(ctx context.Context, now time.Time, lease time.Duration) (d Delivery, ok bool, err error)
+	// Retry records a failed attempt and schedules the next one.
+	Retry(ctx context.Context, id string, attempts int, next time.Time, lastErr string) error
+	// Finish records the final attempt with StatusDelivered or StatusFailed.
+	Finish(ctx context.Context, id, status string, attempts int, lastErr string) error
+}
+
+// NewID returns a random identifier for webhooks and deliveries.
+func NewID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() string {
+	var b [32]byte
+	_, _ = rand.Read(b[:])
+	return "whsec_" + hex.EncodeToString(b[:])
+}
+
+// SignatureHeader carries a delivery's signature.
+const SignatureHeader = "X-Webhook-Signature"
+
+// Sign returns the SignatureHeader value for body sent at ts:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">". Receivers
+// recompute the HMAC with their secret and reject stale timestamps.
+func Sign(secret string, ts time.Time, body []byte) string {
+	t := ts.Unix()
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", t)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", t, hex.EncodeToString(mac.Sum(nil)))
+}
@@ -0,0 +1,282 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memStore is an in-memory Store and Outbox.
+type memStore struct {
+	mu         sync.Mutex
+	hooks      map[string]Webhook
+	deliveries map[string]Delivery
+}
+
+func newMemStore() *memStore {
+	return &memStore{hooks: make(map[string]Webhook), deliveries: make(map[string]Delivery)}
+}
+
+func (m *memStore) Create(_ context.Context, w Webhook, _ int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks[w.ID] = w
+	return nil
+}
+
+func (m *memStore) Get(_ context.Context, id string) (Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.hooks[id]
+	if !ok {
+		return Webhook{}, ErrNotFound
+	}
+	return w, nil
+}
+
+func (m *memStore) List(context.Context, string) ([]Webhook, error) { return nil, nil }
+func (m *memStore) Update(context.Context, Webhook) error           { return nil }
+func (m *memStore) All(context.Context) ([]Webhook, error)          { return nil, nil }
+
+func (m *memStore) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.hooks, id)
+	return nil
+}
+
+func (m *memStore) Enqueue(_ context.Context, d Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries[d.ID] = d
+	return nil
+}
+
+func (m *memStore) Claim(_ context.Context, now time.Time, lease time.Duration) (Delivery, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due *Delivery
+	for _, d := range m.deliveries {
+		if d.Status == StatusPending && !d.NextAttempt.After(now) && (due == nil || d.NextAttempt.Before(due.NextAttempt)) {
+			d := d
+			due = &d
+		}
+	}
+	if due == nil {
+		return Delivery{}, false, nil
+	}
+	due.NextAttempt = now.Add(lease)
+	m.deliveries[due.ID] = *due
+	return *due, true, nil
+}
+
+func (m *memStore) Retry(_ context.Context, id string, attempts int, next time.Time, lastErr string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d := m.deliveries[id]
+	d.Attempts, d.NextAttempt, d.LastError = attempts, next, lastErr
+	m.deliveries[id] = d
+	return nil
+}
+
+func (m *memStore) Finish(_ context.Context, id, status string, attempts int, lastErr string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d := m.deliveries[id]
+	d.Status, d.Attempts, d.LastError = status, attempts, lastErr
+	m.deliveries[id] = d
+	return nil
+}
+
+func (m *memStore) delivery(id string) Delivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.deliveries[id]
+}
+
+func TestFires(t *testing.T) {
+	floor := uint64(1_000)
+	cases := []struct {
+		name      string
+		w         Webhook
+		prev, cur uint64
+		want      bool
+	}{
+		{"any change", Webhook{}, 10, 11, true},
+		{"no change", Webhook{}, 10, 10, false},
+		{"delta met up", Webhook{MinDelta: 100}, 10, 110, true},
+		{"delta met down", Webhook{MinDelta: 100}, 110, 10, true},
+		{"delta missed", Webhook{MinDelta: 100}, 10, 109, false},
+		{"floor crossed", Webhook{Floor: &floor}, 1_000, 999, true},
+		{"already below floor", Webhook{Floor: &floor}, 999, 500, false},
+		{"rising through floor", Webhook{Floor: &floor}, 500, 1_500, false},
+		{"either threshold", Webhook{MinDelta: 1_000_000, Floor: &floor}, 1_200, 900, true},
+	}
+	for _, c := range cases {
+		if got := c.w.Fires(c.prev, c.cur); got != c.want {
+			t.Errorf("%s: Fires(%d, %d) = %v, want %v", c.name, c.prev, c.cur, got, c.want)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	got := Sign("secret", time.Unix(1_700_000_000, 0), body)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000." + string(body)))
+	if want := "t=1700000000,v1=" + hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Fatalf("Sign = %s, want %s", got, want)
+	}
+}
+
+func newTestSender(store *memStore, maxAttempts int) *Sender {
+	s := NewSender(store, store, SenderOptions{MaxAttempts: maxAttempts, BaseDelay: time.Minute, MaxDelay: time.Minute, Timeout: time.Second})
+	// test servers listen on loopback, which the default dialer refuses
+	s.client.Transport = http.DefaultTransport
+	return s
+}
+
+func TestSenderDeliversSignedPayload(t *testing.T) {
+	type received struct {
+		sig, id string
+		body    []byte
+	}
+	got := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{sig: r.Header.Get(SignatureHeader), id: r.Header.Get("X-Webhook-ID"), body: body}
+	}))
+	defer srv.Close()
+
+	store := newMemStore()
+	_ = store.Create(context.Background(), Webhook{ID: "w1", URL: srv.URL, Secret: "s3cret"}, 0)
+	_ = store.Enqueue(context.Background(), Delivery{ID: "d1", WebhookID: "w1", Payload: []byte(`{"x":1}`), Status: StatusPending})
+	s := newTestSender(store, 3)
+	if !s.next(context.Background()) {
+		t.Fatal("no delivery claimed")
+	}
+	r := <-got
+	if r.id != "d1" || string(r.body) != `{"x":1}` {
+		t.Fatalf("received id=%s body=%s", r.id, r.body)
+	}
+	ts := strings.TrimPrefix(strings.Split(r.sig, ",")[0], "t=")
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(ts + "." + string(r.body)))
+	if !strings.HasSuffix(r.sig, ",v1="+hex.EncodeToString(mac.Sum(nil))) {
+		t.Fatalf("signature %s does not verify", r.sig)
+	}
+	if d := store.delivery("d1"); d.Status != StatusDelivered || d.Attempts != 1 {
+		t.Fatalf("delivery = %+v", d)
+	}
+	if s.next(context.Background()) {
+		t.Fatal("delivered delivery claimed again")
+	}
+}
+
+func TestSenderRetriesThenFails(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	store := newMemStore()
+	_ = store.Create(context.Background(), Webhook{ID: "w1", URL: srv.URL}, 0)
+	_ = store.Enqueue(context.Background(), Delivery{ID: "d1", WebhookID: "w1", Payload: []byte(`{}`), Status: StatusPending})
+	s := newTestSender(store, 3)
+	s.next(context.Background())
+	if d := store.delivery("d1"); d.Status != StatusPending || d.Attempts != 1 || d.LastError != "HTTP 503" {
+		t.Fatalf("after first attempt: %+v", d)
+	}
+	if s.next(context.Background()) {
+		t.Fatal("delivery claimed before its backoff elapsed")
+	}
+	// each later attempt happens an hour on
+	clock := time.Now()
+	s.now = func() time.Time { clock = clock.Add(time.Hour); return clock }
+	s.next(context.Background())
+	s.next(context.Background())
+	if d := store.delivery("d1"); d.Status != StatusFailed || d.Attempts != 3 {
+		t.Fatalf("after max attempts: %+v", d)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("calls = %d, want 3", calls.Load())
+	}
+}
+
+func TestSenderDropsDeliveriesOfDeletedWebhooks(t *testing.T) {
+	store := newMemStore()
+	_ = store.Enqueue(context.Background(), Delivery{ID: "d1", WebhookID: "gone", Payload: []byte(`{}`), Status: StatusPending})
+	newTestSender(store, 3).next(context.Background())
+	if d := store.delivery("d1"); d.Status != StatusFailed || d.Attempts != 0 {
+		t.Fatalf("delivery = %+v", d)
+	}
+}
+
+func TestSenderDoesNotFollowRedirects(t *testing.T) {
+	var hit atomic.Bool
+	target := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { hit.Store(true) }))
+	defer target.Close()
+	srv := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer srv.Close()
+
+	store := newMemStore()
+	_ = store.Create(context.Background(), Webhook{ID: "w1", URL: srv.URL}, 0)
+	_ = store.Enqueue(context.Background(), Delivery{ID: "d1", WebhookID: "w1", Payload: []byte(`{}`), Status: StatusPending})
+	newTestSender(store, 1).next(context.Background())
+	if hit.Load() {
+		t.Fatal("redirect was followed")
+	}
+	if d := store.delivery("d1"); d.Status != StatusFailed || d.LastError != "HTTP 307" {
+		t.Fatalf("delivery = %+v", d)
+	}
+}
+
+func TestSenderRefusesPrivateDestinations(t *testing.T) {
+	var hit atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { hit.Store(true) }))
+	defer srv.Close()
+
+	store := newMemStore()
+	_ = store.Create(context.Background(), Webhook{ID: "w1", URL: srv.URL}, 0)
+	_ = store.Enqueue(context.Background(), Delivery{ID: "d1", WebhookID: "w1", Payload: []byte(`{}`), Status: StatusPending})
+	NewSender(store, store, SenderOptions{MaxAttempts: 1, Timeout: time.Second}).next(context.Background())
+	if hit.Load() {
+		t.Fatal("delivered to a loopback address")
+	}
+	if d := store.delivery("d1"); d.Status != StatusFailed || !strings.Contains(d.LastError, ErrPrivateDestination.Error()) {
+		t.Fatalf("delivery = %+v", d)
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	for raw, want := range map[string]error{
+		"https://example.com/hook":  nil,
+		"http://93.184.215.14:8080": nil,
+		"ftp://example.com":         ErrInvalidURL,
+		"/relative":                 ErrInvalidURL,
+		"http://127.0.0.1":          ErrPrivateDestination,
+		"http://localhost:8080":     ErrPrivateDestination,
+		"http://10.1.2.3":           ErrPrivateDestination,
+		"http://192.168.0.1":        ErrPrivateDestination,
+		"http://169.254.169.254":    ErrPrivateDestination,
+		"http://0.0.0.0":            ErrPrivateDestination,
+		"http://[::1]:80":           ErrPrivateDestination,
+		"http://[fe80::1]":          ErrPrivateDestination,
+		"http://[::ffff:127.0.0.1]": ErrPrivateDestination,
+	} {
+		if err := CheckURL(raw); err != want {
+			t.Errorf("CheckURL(%q) = %v, want %v", raw, err, want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Package amount converts integer base units, such as lamports or raw token
+// amounts, to decimal amounts without going through binary floating point.
+package amount
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// SolDecimals is the number of decimal places in one SOL (1e9 lamports).
+const SolDecimals = 9
+
+// Format renders raw base units as an exact decimal with up to decimals
+// fractional digits and no trailing zeros, e.g. Format(1500000, 6) is "1.5".
+func Format(raw uint64, decimals uint8) string {
+	s, _ := FormatString(strconv.FormatUint(raw, 10), decimals)
+	return s
+}
+
+// FormatInt is Format for signed amounts such as balance deltas.
+func FormatInt(raw int64, decimals uint8) string {
+	if raw < 0 {
+		// via uint64 so math.MinInt64 negates cleanly
+		return "-" + Format(uint64(-(raw+1))+1, decimals)
+	}
+	return Format(uint64(raw), decimals)
+}
+
+// FormatString is Format for a base-10 integer of any length, as token
+// amounts are reported by the node.
+func FormatString(raw string, decimals uint8) (string, error) {
+	if raw == "" || strings.Trim(raw, "0123456789") != "" {
+		return "", errors.New("amount: not an unsigned integer: " + strconv.Quote(raw))
+	}
+	d := int(decimals)
+	if len(raw) <= d {
+		raw = strings.Repeat("0", d-len(raw)+1) + raw
+	}
+	whole := strings.TrimLeft(raw[:len(raw)-d], "0")
+	if whole == "" {
+		whole = "0"
+	}
+	frac := strings.TrimRight(raw[len(raw)-d:], "0")
+	if frac == "" {
+		return whole, nil
+	}
+	return whole + "." + frac, nil
+}
+
+// Float returns the float64 nearest to the exact amount. Large amounts
+// cannot be represented exactly; use Format where that matters.
+func Float(raw uint64, decimals uint8) float64 {
+	f, _ := strconv.ParseFloat(Format(raw, decimals), 64)
+	return f
+}
+
+// FloatInt is Float for signed amounts.
+func FloatInt(raw int64, decimals uint8) float64 {
+	f, _ := strconv.ParseFloat(FormatInt(raw, decimals), 64)
+	return f
+}
@@ -0,0 +1,46 @@
+package amount
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		raw      uint64
+		decimals uint8
+		want     string
+	}{
+		{0, 9, "0"},
+		{1, 9, "0.000000001"},
+		{1_500_000_000, 9, "1.5"},
+		{2_000_000_000, 9, "2"},
+		{1_500_000, 6, "1.5"},
+		{42, 0, "42"},
+		{math.MaxUint64, 9, "18446744073.709551615"},
+	}
+	for _, c := range cases {
+		if got := Format(c.raw, c.decimals); got != c.want { t.Fatalf("Format(%d,%d)=%q want %q", c.raw, c.decimals, got, c.want) }
+	}
+}
+
+func TestFormatInt(t *testing.T) {
+	if got := FormatInt(-500_005_000, 9); got != "-0.500005" { t.Fatalf("got %q", got) }
+	if got := FormatInt(math.MinInt64, 9); got != "-9223372036.854775808" { t.Fatalf("got %q", got) }
+	if got := FormatInt(7, 2); got != "0.07" { t.Fatalf("got %q", got) }
+}
+
+func TestFormatString(t *testing.T) {
+	if got, err := FormatString("123456789012345678901234567890", 18); err != nil || got != "123456789012.34567890123456789" { t.Fatalf("got %q err=%v", got, err) }
+	if got, err := FormatString("000100", 2); err != nil || got != "1" { t.Fatalf("got %q err=%v", got, err) }
+	for _, bad := range []string{"", "-1", "1.5", "12a"} {
+		if _, err := FormatString(bad, 2); err == nil { t.Fatalf("FormatString(%q) accepted", bad) }
+	}
+}
+
+func TestFloat(t *testing.T) {
+	if got := Float(1_234_567_891, 9); got != 1.234567891 { t.Fatalf("got %v", got) }
+	if got := FloatInt(-500_005_000, 9); got != -0.500005 { t.Fatalf("got %v", got) }
+	// dividing then rounding gives 1.8000000000000004e10 here
+	if got := Float(18_000_000_000_000_001_092, 9); got != 1.8e10 { t.Fatalf("got %v", got) }
+}
@@ -0,0 +1,102 @@
+// Package redact scrubs upstream URLs and credentials from text bound for
+// logs or clients.
+package redact
+
+import (
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const mask = "REDACTED"
+
+var (
+	urlRE = regexp.MustCompile(`\b(?:https?|wss?)://[^\s"'<>\\]+`)
+	// credential-looking query or header style pairs outside of URLs
+	paramRE = regexp.MustCompile(`(?i)\b((?:api[-_]?key|access[-_]?token|token|secret|password|auth)=)[^&\s"'\\]+`)
+)
+
+var (
+	mu      sync.RWMutex
+	secrets []string
+)
+
+// AddSecret registers a literal, such as a configured RPC URL or its API
+// key, to be masked wherever it appears. Empty strings are ignored.
+func AddSecret(s string) {
+	if s == "" {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	secrets = append(secrets, s)
+	// longest first, so a URL is masked whole before its key is
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+}
+
+// minSecretLen keeps AddURL from registering short path parts like "v1".
+const minSecretLen = 8
+
+// AddURL registers the parts of a configured URL that can carry a key: the
+// userinfo password, path segments and query values. The host stays readable.
+func AddURL(raw string) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return
+	}
+	var parts []string
+	if pw, ok := u.User.Password(); ok {
+		parts = append(parts, pw)
+	}
+	parts = append(parts, strings.Split(u.Path, "/")...)
+	for _, vs := range u.Query() {
+		parts = append(parts, vs...)
+	}
+	for _, p := range parts {
+		if len(p) >= minSecretLen {
+			AddSecret(p)
+		}
+	}
+}
+
+// String masks registered secrets, reduces URLs to scheme and host, and
+// masks credential parameters.
+func String(s string) string {
+	mu.RLock()
+	for _, sec := range secrets {
+		s = strings.ReplaceAll(s, sec, mask)
+	}
+	mu.RUnlock()
+	s = urlRE.ReplaceAllStringFunc(s, stripURL)
+	return paramRE.ReplaceAllString(s, "${1}"+mask)
+}
+
+// stripURL keeps scheme and host; path, query and userinfo often carry keys.
+func stripURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return mask
+	}
+	out := u.Scheme + "://" + u.Host
+	if u.User != nil || strings.Trim(u.Path, "/") != "" || u.RawQuery != "" || u.Fragment != "" {
+		out += "/" + mask
+	}
+	return out
+}
+
+// Writer redacts everything written through it, e.g. as log output.
+type Writer struct {
+	w io.Writer
+}
+
+func NewWriter(w io.Writer) *Writer { return &Writer{w: w} }
+
+func (rw *Writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(rw.w, String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
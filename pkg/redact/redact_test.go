@@ -0,0 +1,41 @@
+package redact
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestString_StripsURLs(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{`Post "https://mainnet.helius-rpc.com/?api-key=abc123": EOF`, `Post "https://mainnet.helius-rpc.com/REDACTED": EOF`},
+		{`dial https://x.rpcpool.com/tok3n failed`, `dial https://x.rpcpool.com/REDACTED failed`},
+		{`wss://user:pw@node.example/ws`, `wss://node.example/REDACTED`},
+		{`endpoint=https://api.mainnet-beta.solana.com ok`, `endpoint=https://api.mainnet-beta.solana.com ok`},
+		{`retry api-key=abc&x=1 token=zzz`, `retry api-key=REDACTED&x=1 token=REDACTED`},
+		{`err="Post \"https://h.io/?api_key=k\": timeout"`, `err="Post \"https://h.io/REDACTED\": timeout"`},
+	}
+	for _, c := range cases {
+		if got := String(c.in); got != c.want { t.Fatalf("String(%q)=%q want %q", c.in, got, c.want) }
+	}
+}
+
+func TestString_MasksRegisteredSecrets(t *testing.T) {
+	AddSecret("s3cr3t-key")
+	AddSecret("")
+	if got := String("upstream said s3cr3t-key is invalid"); got != "upstream said REDACTED is invalid" { t.Fatalf("got=%q", got) }
+}
+
+func TestWriter_RedactsLogOutput(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.New(NewWriter(&buf), "", 0)
+	l.Printf("event=rpc_endpoint_error err=%q", `Post "https://rpc.example/?api-key=abc": EOF`)
+	if out := buf.String(); strings.Contains(out, "abc") || !strings.Contains(out, "rpc.example") { t.Fatalf("out=%q", out) }
+}
+
+func TestAddURL_RegistersKeyParts(t *testing.T) {
+	AddURL("https://rpc.example/v1/path-tok-123?api-key=query-key-456")
+	got := String("node rejected query-key-456 and path-tok-123 on v1")
+	if got != "node rejected REDACTED and REDACTED on v1" { t.Fatalf("got=%q", got) }
+}
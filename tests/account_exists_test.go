@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// existenceFetcher reports funded wallets with lamports and every other
+// address as having no account, counting calls per wallet.
+type existenceFetcher struct {
+	mu     sync.Mutex
+	funded map[sol.PublicKey]uint64
+	calls  map[sol.PublicKey]int
+}
+
+func (f *existenceFetcher) GetBalance(_ context.Context, pk sol.PublicKey, _ rpc.CommitmentType) (solana.Balance, time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[pk]++
+	lamports, ok := f.funded[pk]
+	return solana.Balance{Lamports: lamports, Slot: 100, Exists: ok}, time.Millisecond, nil
+}
+
+func TestBalanceDistinguishesMissingAccounts(t *testing.T) {
+	drained, unfunded := sol.NewWallet().PublicKey(), sol.NewWallet().PublicKey()
+	f := &existenceFetcher{funded: map[sol.PublicKey]uint64{drained: 0}, calls: map[sol.PublicKey]int{}}
+	c := cache.New(time.Minute).WithMissingTTL(20 * time.Millisecond)
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{Cache: c, Fetcher: f, Timeout: time.Second, MaxConcurrency: 4})
+	ts := httptest.NewServer(apihttp.NewRouter(bh, rate.NewLimiterMap(1000, 1000, time.Minute), fakeStore{ok: true}))
+	defer ts.Close()
+
+	req := types.GetBalanceRequest{Wallets: []string{drained.String(), unfunded.String()}}
+	var out types.GetBalanceResponse
+	if code := postQuote(t, ts, "/api/get-balance", req, &out); code != http.StatusOK { t.Fatalf("status=%d", code) }
+	if len(out.Balances) != 2 { t.Fatalf("resp=%+v", out) }
+	for _, be := range out.Balances {
+		if be.Lamports != 0 { t.Fatalf("lamports=%d", be.Lamports) }
+		if want := be.Wallet == drained.String(); be.Exists != want { t.Fatalf("%s exists=%v want %v", be.Wallet, be.Exists, want) }
+	}
+
+	// the negative result expires first; the drained wallet stays cached
+	time.Sleep(30 * time.Millisecond)
+	out = types.GetBalanceResponse{}
+	postQuote(t, ts, "/api/get-balance", req, &out)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.calls[drained] != 1 || f.calls[unfunded] != 2 { t.Fatalf("calls drained=%d unfunded=%d", f.calls[drained], f.calls[unfunded]) }
+}
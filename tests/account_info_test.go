@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// fakeAccountFetcher reports every address in missing as nonexistent and
+// every other one as a 165-byte token account holding lamports.
+type fakeAccountFetcher struct {
+	lamports uint64
+	missing  map[sol.PublicKey]bool
+	calls    int32
+}
+
+func (f *fakeAccountFetcher) GetAccountInfos(_ context.Context, pks []sol.PublicKey, _ rpc.CommitmentType) ([]solana.AccountInfo, time.Duration, error) {
+	atomic.AddInt32(&f.calls, 1)
+	out := make([]solana.AccountInfo, len(pks))
+	for i, pk := range pks {
+		out[i] = solana.AccountInfo{Slot: 100}
+		if !f.missing[pk] {
+			out[i] = solana.AccountInfo{Exists: true, Lamports: f.lamports, Owner: sol.TokenProgramID, DataLen: 165, Kind: solana.KindTokenAccount, Slot: 100}
+		}
+	}
+	return out, time.Millisecond, nil
+}
+
+func TestAccountInfoReportsMetadataAndMissingAccounts(t *testing.T) {
+	pda, _, err := sol.FindProgramAddress([][]byte{[]byte("vault")}, sol.SystemProgramID)
+	if err != nil { t.Fatalf("pda: %v", err) }
+	wallet := sol.NewWallet().PublicKey()
+	f := &fakeAccountFetcher{lamports: 2_039_280, missing: map[sol.PublicKey]bool{wallet: true}}
+	c := cache.New(10 * time.Second)
+	h := handlers.NewAccountInfoHandler(handlers.AccountInfoDeps{Cache: c, Fetcher: f, TTL: time.Minute, Timeout: time.Second, MaxConcurrency: 4})
+	ts := newAccountServer(t, h)
+
+	var out types.GetAccountInfoResponse
+	req := types.GetBalanceRequest{Wallets: []string{pda.String(), wallet.String(), "not-a-key"}}
+	if code := postQuote(t, ts, "/api/account-info", req, &out); code != http.StatusOK { t.Fatalf("status=%d", code) }
+	if len(out.Accounts) != 2 || len(out.Errors) != 1 || out.Errors[0].Code != types.CodeInvalidPubkey { t.Fatalf("resp=%+v", out) }
+	byAddr := map[string]types.AccountInfoEntry{}
+	for _, e := range out.Accounts {
+		byAddr[e.Address] = e
+	}
+
+	got := byAddr[pda.String()]
+	if !got.Exists || got.OnCurve || got.Source != "rpc" || got.Commitment != "finalized" || got.Slot != 100 { t.Fatalf("pda=%+v", got) }
+	if a := got.Account; a == nil || a.Kind != "token_account" || a.Owner != sol.TokenProgramID.String() || a.DataLength != 165 || !a.RentExempt || a.RentExemptMinimum != 2_039_280 { t.Fatalf("pda account=%+v", got.Account) }
+
+	missing := byAddr[wallet.String()]
+	if missing.Exists || missing.Account != nil || !missing.OnCurve { t.Fatalf("missing=%+v", missing) }
+
+	// the second request is served from cache, nonexistent accounts included
+	out = types.GetAccountInfoResponse{}
+	postQuote(t, ts, "/api/account-info", types.GetBalanceRequest{Wallets: []string{pda.String(), wallet.String()}}, &out)
+	if n := atomic.LoadInt32(&f.calls); n != 1 { t.Fatalf("fetcher calls=%d want 1", n) }
+	if len(out.Accounts) != 2 || out.Accounts[0].Source != "cache" || out.Accounts[1].Source != "cache" { t.Fatalf("cached=%+v", out.Accounts) }
+
+	if code := postQuote(t, ts, "/api/account-info", types.GetBalanceRequest{Wallets: []string{pda.String()}, Commitment: "recent"}, &out); code != http.StatusBadRequest {
+		t.Fatalf("invalid commitment status=%d", code)
+	}
+}
+
+func newAccountServer(t *testing.T, h http.Handler) *httptest.Server {
+	t.Helper()
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{Cache: cache.New(time.Second), Fetcher: &fakeFetcher{}, Timeout: time.Second, MaxConcurrency: 1})
+	ts := httptest.NewServer(apihttp.NewRouter(bh, rate.NewLimiterMap(1000, 1000, time.Minute), fakeStore{ok: true}, apihttp.WithRoute("/api/account-info", h)))
+	t.Cleanup(ts.Close)
+	return ts
+}
@@ -13,13 +13,15 @@ import (
 	apihttp "github.com/example/solapi/internal/http"
 	"github.com/example/solapi/internal/handlers"
 	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
 	"github.com/example/solapi/internal/types"
 	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
 )
 
 type noOpFetcher struct{}
 
-func (noOpFetcher) GetBalance(_ context.Context, _ sol.PublicKey) (uint64, time.Duration, error) { return 0, 0, nil }
+func (noOpFetcher) GetBalance(_ context.Context, _ sol.PublicKey, _ rpc.CommitmentType) (solana.Balance, time.Duration, error) { return solana.Balance{}, 0, nil }
 
 func routerWithAuth(ok bool) http.Handler {
 	c := cache.New(10 * time.Second)
@@ -14,8 +14,10 @@ import (
 	apihttp "github.com/example/solapi/internal/http"
 	"github.com/example/solapi/internal/handlers"
 	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
 	"github.com/example/solapi/internal/types"
 	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
 )
 
 type fakeStore struct{ ok bool }
@@ -30,14 +32,14 @@ type fakeFetcher struct {
 	delay    time.Duration
 }
 
-func (f *fakeFetcher) GetBalance(_ context.Context, _ sol.PublicKey) (uint64, time.Duration, error) {
+func (f *fakeFetcher) GetBalance(_ context.Context, _ sol.PublicKey, _ rpc.CommitmentType) (solana.Balance, time.Duration, error) {
 	f.mu.Lock()
 	f.calls++
 	f.mu.Unlock()
 	if f.delay > 0 {
 		time.Sleep(f.delay)
 	}
-	return f.lamports, 5 * time.Millisecond, nil
+	return solana.Balance{Lamports: f.lamports, Slot: 100, Exists: f.lamports > 0}, 5 * time.Millisecond, nil
 }
 
 func newTestServer(t *testing.T, lamports uint64, delay time.Duration) (*httptest.Server, *fakeFetcher) {
@@ -74,8 +76,9 @@ func TestSingleWalletSuccess(t *testing.T) {
 	if resp.StatusCode != http.StatusOK { t.Fatalf("status=%d", resp.StatusCode) }
 	if len(out.Balances) != 1 { t.Fatalf("balances len=%d", len(out.Balances)) }
 	if out.Balances[0].Lamports != 2_000_000_000 { t.Fatalf("lamports=%d", out.Balances[0].Lamports) }
-	if out.Balances[0].Sol != 2.0 { t.Fatalf("sol=%f", out.Balances[0].Sol) }
+	if out.Balances[0].Sol == nil || *out.Balances[0].Sol != 2.0 { t.Fatalf("sol=%v", out.Balances[0].Sol) }
 	if out.Balances[0].Source != "rpc" { t.Fatalf("source=%s", out.Balances[0].Source) }
+	if out.Balances[0].Slot != 100 { t.Fatalf("slot=%d", out.Balances[0].Slot) }
 	ff.mu.Lock(); calls := ff.calls; ff.mu.Unlock()
 	if calls != 1 { t.Fatalf("fetch calls=%d", calls) }
 }
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+type fakeBatchFetcher struct {
+	mu         sync.Mutex
+	batchCalls int
+	singleCall int
+	keys       int
+	failKey    sol.PublicKey
+	delay      time.Duration
+}
+
+func (f *fakeBatchFetcher) GetBalance(_ context.Context, _ sol.PublicKey, _ rpc.CommitmentType) (solana.Balance, time.Duration, error) {
+	f.mu.Lock(); f.singleCall++; f.mu.Unlock()
+	return solana.Balance{Lamports: 1}, 0, nil
+}
+
+func (f *fakeBatchFetcher) GetBalances(_ context.Context, pks []sol.PublicKey, _ rpc.CommitmentType) ([]solana.BalanceResult, time.Duration, error) {
+	f.mu.Lock(); f.batchCalls++; f.keys += len(pks); f.mu.Unlock()
+	time.Sleep(f.delay)
+	out := make([]solana.BalanceResult, len(pks))
+	for i, pk := range pks {
+		if pk == f.failKey {
+			out[i] = solana.BalanceResult{Err: errors.New("account decode failed")}
+			continue
+		}
+		out[i] = solana.BalanceResult{Lamports: 500_000_000, Exists: true}
+	}
+	return out, 5 * time.Millisecond, nil
+}
+
+func newBatchTestServer(t *testing.T, ff *fakeBatchFetcher) *httptest.Server {
+	t.Helper()
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{
+		Cache:          cache.New(10 * time.Second),
+		Fetcher:        ff,
+		Timeout:        3 * time.Second,
+		MaxConcurrency: 4,
+	})
+	lm := rate.NewLimiterMap(1000, 1000, time.Minute)
+	return httptest.NewServer(apihttp.NewRouter(bh, lm, fakeStore{ok: true}))
+}
+
+func TestBatchFetcherOneCallPerChunk(t *testing.T) {
+	ff := &fakeBatchFetcher{}
+	ts := newBatchTestServer(t, ff)
+	defer ts.Close()
+
+	ws := make([]string, 100)
+	for i := range ws { ws[i] = sol.NewWallet().PublicKey().String() }
+	resp, out := doPost(t, ts, ws, "dev-123")
+	if resp.StatusCode != http.StatusOK { t.Fatalf("status=%d", resp.StatusCode) }
+	if len(out.Balances) != 100 || len(out.Errors) != 0 { t.Fatalf("balances=%d errors=%d", len(out.Balances), len(out.Errors)) }
+	if out.Balances[0].Source != "rpc" || out.Balances[0].Sol == nil || *out.Balances[0].Sol != 0.5 { t.Fatalf("entry=%+v", out.Balances[0]) }
+	ff.mu.Lock(); batch, single := ff.batchCalls, ff.singleCall; ff.mu.Unlock()
+	if batch != 1 || single != 0 { t.Fatalf("batch=%d single=%d", batch, single) }
+
+	// second request is served from cache without another upstream call
+	_, out2 := doPost(t, ts, ws[:3], "dev-123")
+	if len(out2.Balances) != 3 || out2.Balances[0].Source != "cache" { t.Fatalf("second=%+v", out2) }
+	ff.mu.Lock(); batch = ff.batchCalls; ff.mu.Unlock()
+	if batch != 1 { t.Fatalf("batch calls after cache hit=%d", batch) }
+}
+
+func TestBatchFetcherPerWalletErrors(t *testing.T) {
+	bad := sol.NewWallet().PublicKey()
+	ff := &fakeBatchFetcher{failKey: bad}
+	ts := newBatchTestServer(t, ff)
+	defer ts.Close()
+
+	good := sol.NewWallet().PublicKey().String()
+	_, out := doPost(t, ts, []string{good, bad.String()}, "dev-123")
+	if len(out.Balances) != 1 || out.Balances[0].Wallet != good { t.Fatalf("balances=%+v", out.Balances) }
+	if len(out.Errors) != 1 || out.Errors[0].Wallet != bad.String() { t.Fatalf("errors=%+v", out.Errors) }
+}
+
+func TestBatchFetcherCoalescesConcurrentMisses(t *testing.T) {
+	ff := &fakeBatchFetcher{delay: 50 * time.Millisecond}
+	ts := newBatchTestServer(t, ff)
+	defer ts.Close()
+
+	ws := []string{sol.NewWallet().PublicKey().String(), sol.NewWallet().PublicKey().String(), sol.NewWallet().PublicKey().String()}
+	var wg sync.WaitGroup
+	outs := make([]types.GetBalanceResponse, 8)
+	for i := range outs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// later requests overlap the first, adding one new wallet each
+			_, outs[i] = doPost(t, ts, append([]string{sol.NewWallet().PublicKey().String()}, ws...), "dev-123")
+		}()
+	}
+	wg.Wait()
+	for i, out := range outs {
+		if len(out.Balances) != 4 || len(out.Errors) != 0 { t.Fatalf("response %d: balances=%d errors=%v", i, len(out.Balances), out.Errors) }
+	}
+	ff.mu.Lock(); keys := ff.keys; ff.mu.Unlock()
+	if keys != 8+len(ws) { t.Fatalf("upstream keys=%d, want %d: shared wallets were read more than once", keys, 8+len(ws)) }
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// switchFetcher fails while down is set.
+type switchFetcher struct {
+	down  atomic.Bool
+	calls atomic.Int32
+}
+
+func (f *switchFetcher) GetBalance(_ context.Context, _ sol.PublicKey, _ rpc.CommitmentType) (solana.Balance, time.Duration, error) {
+	f.calls.Add(1)
+	if f.down.Load() { return solana.Balance{}, 0, errors.New("upstream timeout") }
+	return solana.Balance{Lamports: 3_000_000_000, Slot: 7}, time.Millisecond, nil
+}
+
+func newBreakerTestServer(t *testing.T, ff *switchFetcher, stale bool) *httptest.Server {
+	t.Helper()
+	br := solana.NewBreaker(solana.BreakerOptions{FailureThreshold: 2, OpenTimeout: time.Hour})
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{
+		Cache: cache.New(10 * time.Millisecond), Fetcher: br.Wrap(ff), Timeout: 3 * time.Second, MaxConcurrency: 1, ServeStale: stale,
+	})
+	lm := rate.NewLimiterMap(1000, 1000, time.Minute)
+	return httptest.NewServer(apihttp.NewRouter(bh, lm, fakeStore{ok: true}, apihttp.WithHealth("rpc_breaker", func() any { return br.Status() })))
+}
+
+func breakerState(t *testing.T, ts *httptest.Server) string {
+	resp, err := http.Get(ts.URL + "/healthz")
+	if err != nil { t.Fatalf("healthz: %v", err) }
+	defer resp.Body.Close()
+	var body struct {
+		Status  string               `json:"status"`
+		Breaker solana.BreakerStatus `json:"rpc_breaker"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	if resp.StatusCode != http.StatusOK || body.Status != "ok" { t.Fatalf("healthz status=%d body=%+v", resp.StatusCode, body) }
+	return body.Breaker.State
+}
+
+func TestBreakerFailsFastWhenOpen(t *testing.T) {
+	ff := &switchFetcher{}
+	ff.down.Store(true)
+	ts := newBreakerTestServer(t, ff, false)
+	defer ts.Close()
+	if s := breakerState(t, ts); s != "closed" { t.Fatalf("state=%s", s) }
+
+	ws := []string{sol.NewWallet().PublicKey().String(), sol.NewWallet().PublicKey().String(), sol.NewWallet().PublicKey().String()}
+	_, out := doPost(t, ts, ws, "dev-123")
+	if len(out.Errors) != 3 { t.Fatalf("errors=%+v", out.Errors) }
+	if ff.calls.Load() != 2 { t.Fatalf("upstream calls=%d want 2 before opening", ff.calls.Load()) }
+	open := 0
+	for _, e := range out.Errors {
+		if e.Code == types.CodeCircuitOpen { open++ }
+	}
+	if open != 1 { t.Fatalf("want one circuit-open error, got %+v", out.Errors) }
+	if s := breakerState(t, ts); s != "open" { t.Fatalf("state=%s", s) }
+}
+
+func TestBreakerServesStale(t *testing.T) {
+	ff := &switchFetcher{}
+	ts := newBreakerTestServer(t, ff, true)
+	defer ts.Close()
+	known, unknown := "11111111111111111111111111111111", sol.NewWallet().PublicKey().String()
+	_, out := doPost(t, ts, []string{known}, "dev-123")
+	if len(out.Balances) != 1 { t.Fatalf("warmup=%+v", out) }
+
+	ff.down.Store(true)
+	time.Sleep(20 * time.Millisecond)
+	// two failures open the breaker
+	_, _ = doPost(t, ts, []string{unknown, sol.NewWallet().PublicKey().String()}, "dev-123")
+	_, out = doPost(t, ts, []string{known, unknown}, "dev-123")
+	if len(out.Balances) != 1 || out.Balances[0].Source != "stale" || out.Balances[0].Lamports != 3_000_000_000 || out.Balances[0].Slot != 7 { t.Fatalf("balances=%+v", out.Balances) }
+	if len(out.Errors) != 1 || out.Errors[0].Wallet != unknown || out.Errors[0].Code != types.CodeCircuitOpen { t.Fatalf("errors=%+v", out.Errors) }
+}
+
+func TestPoolHealthReported(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { http.Error(w, "unavailable", http.StatusServiceUnavailable) }))
+	defer down.Close()
+	pool := solana.NewPool([]solana.Endpoint{{URL: down.URL}}, "finalized", solana.PoolOptions{FailThreshold: 1, Cooldown: time.Hour})
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{Cache: cache.New(time.Second), Fetcher: pool, Timeout: 3 * time.Second, MaxConcurrency: 1})
+	lm := rate.NewLimiterMap(1000, 1000, time.Minute)
+	ts := httptest.NewServer(apihttp.NewRouter(bh, lm, fakeStore{ok: true}, apihttp.WithHealth("rpc_pool", func() any { return pool.Health() })))
+	defer ts.Close()
+	_, out := doPost(t, ts, []string{sol.NewWallet().PublicKey().String()}, "dev-123")
+	if len(out.Errors) != 1 { t.Fatalf("errors=%+v", out.Errors) }
+
+	resp, err := http.Get(ts.URL + "/healthz")
+	if err != nil { t.Fatalf("healthz: %v", err) }
+	defer resp.Body.Close()
+	var body struct {
+		Pool []solana.EndpointHealth `json:"rpc_pool"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	if len(body.Pool) != 1 || !body.Pool[0].Ejected || body.Pool[0].ErrorRate == 0 { t.Fatalf("rpc_pool=%+v", body.Pool) }
+}
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// commitmentFetcher reports a different balance per commitment level.
+type commitmentFetcher struct {
+	mu    sync.Mutex
+	calls map[rpc.CommitmentType]int
+}
+
+func (f *commitmentFetcher) GetBalance(_ context.Context, _ sol.PublicKey, cm rpc.CommitmentType) (solana.Balance, time.Duration, error) {
+	f.mu.Lock(); f.calls[cm]++; f.mu.Unlock()
+	if cm == rpc.CommitmentConfirmed { return solana.Balance{Lamports: 2_000_000_000}, time.Millisecond, nil }
+	return solana.Balance{Lamports: 1_000_000_000}, time.Millisecond, nil
+}
+
+func postCommitment(t *testing.T, ts *httptest.Server, req types.GetBalanceRequest) (int, types.GetBalanceResponse) {
+	b, _ := json.Marshal(req)
+	hr, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/get-balance", bytes.NewReader(b))
+	hr.Header.Set("X-API-Key", "dev-123")
+	resp, err := ts.Client().Do(hr)
+	if err != nil { t.Fatalf("request error: %v", err) }
+	defer resp.Body.Close()
+	var out types.GetBalanceResponse
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out
+}
+
+func TestCommitmentKeysCacheSeparately(t *testing.T) {
+	ff := &commitmentFetcher{calls: map[rpc.CommitmentType]int{}}
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{
+		Cache: cache.New(10 * time.Second), Fetcher: ff, Timeout: 3 * time.Second, MaxConcurrency: 4,
+		Commitment: rpc.CommitmentFinalized,
+	})
+	ts := httptest.NewServer(apihttp.NewRouter(bh, rate.NewLimiterMap(1000, 1000, time.Minute), fakeStore{ok: true}))
+	defer ts.Close()
+	w := []string{"11111111111111111111111111111111"}
+
+	_, out := postCommitment(t, ts, types.GetBalanceRequest{Wallets: w, Commitment: "confirmed"})
+	if len(out.Balances) != 1 || out.Balances[0].Lamports != 2_000_000_000 || out.Balances[0].Commitment != "confirmed" { t.Fatalf("confirmed=%+v", out) }
+
+	// a cached confirmed read must not answer a finalized request
+	_, out = postCommitment(t, ts, types.GetBalanceRequest{Wallets: w})
+	if out.Balances[0].Lamports != 1_000_000_000 || out.Balances[0].Source != "rpc" || out.Balances[0].Commitment != "finalized" { t.Fatalf("default=%+v", out.Balances[0]) }
+
+	_, out = postCommitment(t, ts, types.GetBalanceRequest{Wallets: w, Commitment: "confirmed"})
+	if out.Balances[0].Source != "cache" || out.Balances[0].Lamports != 2_000_000_000 { t.Fatalf("cached confirmed=%+v", out.Balances[0]) }
+	if ff.calls[rpc.CommitmentConfirmed] != 1 || ff.calls[rpc.CommitmentFinalized] != 1 { t.Fatalf("calls=%v", ff.calls) }
+
+	if status, _ := postCommitment(t, ts, types.GetBalanceRequest{Wallets: w, Commitment: "max"}); status != http.StatusBadRequest { t.Fatalf("invalid commitment status=%d", status) }
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+func newDeriveServer(t *testing.T, f *fakeAccountFetcher) *httptest.Server {
+	t.Helper()
+	aih := handlers.NewAccountInfoHandler(handlers.AccountInfoDeps{Cache: cache.New(time.Minute), Fetcher: f, TTL: time.Minute, Timeout: time.Second, MaxConcurrency: 4})
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{Cache: cache.New(time.Second), Fetcher: &fakeFetcher{}, Timeout: time.Second, MaxConcurrency: 1})
+	ts := httptest.NewServer(apihttp.NewRouter(bh, rate.NewLimiterMap(1000, 1000, time.Minute), fakeStore{ok: true},
+		apihttp.WithRoute("/api/associated-token-account", handlers.NewAssociatedTokenHandler(aih)),
+		apihttp.WithRoute("/api/find-program-address", handlers.NewProgramAddressHandler())))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func postJSON(t *testing.T, ts *httptest.Server, path string, body, out any) int {
+	t.Helper()
+	b, _ := json.Marshal(body)
+	hreq, _ := http.NewRequest(http.MethodPost, ts.URL+path, bytes.NewReader(b))
+	hreq.Header.Set("X-API-Key", "k")
+	resp, err := http.DefaultClient.Do(hreq)
+	if err != nil { t.Fatalf("request: %v", err) }
+	defer resp.Body.Close()
+	_ = json.NewDecoder(resp.Body).Decode(out)
+	return resp.StatusCode
+}
+
+func TestAssociatedTokenAccountDerivesAndChecksExistence(t *testing.T) {
+	owner, mint := sol.NewWallet().PublicKey(), sol.NewWallet().PublicKey()
+	classic, _, _ := sol.FindAssociatedTokenAddress(owner, mint)
+	t22, _, _ := solana.AssociatedTokenAddress(owner, mint, solana.Token2022ProgramID)
+	f := &fakeAccountFetcher{lamports: 2_039_280, missing: map[sol.PublicKey]bool{t22: true}}
+	ts := newDeriveServer(t, f)
+
+	var out types.AssociatedTokenAccountResponse
+	if code := postJSON(t, ts, "/api/associated-token-account", types.AssociatedTokenAccountRequest{Owner: owner.String(), Mint: mint.String()}, &out); code != http.StatusOK { t.Fatalf("status=%d", code) }
+	if out.Address != classic.String() || out.TokenProgram != sol.TokenProgramID.String() || !out.Exists || out.Account == nil || out.Account.Kind != "token_account" { t.Fatalf("classic=%+v", out) }
+
+	out = types.AssociatedTokenAccountResponse{}
+	postJSON(t, ts, "/api/associated-token-account", types.AssociatedTokenAccountRequest{Owner: owner.String(), Mint: mint.String(), TokenProgram: "spl-token-2022"}, &out)
+	if out.Address != t22.String() || out.TokenProgram != solana.Token2022ProgramID.String() || out.Exists || out.Account != nil { t.Fatalf("token-2022=%+v", out) }
+
+	var e map[string]any
+	if code := postJSON(t, ts, "/api/associated-token-account", types.AssociatedTokenAccountRequest{Owner: owner.String(), Mint: "nope"}, &e); code != http.StatusBadRequest || e["error"] != "invalid mint" { t.Fatalf("status=%d body=%v", code, e) }
+	if code := postJSON(t, ts, "/api/associated-token-account", types.AssociatedTokenAccountRequest{Owner: owner.String(), Mint: mint.String(), TokenProgram: sol.SystemProgramID.String()}, &e); code != http.StatusBadRequest { t.Fatalf("bad program status=%d", code) }
+}
+
+func TestFindProgramAddressDecodesSeeds(t *testing.T) {
+	ts := newDeriveServer(t, &fakeAccountFetcher{})
+	program, owner := sol.TokenProgramID, sol.NewWallet().PublicKey()
+	want, wantBump, _ := sol.FindProgramAddress([][]byte{[]byte("vault"), owner[:], {0xde, 0xad}}, program)
+
+	var out types.FindProgramAddressResponse
+	req := types.FindProgramAddressRequest{ProgramID: program.String(), Seeds: []types.ProgramAddressSeed{
+		{Value: "vault"},
+		{Value: owner.String(), Encoding: "base58"},
+		{Value: "dead", Encoding: "hex"},
+	}}
+	if code := postJSON(t, ts, "/api/find-program-address", req, &out); code != http.StatusOK { t.Fatalf("status=%d", code) }
+	if out.Address != want.String() || out.Bump != wantBump || out.ProgramID != program.String() { t.Fatalf("resp=%+v want %s/%d", out, want, wantBump) }
+
+	for _, seeds := range [][]types.ProgramAddressSeed{
+		{{Value: "zz", Encoding: "hex"}},
+		{{Value: "0OIl", Encoding: "base58"}},
+		{{Value: "x", Encoding: "base64"}},
+		{{Value: "this seed is longer than thirty-two bytes"}},
+		make([]types.ProgramAddressSeed, 16),
+	} {
+		var e map[string]any
+		if code := postJSON(t, ts, "/api/find-program-address", types.FindProgramAddressRequest{ProgramID: program.String(), Seeds: seeds}, &e); code != http.StatusBadRequest { t.Fatalf("seeds=%v status=%d body=%v", seeds, code, e) }
+	}
+}
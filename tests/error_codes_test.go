@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+const leakyURL = "https://mainnet.helius-rpc.com/?api-key=secret-123"
+
+type errFetcher struct{ err error }
+
+func (f errFetcher) GetBalance(_ context.Context, _ sol.PublicKey, _ rpc.CommitmentType) (solana.Balance, time.Duration, error) {
+	return solana.Balance{}, 0, f.err
+}
+
+func TestErrorEntryCodes(t *testing.T) {
+	wrap := func(err error) error {
+		return fmt.Errorf("rpc call getBalance(): %w", &url.Error{Op: "Post", URL: leakyURL, Err: err})
+	}
+	cases := []struct {
+		err       error
+		code      string
+		retryable bool
+	}{
+		{wrap(context.DeadlineExceeded), types.CodeUpstreamTimeout, true},
+		{wrap(&solana.RateLimitedError{RetryAfter: time.Second}), types.CodeUpstreamRateLimited, true},
+		{wrap(syscall.ECONNREFUSED), types.CodeUpstreamUnavailable, true},
+		{jsonrpc.NewHTTPError(502, errors.New(leakyURL)), types.CodeUpstreamUnavailable, true},
+		{solana.ErrCircuitOpen, types.CodeCircuitOpen, true},
+		{&jsonrpc.RPCError{Code: -32602, Message: "invalid param " + leakyURL}, types.CodeInternal, false},
+	}
+	for _, c := range cases {
+		bh := handlers.NewBalanceHandler(handlers.BalanceDeps{Cache: cache.New(time.Second), Fetcher: errFetcher{c.err}, Timeout: time.Second, MaxConcurrency: 2})
+		ts := httptest.NewServer(apihttp.NewRouter(bh, rate.NewLimiterMap(1000, 1000, time.Minute), fakeStore{ok: true}))
+		_, out := doPost(t, ts, []string{"11111111111111111111111111111111", "nope"}, "dev-123")
+		ts.Close()
+		if len(out.Errors) != 2 { t.Fatalf("%v: errors=%+v", c.err, out.Errors) }
+		for _, e := range out.Errors {
+			if strings.Contains(e.Error, "helius") || strings.Contains(e.Error, "secret") { t.Fatalf("message leaks upstream: %+v", e) }
+			if e.Wallet == "nope" {
+				if e.Code != types.CodeInvalidPubkey || e.Retryable { t.Fatalf("invalid key entry=%+v", e) }
+				continue
+			}
+			if e.Code != c.code || e.Retryable != c.retryable || e.Error == "" { t.Fatalf("%v: entry=%+v want code=%s retryable=%v", c.err, e, c.code, c.retryable) }
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/pricing"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// fixedPrices prices the pairs it holds, published at the given time.
+type fixedPrices map[string]pricing.Quote
+
+func (f fixedPrices) Name() string { return "fixed" }
+
+func (f fixedPrices) Price(_ context.Context, asset, currency string) (pricing.Quote, error) {
+	q, ok := f[asset+"/"+currency]
+	if !ok { return pricing.Quote{}, pricing.ErrNoPrice }
+	q.Asset, q.Currency = asset, currency
+	return q, nil
+}
+
+func newQuoteServer(t *testing.T, prices fixedPrices) *httptest.Server {
+	t.Helper()
+	c := cache.New(10 * time.Second)
+	pricer := pricing.NewPricer(prices, time.Minute, time.Minute)
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{Cache: c, Fetcher: &fakeFetcher{lamports: 2_500_000_000}, Timeout: time.Second, MaxConcurrency: 4, Prices: pricer})
+	th := handlers.NewTokenBalanceHandler(handlers.TokenDeps{Cache: c, Fetcher: &fakeTokenFetcher{}, TTL: time.Minute, Timeout: time.Second, MaxConcurrency: 4, Prices: pricer})
+	ts := httptest.NewServer(apihttp.NewRouter(bh, rate.NewLimiterMap(1000, 1000, time.Minute), fakeStore{ok: true}, apihttp.WithRoute("/api/get-token-balances", th)))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func postQuote(t *testing.T, ts *httptest.Server, path string, req types.GetBalanceRequest, out any) int {
+	t.Helper()
+	b, _ := json.Marshal(req)
+	hreq, _ := http.NewRequest(http.MethodPost, ts.URL+path, bytes.NewReader(b))
+	hreq.Header.Set("X-API-Key", "k")
+	resp, err := http.DefaultClient.Do(hreq)
+	if err != nil { t.Fatalf("request: %v", err) }
+	defer resp.Body.Close()
+	_ = json.NewDecoder(resp.Body).Decode(out)
+	return resp.StatusCode
+}
+
+func TestBalanceQuoteAddsFiatValue(t *testing.T) {
+	published := time.Now().Add(-10 * time.Second).UTC()
+	ts := newQuoteServer(t, fixedPrices{
+		"SOL/USD": {Price: 150, PublishedAt: published},
+		"SOL/EUR": {Price: 140, PublishedAt: time.Now().Add(-time.Hour)},
+	})
+	wallet := sol.NewWallet().PublicKey().String()
+
+	var out types.GetBalanceResponse
+	if code := postQuote(t, ts, "/api/get-balance", types.GetBalanceRequest{Wallets: []string{wallet}, Quote: "usd"}, &out); code != http.StatusOK {
+		t.Fatalf("status=%d", code)
+	}
+	if len(out.Balances) != 1 || out.Balances[0].Fiat == nil { t.Fatalf("resp=%+v", out) }
+	f := out.Balances[0].Fiat
+	if f.Currency != "USD" || f.Price != 150 || f.Value != 375 || f.PriceTime != published.Format(time.RFC3339) { t.Fatalf("fiat=%+v", f) }
+
+	// a stale price keeps the balance and reports the wallet
+	out = types.GetBalanceResponse{}
+	postQuote(t, ts, "/api/get-balance", types.GetBalanceRequest{Wallets: []string{wallet}, Quote: "eur"}, &out)
+	if len(out.Balances) != 1 || out.Balances[0].Fiat != nil { t.Fatalf("stale balances=%+v", out.Balances) }
+	if len(out.Errors) != 1 || out.Errors[0].Code != types.CodePriceUnavailable { t.Fatalf("stale errors=%+v", out.Errors) }
+
+	if code := postQuote(t, ts, "/api/get-balance", types.GetBalanceRequest{Wallets: []string{wallet}, Quote: "btc"}, &out); code != http.StatusBadRequest {
+		t.Fatalf("invalid quote status=%d", code)
+	}
+	out = types.GetBalanceResponse{}
+	postQuote(t, ts, "/api/get-balance", types.GetBalanceRequest{Wallets: []string{wallet}}, &out)
+	if len(out.Balances) != 1 || out.Balances[0].Fiat != nil { t.Fatalf("unquoted balances=%+v", out.Balances) }
+}
+
+func TestTokenQuoteValuesPricedMints(t *testing.T) {
+	ts := newQuoteServer(t, fixedPrices{"mint-1/USD": {Price: 2, PublishedAt: time.Now()}})
+	var out types.GetTokenBalancesResponse
+	if code := postQuote(t, ts, "/api/get-token-balances", types.GetBalanceRequest{Wallets: []string{sol.NewWallet().PublicKey().String()}, Quote: "USD"}, &out); code != http.StatusOK {
+		t.Fatalf("status=%d", code)
+	}
+	if len(out.Wallets) != 1 || len(out.Wallets[0].Tokens) != 2 { t.Fatalf("resp=%+v", out) }
+	for _, tb := range out.Wallets[0].Tokens {
+		switch tb.Mint {
+		case "mint-1":
+			if tb.Fiat == nil || tb.Fiat.Value != 3 { t.Fatalf("mint-1 fiat=%+v", tb.Fiat) }
+		default:
+			if tb.Fiat != nil { t.Fatalf("%s should be unvalued: %+v", tb.Mint, tb.Fiat) }
+		}
+	}
+}
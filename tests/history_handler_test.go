@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+type fakeHistoryFetcher struct {
+	mu    sync.Mutex
+	calls int
+	last  solana.HistoryPoint
+	final bool
+}
+
+func (f *fakeHistoryFetcher) GetBalanceAt(_ context.Context, _ sol.PublicKey, at solana.HistoryPoint) (solana.HistoricalBalance, time.Duration, error) {
+	f.mu.Lock(); f.calls++; f.last = at; f.mu.Unlock()
+	bt := time.Unix(1_700_000_000, 0).UTC()
+	return solana.HistoricalBalance{Lamports: 2_500_000_000, Slot: 123, Signature: "sig", BlockTime: &bt, Found: true, Final: f.final}, time.Millisecond, nil
+}
+
+func newHistoryTestServer(t *testing.T, hf *fakeHistoryFetcher, ttl time.Duration) *httptest.Server {
+	t.Helper()
+	c := cache.New(10 * time.Second)
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{Cache: c, Fetcher: dummyFetcher{}, Timeout: 3 * time.Second, MaxConcurrency: 16})
+	hh := handlers.NewHistoryHandler(handlers.HistoryDeps{Cache: c, Fetcher: hf, TTL: ttl, Timeout: 3 * time.Second, MaxConcurrency: 16})
+	lm := rate.NewLimiterMap(1000, 1000, time.Minute)
+	return httptest.NewServer(apihttp.NewRouter(bh, lm, fakeStore{ok: true}, apihttp.WithRoute("/api/get-historical-balance", hh)))
+}
+
+func postHistory(t *testing.T, ts *httptest.Server, body any) (int, types.GetHistoricalBalanceResponse) {
+	b, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/get-historical-balance", bytes.NewReader(b))
+	req.Header.Set("X-API-Key", "dev-123")
+	resp, err := ts.Client().Do(req)
+	if err != nil { t.Fatalf("request error: %v", err) }
+	defer resp.Body.Close()
+	var out types.GetHistoricalBalanceResponse
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out
+}
+
+func TestHistoricalBalanceByTimestamp(t *testing.T) {
+	hf := &fakeHistoryFetcher{final: true}
+	ts := newHistoryTestServer(t, hf, 10*time.Millisecond)
+	defer ts.Close()
+	w := "11111111111111111111111111111111"
+
+	status, out := postHistory(t, ts, types.GetHistoricalBalanceRequest{Wallets: []string{w}, Timestamp: "2024-01-31T23:59:59Z"})
+	if status != http.StatusOK { t.Fatalf("status=%d", status) }
+	if len(out.Balances) != 1 { t.Fatalf("out=%+v", out) }
+	be := out.Balances[0]
+	if be.Lamports != 2_500_000_000 || be.Sol == nil || *be.Sol != 2.5 || be.Slot != 123 || be.Signature != "sig" || be.BlockTime == "" || be.Source != "rpc" { t.Fatalf("entry=%+v", be) }
+	if !hf.last.Time.Equal(time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC)) || hf.last.Slot != 0 { t.Fatalf("point=%+v", hf.last) }
+
+	// settled results outlive the short TTL
+	time.Sleep(20 * time.Millisecond)
+	_, out2 := postHistory(t, ts, types.GetHistoricalBalanceRequest{Wallets: []string{w}, Timestamp: "2024-01-31T23:59:59Z"})
+	if out2.Balances[0].Source != "cache" { t.Fatalf("source=%s", out2.Balances[0].Source) }
+	hf.mu.Lock(); calls := hf.calls; hf.mu.Unlock()
+	if calls != 1 { t.Fatalf("calls=%d", calls) }
+}
+
+func TestHistoricalBalanceUnsettledUsesTTL(t *testing.T) {
+	hf := &fakeHistoryFetcher{}
+	ts := newHistoryTestServer(t, hf, 10*time.Millisecond)
+	defer ts.Close()
+	req := types.GetHistoricalBalanceRequest{Wallets: []string{"11111111111111111111111111111111"}, Slot: 999}
+	postHistory(t, ts, req)
+	time.Sleep(20 * time.Millisecond)
+	_, out := postHistory(t, ts, req)
+	if out.Balances[0].Source != "rpc" { t.Fatalf("unsettled result should expire, source=%s", out.Balances[0].Source) }
+	if hf.last.Slot != 999 { t.Fatalf("point=%+v", hf.last) }
+}
+
+func TestHistoricalBalanceValidation(t *testing.T) {
+	ts := newHistoryTestServer(t, &fakeHistoryFetcher{}, time.Second)
+	defer ts.Close()
+	w := []string{"11111111111111111111111111111111"}
+	for name, body := range map[string]types.GetHistoricalBalanceRequest{
+		"neither":   {Wallets: w},
+		"both":      {Wallets: w, Slot: 1, Timestamp: "2024-01-01T00:00:00Z"},
+		"bad time":  {Wallets: w, Timestamp: "yesterday"},
+		"no wallet": {Slot: 1},
+	} {
+		if status, _ := postHistory(t, ts, body); status != http.StatusBadRequest { t.Fatalf("%s: status=%d", name, status) }
+	}
+}
@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestPushedBalancesServeFromCache(t *testing.T) {
+	ff := &fakeFetcher{lamports: 1}
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{
+		Cache: cache.New(time.Millisecond), Fetcher: ff, Timeout: time.Second, MaxConcurrency: 4, LiveTTL: time.Minute,
+	})
+	ts := httptest.NewServer(apihttp.NewRouter(bh, rate.NewLimiterMap(1000, 1000, time.Minute), fakeStore{ok: true}))
+	defer ts.Close()
+
+	pk := sol.NewWallet().PublicKey()
+	bh.Update(pk, rpc.CommitmentFinalized, solana.Balance{Lamports: 5_000, Slot: 200})
+	// an older push never rolls the balance back
+	bh.Update(pk, rpc.CommitmentFinalized, solana.Balance{Lamports: 4_000, Slot: 150})
+	time.Sleep(5 * time.Millisecond) // past the cache default, inside LiveTTL
+
+	_, out := doPost(t, ts, []string{pk.String()}, "dev-123")
+	if len(out.Balances) != 1 || out.Balances[0].Source != "cache" || out.Balances[0].Lamports != 5_000 || out.Balances[0].Slot != 200 { t.Fatalf("balances=%+v", out.Balances) }
+	if ff.calls != 0 { t.Fatalf("fetcher calls=%d", ff.calls) }
+}
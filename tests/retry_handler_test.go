@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+type unavailableFetcher struct{ calls atomic.Int32 }
+
+func (f *unavailableFetcher) GetBalance(_ context.Context, _ sol.PublicKey, _ rpc.CommitmentType) (solana.Balance, time.Duration, error) {
+	f.calls.Add(1)
+	return solana.Balance{}, 0, jsonrpc.NewHTTPError(503, errors.New("service unavailable"))
+}
+
+func TestErrorEntryReportsAttempts(t *testing.T) {
+	ff := &unavailableFetcher{}
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{
+		Cache: cache.New(10 * time.Second), Fetcher: solana.WithRetry(ff, solana.RetryOptions{MaxAttempts: 3, BaseDelay: time.Millisecond}),
+		Timeout: 3 * time.Second, MaxConcurrency: 4,
+	})
+	ts := httptest.NewServer(apihttp.NewRouter(bh, rate.NewLimiterMap(1000, 1000, time.Minute), fakeStore{ok: true}))
+	defer ts.Close()
+
+	_, out := doPost(t, ts, []string{"11111111111111111111111111111111", "bad"}, "dev-123")
+	if len(out.Errors) != 2 { t.Fatalf("errors=%+v", out.Errors) }
+	for _, e := range out.Errors {
+		switch e.Wallet {
+		case "bad":
+			if e.Attempts != 0 || e.Code != types.CodeInvalidPubkey || e.Retryable { t.Fatalf("invalid key entry: %+v", e) }
+		default:
+			if e.Attempts != 3 || e.Code != types.CodeUpstreamUnavailable || !e.Retryable || e.Error == "service unavailable" { t.Fatalf("entry=%+v", e) }
+		}
+	}
+	if ff.calls.Load() != 3 { t.Fatalf("calls=%d", ff.calls.Load()) }
+}
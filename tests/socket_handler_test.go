@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gorilla/websocket"
+)
+
+func newSocketEnv(t *testing.T, deps handlers.SocketDeps) (*httptest.Server, *handlers.BalanceHandler, *recordingWatcher) {
+	t.Helper()
+	w := &recordingWatcher{watched: make(map[sol.PublicKey]int)}
+	hub := handlers.NewHub(w)
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{
+		Cache: cache.New(10 * time.Second), Fetcher: &fakeFetcher{lamports: 1_000}, Timeout: time.Second, MaxConcurrency: 4, Hub: hub,
+	})
+	deps.Balances, deps.Hub = bh, hub
+	wsh := handlers.NewSocketHandler(deps)
+	ts := httptest.NewUnstartedServer(apihttp.NewRouter(bh, rate.NewLimiterMap(1000, 1000, time.Minute), fakeStore{ok: true}, apihttp.WithRoute("/api/ws", wsh)))
+	ts.Config.RegisterOnShutdown(wsh.Close)
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return ts, bh, w
+}
+
+func dialSocket(t *testing.T, ts *httptest.Server, key string) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	h := http.Header{}
+	if key != "" { h.Set("X-API-Key", key) }
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/api/ws", h)
+	if err == nil { t.Cleanup(func() { conn.Close() }) }
+	return conn, resp, err
+}
+
+func readMsg(t *testing.T, conn *websocket.Conn) types.SocketMessage {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg types.SocketMessage
+	if err := conn.ReadJSON(&msg); err != nil { t.Fatalf("read: %v", err) }
+	return msg
+}
+
+func TestSocketSubscribeUpdatesAndUnsubscribe(t *testing.T) {
+	ts, bh, watcher := newSocketEnv(t, handlers.SocketDeps{})
+	conn, _, err := dialSocket(t, ts, "dev-123")
+	if err != nil { t.Fatalf("dial: %v", err) }
+	pk := sol.NewWallet().PublicKey()
+	_ = conn.WriteJSON(types.SocketRequest{Op: "subscribe", Wallets: []string{pk.String(), "bad"}})
+
+	if m := readMsg(t, conn); m.Type != "error" || m.Error.Wallet != "bad" || m.Error.Code != types.CodeInvalidPubkey { t.Fatalf("msg=%+v", m) }
+	if m := readMsg(t, conn); m.Type != "subscribed" || len(m.Wallets) != 1 || m.Wallets[0] != pk.String() { t.Fatalf("msg=%+v", m) }
+	if m := readMsg(t, conn); m.Type != "balance" || m.Balance.Wallet != pk.String() || m.Balance.Lamports != 1_000 { t.Fatalf("msg=%+v", m) }
+	if watcher.count(pk) != 1 { t.Fatalf("wallet not watched upstream") }
+
+	bh.Update(pk, rpc.CommitmentFinalized, solana.Balance{Lamports: 3_000, Slot: 500})
+	if m := readMsg(t, conn); m.Type != "balance" || m.Balance.Lamports != 3_000 || m.Balance.Source != "live" { t.Fatalf("msg=%+v", m) }
+
+	_ = conn.WriteJSON(types.SocketRequest{Op: "unsubscribe", Wallets: []string{pk.String()}})
+	if m := readMsg(t, conn); m.Type != "unsubscribed" || len(m.Wallets) != 1 { t.Fatalf("msg=%+v", m) }
+	if watcher.count(pk) != 0 { t.Fatalf("wallet still watched") }
+
+	// malformed messages are reported without dropping the connection
+	_ = conn.WriteMessage(websocket.TextMessage, []byte("{not json"))
+	if m := readMsg(t, conn); m.Type != "error" || m.Error.Code != types.CodeInvalidMessage { t.Fatalf("msg=%+v", m) }
+	_ = conn.WriteJSON(types.SocketRequest{Op: "watch"})
+	if m := readMsg(t, conn); m.Type != "error" || m.Error.Code != types.CodeInvalidMessage { t.Fatalf("msg=%+v", m) }
+}
+
+func TestSocketPerKeyLimits(t *testing.T) {
+	ts, _, _ := newSocketEnv(t, handlers.SocketDeps{MaxConnsPerKey: 1, MaxSubsPerKey: 1, MessagesPerMinute: 2})
+	if _, resp, err := dialSocket(t, ts, ""); err == nil || resp.StatusCode != http.StatusUnauthorized { t.Fatalf("unauthenticated handshake err=%v", err) }
+	conn, _, err := dialSocket(t, ts, "dev-123")
+	if err != nil { t.Fatalf("dial: %v", err) }
+	if _, resp, err := dialSocket(t, ts, "dev-123"); err == nil || resp.StatusCode != http.StatusTooManyRequests { t.Fatalf("second connection err=%v", err) }
+
+	a, b := sol.NewWallet().PublicKey().String(), sol.NewWallet().PublicKey().String()
+	_ = conn.WriteJSON(types.SocketRequest{Op: "subscribe", Wallets: []string{a, b}})
+	if m := readMsg(t, conn); m.Type != "error" || m.Error.Code != types.CodeLimitExceeded { t.Fatalf("msg=%+v", m) }
+	_ = conn.WriteJSON(types.SocketRequest{Op: "subscribe", Wallets: []string{a}})
+	if m := readMsg(t, conn); m.Type != "subscribed" { t.Fatalf("msg=%+v", m) }
+	readMsg(t, conn) // initial balance
+	_ = conn.WriteJSON(types.SocketRequest{Op: "unsubscribe", Wallets: []string{a}})
+	if m := readMsg(t, conn); m.Type != "error" || m.Error.Code != types.CodeLimitExceeded || !m.Error.Retryable { t.Fatalf("msg=%+v", m) }
+}
+
+func TestSocketClosesOnShutdown(t *testing.T) {
+	ts, _, _ := newSocketEnv(t, handlers.SocketDeps{})
+	conn, _, err := dialSocket(t, ts, "dev-123")
+	if err != nil { t.Fatalf("dial: %v", err) }
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := ts.Config.Shutdown(ctx); err != nil { t.Fatalf("shutdown: %v", err) }
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) { t.Fatalf("err=%v", err) }
+}
+
+// changingFetcher returns whatever balance was last stored.
+type changingFetcher struct{ lamports atomic.Uint64 }
+
+func (f *changingFetcher) GetBalance(_ context.Context, _ sol.PublicKey, _ rpc.CommitmentType) (solana.Balance, time.Duration, error) {
+	l := f.lamports.Load()
+	return solana.Balance{Lamports: l, Slot: 100, Exists: l > 0}, time.Millisecond, nil
+}
+
+func TestSocketPollsWithoutLiveUpstream(t *testing.T) {
+	f := &changingFetcher{}
+	f.lamports.Store(1_000)
+	hub := handlers.NewHub(nil)
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{Cache: cache.New(20 * time.Millisecond), Fetcher: f, Timeout: time.Second, MaxConcurrency: 4, Hub: hub})
+	wsh := handlers.NewSocketHandler(handlers.SocketDeps{Balances: bh, Hub: hub, Poll: 30 * time.Millisecond})
+	ts := httptest.NewServer(apihttp.NewRouter(bh, rate.NewLimiterMap(1000, 1000, time.Minute), fakeStore{ok: true}, apihttp.WithRoute("/api/ws", wsh)))
+	defer ts.Close()
+	defer wsh.Close()
+
+	conn, _, err := dialSocket(t, ts, "dev-123")
+	if err != nil { t.Fatalf("dial: %v", err) }
+	pk := sol.NewWallet().PublicKey()
+	_ = conn.WriteJSON(types.SocketRequest{Op: "subscribe", Wallets: []string{pk.String()}})
+	if m := readMsg(t, conn); m.Type != "subscribed" { t.Fatalf("msg=%+v", m) }
+	if m := readMsg(t, conn); m.Type != "balance" || m.Balance.Lamports != 1_000 { t.Fatalf("msg=%+v", m) }
+
+	f.lamports.Store(4_000)
+	if m := readMsg(t, conn); m.Type != "balance" || m.Balance.Lamports != 4_000 { t.Fatalf("msg=%+v", m) }
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+type fakeStakeFetcher struct {
+	mu    sync.Mutex
+	calls int
+	fail  bool
+}
+
+func (f *fakeStakeFetcher) GetStakeAccounts(_ context.Context, _ sol.PublicKey) ([]solana.StakeAccount, time.Duration, error) {
+	f.mu.Lock(); f.calls++; f.mu.Unlock()
+	if f.fail { return nil, 0, errors.New("gpa unavailable") }
+	return []solana.StakeAccount{
+		{Address: "s1", Lamports: 3_002_282_880, Delegated: 3_000_000_000, State: solana.StakeActive},
+		{Address: "s2", Lamports: 1_002_282_880, Delegated: 1_000_000_000, State: solana.StakeActivating},
+		{Address: "s3", Lamports: 2_282_880, State: solana.StakeInactive},
+	}, time.Millisecond, nil
+}
+
+func newStakeTestServer(t *testing.T, sf *fakeStakeFetcher) *httptest.Server {
+	t.Helper()
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{
+		Cache: cache.New(10 * time.Second), Fetcher: &fakeFetcher{lamports: 1_000_000_000},
+		Timeout: 3 * time.Second, MaxConcurrency: 16, Stake: sf, StakeTTL: time.Minute,
+	})
+	lm := rate.NewLimiterMap(1000, 1000, time.Minute)
+	return httptest.NewServer(apihttp.NewRouter(bh, lm, fakeStore{ok: true}))
+}
+
+func postStake(t *testing.T, ts *httptest.Server, req types.GetBalanceRequest) types.GetBalanceResponse {
+	b, _ := json.Marshal(req)
+	hr, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/get-balance", bytes.NewReader(b))
+	hr.Header.Set("X-API-Key", "dev-123")
+	resp, err := ts.Client().Do(hr)
+	if err != nil { t.Fatalf("request error: %v", err) }
+	defer resp.Body.Close()
+	var out types.GetBalanceResponse
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return out
+}
+
+func TestIncludeStakeAggregates(t *testing.T) {
+	sf := &fakeStakeFetcher{}
+	ts := newStakeTestServer(t, sf)
+	defer ts.Close()
+	w := "11111111111111111111111111111111"
+
+	out := postStake(t, ts, types.GetBalanceRequest{Wallets: []string{w}, IncludeStake: true})
+	if len(out.Balances) != 1 || out.Balances[0].Stake == nil { t.Fatalf("out=%+v", out) }
+	be := out.Balances[0]
+	st := be.Stake
+	if st.Accounts != 3 || st.Active != 3_000_000_000 || st.Activating != 1_000_000_000 || st.Deactivating != 0 { t.Fatalf("stake=%+v", st) }
+	if st.Inactive != 3*2_282_880 { t.Fatalf("inactive=%d", st.Inactive) }
+	if be.TotalLamports != 1_000_000_000+3_002_282_880+1_002_282_880+2_282_880 { t.Fatalf("total=%d", be.TotalLamports) }
+	if st.Source != "rpc" { t.Fatalf("source=%s", st.Source) }
+
+	out2 := postStake(t, ts, types.GetBalanceRequest{Wallets: []string{w}, IncludeStake: true})
+	if out2.Balances[0].Stake.Source != "cache" { t.Fatalf("second source=%s", out2.Balances[0].Stake.Source) }
+	sf.mu.Lock(); calls := sf.calls; sf.mu.Unlock()
+	if calls != 1 { t.Fatalf("stake calls=%d", calls) }
+}
+
+func TestIncludeStakeOptIn(t *testing.T) {
+	sf := &fakeStakeFetcher{}
+	ts := newStakeTestServer(t, sf)
+	defer ts.Close()
+	out := postStake(t, ts, types.GetBalanceRequest{Wallets: []string{"11111111111111111111111111111111"}})
+	if out.Balances[0].Stake != nil || out.Balances[0].TotalLamports != 0 { t.Fatalf("stake returned without opt-in: %+v", out.Balances[0]) }
+	if sf.calls != 0 { t.Fatalf("stake calls=%d", sf.calls) }
+}
+
+func TestIncludeStakeFailureKeepsBalance(t *testing.T) {
+	ts := newStakeTestServer(t, &fakeStakeFetcher{fail: true})
+	defer ts.Close()
+	out := postStake(t, ts, types.GetBalanceRequest{Wallets: []string{"11111111111111111111111111111111"}, IncludeStake: true})
+	if len(out.Balances) != 1 || out.Balances[0].Stake != nil { t.Fatalf("balances=%+v", out.Balances) }
+	if len(out.Errors) != 1 { t.Fatalf("errors=%+v", out.Errors) }
+}
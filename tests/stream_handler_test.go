@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/auth"
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+type recordingWatcher struct {
+	mu      sync.Mutex
+	watched map[sol.PublicKey]int
+}
+
+func (w *recordingWatcher) Watch(pk sol.PublicKey)   { w.mu.Lock(); w.watched[pk]++; w.mu.Unlock() }
+func (w *recordingWatcher) Unwatch(pk sol.PublicKey) { w.mu.Lock(); w.watched[pk]--; w.mu.Unlock() }
+func (w *recordingWatcher) count(pk sol.PublicKey) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.watched[pk]
+}
+
+type streamEnv struct {
+	ts      *httptest.Server
+	bh      *handlers.BalanceHandler
+	sh      *handlers.StreamHandler
+	watcher *recordingWatcher
+}
+
+func newStreamEnv(t *testing.T, maxPerKey int) *streamEnv {
+	t.Helper()
+	w := &recordingWatcher{watched: make(map[sol.PublicKey]int)}
+	hub := handlers.NewHub(w)
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{
+		Cache: cache.New(10 * time.Second), Fetcher: &fakeFetcher{lamports: 1_000}, Timeout: time.Second, MaxConcurrency: 4, Hub: hub,
+	})
+	sh := handlers.NewStreamHandler(handlers.StreamDeps{Balances: bh, Hub: hub, Heartbeat: 50 * time.Millisecond, MaxPerKey: maxPerKey})
+	tokens := auth.NewStreamTokens(time.Minute)
+	ts := httptest.NewUnstartedServer(apihttp.NewRouter(bh, rate.NewLimiterMap(1000, 1000, time.Minute), fakeStore{ok: true},
+		apihttp.WithStreamRoute("/api/stream/balances", sh, tokens),
+		apihttp.WithRoute("/api/stream/token", handlers.NewStreamTokenHandler(tokens))))
+	// as in production: a short WriteTimeout must not cut streams off
+	ts.Config.WriteTimeout = 200 * time.Millisecond
+	ts.Config.RegisterOnShutdown(sh.Close)
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return &streamEnv{ts: ts, bh: bh, sh: sh, watcher: w}
+}
+
+// streamToken issues a stream token for dev-123 through the API.
+func (env *streamEnv) streamToken(t *testing.T) string {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, env.ts.URL+"/api/stream/token", nil)
+	req.Header.Set("X-API-Key", "dev-123")
+	resp, err := env.ts.Client().Do(req)
+	if err != nil { t.Fatalf("request error: %v", err) }
+	defer resp.Body.Close()
+	var out types.StreamTokenResponse
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	if resp.StatusCode != http.StatusOK || out.Token == "" || out.ExpiresAt == "" { t.Fatalf("token status=%d out=%+v", resp.StatusCode, out) }
+	return out.Token
+}
+
+type sseEvent struct{ name, data string }
+
+func openStream(t *testing.T, ts *httptest.Server, query string) (*http.Response, <-chan sseEvent) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/stream/balances?"+query, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := ts.Client().Do(req)
+	if err != nil { t.Fatalf("request error: %v", err) }
+	events := make(chan sseEvent, 32)
+	go func() {
+		defer close(events)
+		sc := bufio.NewScanner(resp.Body)
+		var ev sseEvent
+		for sc.Scan() {
+			line := sc.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				ev.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				ev.data = strings.TrimPrefix(line, "data: ")
+			case line == "":
+				events <- ev
+				ev = sseEvent{}
+			}
+		}
+	}()
+	return resp, events
+}
+
+// nextEvent skips heartbeats unless asked for one.
+func nextEvent(t *testing.T, events <-chan sseEvent, name string) sseEvent {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok { t.Fatalf("stream ended waiting for %s", name) }
+			if ev.name == "heartbeat" && name != "heartbeat" { continue }
+			if ev.name != name { t.Fatalf("event=%+v want %s", ev, name) }
+			return ev
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", name)
+		}
+	}
+}
+
+func TestStreamSendsSnapshotChangesAndHeartbeats(t *testing.T) {
+	env := newStreamEnv(t, 0)
+	pk := sol.NewWallet().PublicKey()
+	resp, events := openStream(t, env.ts, "wallets="+pk.String()+",bad&token="+env.streamToken(t))
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" { t.Fatalf("status=%d ct=%s", resp.StatusCode, resp.Header.Get("Content-Type")) }
+
+	var e types.ErrorEntry
+	_ = json.Unmarshal([]byte(nextEvent(t, events, "error").data), &e)
+	if e.Wallet != "bad" || e.Code != types.CodeInvalidPubkey { t.Fatalf("error=%+v", e) }
+	var b types.BalanceEntry
+	_ = json.Unmarshal([]byte(nextEvent(t, events, "balance").data), &b)
+	if b.Wallet != pk.String() || b.Lamports != 1_000 || b.Source != "rpc" { t.Fatalf("snapshot=%+v", b) }
+	if env.watcher.count(pk) != 1 { t.Fatalf("wallet not watched upstream") }
+
+	// an unchanged balance is not resent; a change is
+	env.bh.Update(pk, rpc.CommitmentFinalized, solana.Balance{Lamports: 1_000, Slot: 200})
+	env.bh.Update(pk, rpc.CommitmentFinalized, solana.Balance{Lamports: 2_500, Slot: 201})
+	_ = json.Unmarshal([]byte(nextEvent(t, events, "balance").data), &b)
+	if b.Lamports != 2_500 || b.Slot != 201 || b.Source != "live" { t.Fatalf("update=%+v", b) }
+
+	// outlives the server's WriteTimeout
+	time.Sleep(300 * time.Millisecond)
+	nextEvent(t, events, "heartbeat")
+
+	resp.Body.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for env.watcher.count(pk) != 0 && time.Now().Before(deadline) { time.Sleep(10 * time.Millisecond) }
+	if env.watcher.count(pk) != 0 { t.Fatalf("wallet still watched after disconnect") }
+}
+
+func TestStreamLimitsPerKeyAndRequiresAuth(t *testing.T) {
+	env := newStreamEnv(t, 1)
+	q := "wallets=11111111111111111111111111111111&token=" + env.streamToken(t)
+	resp, events := openStream(t, env.ts, q)
+	defer resp.Body.Close()
+	nextEvent(t, events, "balance")
+	second, _ := openStream(t, env.ts, q)
+	second.Body.Close()
+	if second.StatusCode != http.StatusTooManyRequests { t.Fatalf("second stream status=%d", second.StatusCode) }
+	noKey, _ := openStream(t, env.ts, "wallets=11111111111111111111111111111111")
+	noKey.Body.Close()
+	if noKey.StatusCode != http.StatusUnauthorized { t.Fatalf("status=%d", noKey.StatusCode) }
+}
+
+func TestStreamRejectsAPIKeyAndUnknownTokensInURL(t *testing.T) {
+	env := newStreamEnv(t, 0)
+	w := "wallets=11111111111111111111111111111111"
+	keyInURL, _ := openStream(t, env.ts, w+"&api_key=dev-123")
+	keyInURL.Body.Close()
+	if keyInURL.StatusCode != http.StatusUnauthorized { t.Fatalf("api_key in URL status=%d", keyInURL.StatusCode) }
+	bogus, _ := openStream(t, env.ts, w+"&token=nope")
+	bogus.Body.Close()
+	if bogus.StatusCode != http.StatusUnauthorized { t.Fatalf("unknown token status=%d", bogus.StatusCode) }
+
+	// tokens only open streams
+	req, _ := http.NewRequest(http.MethodPost, env.ts.URL+"/api/stream/token?token="+env.streamToken(t), nil)
+	resp, err := env.ts.Client().Do(req)
+	if err != nil { t.Fatalf("request error: %v", err) }
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized { t.Fatalf("token accepted outside streams: status=%d", resp.StatusCode) }
+}
+
+func TestStreamEndsOnShutdown(t *testing.T) {
+	env := newStreamEnv(t, 0)
+	resp, events := openStream(t, env.ts, "wallets=11111111111111111111111111111111&token="+env.streamToken(t))
+	defer resp.Body.Close()
+	nextEvent(t, events, "balance")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := env.ts.Config.Shutdown(ctx); err != nil { t.Fatalf("shutdown: %v", err) }
+	for range events {
+	}
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+type fakeTokenFetcher struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakeTokenFetcher) GetTokenAccounts(_ context.Context, _ sol.PublicKey) ([]solana.TokenAccount, time.Duration, error) {
+	f.mu.Lock(); f.calls++; f.mu.Unlock()
+	return []solana.TokenAccount{
+		{Address: "acct-b", Mint: "mint-2", Program: solana.ProgramSPLToken2022, Amount: "5", Decimals: 0, UIAmount: 5, UIAmountString: "5",
+			Extensions: &types.TokenExtensions{NonTransferable: true}},
+		{Address: "acct-a", Mint: "mint-1", Program: solana.ProgramSPLToken, Amount: "1500000", Decimals: 6, UIAmount: 1.5, UIAmountString: "1.5"},
+	}, time.Millisecond, nil
+}
+
+func newTokenTestServer(t *testing.T, store fakeStore) (*httptest.Server, *fakeTokenFetcher, *cache.Cache) {
+	t.Helper()
+	c := cache.New(10 * time.Second)
+	tf := &fakeTokenFetcher{}
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{Cache: c, Fetcher: dummyFetcher{}, Timeout: 3 * time.Second, MaxConcurrency: 16})
+	th := handlers.NewTokenBalanceHandler(handlers.TokenDeps{Cache: c, Fetcher: tf, TTL: time.Minute, Timeout: 3 * time.Second, MaxConcurrency: 16})
+	lm := rate.NewLimiterMap(1000, 1000, time.Minute)
+	r := apihttp.NewRouter(bh, lm, store, apihttp.WithRoute("/api/get-token-balances", th))
+	return httptest.NewServer(r), tf, c
+}
+
+func postTokens(t *testing.T, ts *httptest.Server, wallets []string, key string) (*http.Response, types.GetTokenBalancesResponse) {
+	b, _ := json.Marshal(types.GetBalanceRequest{Wallets: wallets})
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/get-token-balances", bytes.NewReader(b))
+	if key != "" { req.Header.Set("X-API-Key", key) }
+	resp, err := ts.Client().Do(req)
+	if err != nil { t.Fatalf("request error: %v", err) }
+	var out types.GetTokenBalancesResponse
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	resp.Body.Close()
+	return resp, out
+}
+
+func TestTokenBalancesCachedPerOwner(t *testing.T) {
+	ts, tf, c := newTokenTestServer(t, fakeStore{ok: true})
+	defer ts.Close()
+	w := "11111111111111111111111111111111"
+
+	resp, out := postTokens(t, ts, []string{w, w, "not-a-key"}, "dev-123")
+	if resp.StatusCode != http.StatusOK { t.Fatalf("status=%d", resp.StatusCode) }
+	if len(out.Wallets) != 1 || len(out.Errors) != 1 { t.Fatalf("out=%+v", out) }
+	got := out.Wallets[0]
+	if got.Source != "rpc" || len(got.Tokens) != 2 { t.Fatalf("wallet=%+v", got) }
+	if got.Tokens[0].Mint != "mint-1" || got.Tokens[0].UIAmount == nil || *got.Tokens[0].UIAmount != 1.5 || got.Tokens[0].Amount != "1500000" { t.Fatalf("tokens=%+v", got.Tokens) }
+	if got.Tokens[0].Extensions != nil || got.Tokens[1].Program != "spl-token-2022" || got.Tokens[1].Extensions == nil || !got.Tokens[1].Extensions.NonTransferable {
+		t.Fatalf("token-2022 fields not returned: %+v", got.Tokens)
+	}
+
+	_, out2 := postTokens(t, ts, []string{w}, "dev-123")
+	if out2.Wallets[0].Source != "cache" { t.Fatalf("src=%s", out2.Wallets[0].Source) }
+	tf.mu.Lock(); calls := tf.calls; tf.mu.Unlock()
+	if calls != 1 { t.Fatalf("fetch calls=%d", calls) }
+	// token entries must not shadow the wallet's SOL balance entry
+	if _, ok := c.Get(w); ok { t.Fatalf("token lookup populated the balance key") }
+}
+
+func TestTokenBalancesRequiresAuth(t *testing.T) {
+	ts, _, _ := newTokenTestServer(t, fakeStore{ok: true})
+	defer ts.Close()
+	resp, _ := postTokens(t, ts, []string{"11111111111111111111111111111111"}, "")
+	if resp.StatusCode != http.StatusUnauthorized { t.Fatalf("status=%d", resp.StatusCode) }
+}
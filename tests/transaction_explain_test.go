@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/mr-tron/base58"
+)
+
+// rpcStandIn is a local JSON-RPC node serving getTransaction from fixtures.
+type rpcStandIn struct {
+	*httptest.Server
+	mu    sync.Mutex
+	calls int
+}
+
+func newRPCStandIn(t *testing.T, txs map[string]any) *rpcStandIn {
+	s := &rpcStandIn{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		s.mu.Lock(); s.calls++; s.mu.Unlock()
+		var sig string
+		if req.Method != "getTransaction" || len(req.Params) == 0 || json.Unmarshal(req.Params[0], &sig) != nil {
+			_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "error": map[string]any{"code": -32601, "message": "method not found"}})
+			return
+		}
+		if sig == testSig(0xee) {
+			_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "error": map[string]any{"code": -32602, "message": "invalid params"}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": txs[sig]})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// transferFixture is a json-encoded getTransaction result: a compute budget
+// price, a SOL transfer, a checked token transfer and an unknown program.
+func transferFixture(payer, dest, src, dst, mint, other sol.PublicKey) map[string]any {
+	u64 := func(v uint64) []byte { return binary.LittleEndian.AppendUint64(nil, v) }
+	data := func(b ...[]byte) string {
+		var out []byte
+		for _, p := range b { out = append(out, p...) }
+		return base58.Encode(out)
+	}
+	ix := func(program int, accounts []int, d string) map[string]any { return map[string]any{"programIdIndex": program, "accounts": accounts, "data": d} }
+	keys := []string{payer.String(), dest.String(), src.String(), dst.String(), mint.String(), sol.SystemProgramID.String(), sol.TokenProgramID.String(), sol.ComputeBudget.String(), other.String()}
+	tb := func(i int, owner sol.PublicKey, amt string) map[string]any {
+		return map[string]any{"accountIndex": i, "mint": mint.String(), "owner": owner.String(), "programId": sol.TokenProgramID.String(), "uiTokenAmount": map[string]any{"amount": amt, "decimals": 6}}
+	}
+	return map[string]any{
+		"slot": 321, "blockTime": 1_700_000_000, "version": "legacy",
+		"transaction": map[string]any{"signatures": []string{testSig(1)}, "message": map[string]any{
+			"header":      map[string]any{"numRequiredSignatures": 1, "numReadonlySignedAccounts": 0, "numReadonlyUnsignedAccounts": 5},
+			"accountKeys": keys,
+			"instructions": []any{
+				ix(7, nil, data([]byte{3}, u64(10_000))),
+				ix(5, []int{0, 1}, data([]byte{2, 0, 0, 0}, u64(250_000_000))),
+				ix(6, []int{2, 4, 3, 0}, data([]byte{12}, u64(1_250_000), []byte{6})),
+				ix(8, []int{0, 1}, data([]byte{1, 2, 3})),
+			},
+		}},
+		"meta": map[string]any{
+			"err": nil, "fee": 5000, "computeUnitsConsumed": 4_200,
+			"preBalances":       []uint64{1_000_000_000, 0, 2_039_280, 2_039_280, 1, 1, 1, 1, 1},
+			"postBalances":      []uint64{749_995_000, 250_000_000, 2_039_280, 2_039_280, 1, 1, 1, 1, 1},
+			"preTokenBalances":  []any{tb(2, payer, "2000000"), tb(3, dest, "0")},
+			"postTokenBalances": []any{tb(2, payer, "750000"), tb(3, dest, "1250000")},
+			"logMessages":       []string{"Program 11111111111111111111111111111111 invoke [1]"},
+			"loadedAddresses":   map[string]any{"writable": []string{}, "readonly": []string{}},
+		},
+	}
+}
+
+func getExplained(t *testing.T, ts *httptest.Server, method, sig string) (int, types.ExplainTransactionResponse) {
+	req, _ := http.NewRequest(method, ts.URL+"/api/transactions/"+sig, nil)
+	req.Header.Set("X-API-Key", "dev-123")
+	resp, err := ts.Client().Do(req)
+	if err != nil { t.Fatalf("request error: %v", err) }
+	defer resp.Body.Close()
+	var out types.ExplainTransactionResponse
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out
+}
+
+func TestTransactionExplain(t *testing.T) {
+	payer, dest, src, dst, mint, other := sol.NewWallet().PublicKey(), sol.NewWallet().PublicKey(), sol.NewWallet().PublicKey(), sol.NewWallet().PublicKey(), sol.NewWallet().PublicKey(), sol.NewWallet().PublicKey()
+	node := newRPCStandIn(t, map[string]any{testSig(1): transferFixture(payer, dest, src, dst, mint, other)})
+	c := cache.New(10 * time.Second)
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{Cache: c, Fetcher: dummyFetcher{}, Timeout: 3 * time.Second, MaxConcurrency: 16})
+	txdh := handlers.NewTransactionDetailHandler(handlers.TransactionDetailDeps{Cache: cache.New(10 * time.Second).WithMaxEntries(10), Fetcher: solana.NewClient(node.URL, "finalized"), TTL: time.Second, Timeout: 3 * time.Second})
+	ts := httptest.NewServer(apihttp.NewRouter(bh, rate.NewLimiterMap(1000, 1000, time.Minute), fakeStore{ok: true}, apihttp.WithRoute("/api/transactions/", txdh)))
+	defer ts.Close()
+
+	status, out := getExplained(t, ts, http.MethodGet, testSig(1))
+	if status != http.StatusOK || out.Source != "rpc" { t.Fatalf("status=%d out=%+v", status, out) }
+	if out.Signature != testSig(1) || out.Slot != 321 || out.Status != "success" || out.Fee != 5000 || out.FeePayer != payer.String() || out.Version != "legacy" { t.Fatalf("header=%+v", out) }
+	if out.ComputeUnits == nil || *out.ComputeUnits != 4_200 || out.BlockTime != "2023-11-14T22:13:20Z" || len(out.Logs) != 1 { t.Fatalf("meta=%+v", out) }
+	if len(out.Instructions) != 4 { t.Fatalf("instructions=%+v", out.Instructions) }
+	if ix := out.Instructions[0]; ix.Program != "compute-budget" || ix.Type != "setComputeUnitPrice" || ix.Info["micro_lamports"] != float64(10_000) { t.Fatalf("compute budget=%+v", ix) }
+	if ix := out.Instructions[1]; ix.Program != "system" || ix.Type != "transfer" || ix.Info["destination"] != dest.String() || ix.Info["sol"] != "0.25" { t.Fatalf("system transfer=%+v", ix) }
+	if ix := out.Instructions[2]; ix.Program != "spl-token" || ix.Type != "transferChecked" || ix.Info["source"] != src.String() || ix.Info["amount"] != "1250000" || ix.Info["ui_amount"] != "1.25" { t.Fatalf("token transfer=%+v", ix) }
+	if ix := out.Instructions[3]; ix.ProgramID != other.String() || ix.Type != "" || ix.Data != "010203" || len(ix.Accounts) != 2 { t.Fatalf("unknown program=%+v", ix) }
+
+	if len(out.SolChanges) != 2 || out.SolChanges[0].LamportsDelta != -250_005_000 || out.SolChanges[1].SolDelta != "0.25" { t.Fatalf("sol changes=%+v", out.SolChanges) }
+	if len(out.TokenChanges) != 2 { t.Fatalf("token changes=%+v", out.TokenChanges) }
+	if tc := out.TokenChanges[0]; tc.Account != src.String() || tc.Owner != payer.String() || tc.Delta != "-1250000" || tc.UIDelta != "-1.25" { t.Fatalf("source change=%+v", tc) }
+	if tc := out.TokenChanges[1]; tc.Account != dst.String() || tc.Mint != mint.String() || tc.UIDelta != "1.25" { t.Fatalf("destination change=%+v", tc) }
+
+	// finalized transactions are served from cache
+	if status, again := getExplained(t, ts, http.MethodGet, testSig(1)); status != http.StatusOK || again.Source != "cache" || node.calls != 1 { t.Fatalf("status=%d source=%s calls=%d", status, again.Source, node.calls) }
+
+	if status, _ := getExplained(t, ts, http.MethodGet, testSig(2)); status != http.StatusNotFound { t.Fatalf("missing tx status=%d", status) }
+	if status, _ := getExplained(t, ts, http.MethodGet, testSig(0xee)); status != http.StatusBadGateway { t.Fatalf("rpc error status=%d", status) }
+	if status, _ := getExplained(t, ts, http.MethodGet, "not-a-signature"); status != http.StatusBadRequest { t.Fatalf("bad signature status=%d", status) }
+	if status, _ := getExplained(t, ts, http.MethodPost, testSig(1)); status != http.StatusMethodNotAllowed { t.Fatalf("POST status=%d", status) }
+}
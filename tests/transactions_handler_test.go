@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+type fakeTxFetcher struct {
+	mu        sync.Mutex
+	sigs      []solana.SignatureInfo
+	lastPage  solana.SignaturePage
+	deltaCall map[string]int
+	failSig   string
+}
+
+func (f *fakeTxFetcher) GetSignatures(_ context.Context, _ sol.PublicKey, page solana.SignaturePage) ([]solana.SignatureInfo, time.Duration, error) {
+	f.mu.Lock(); f.lastPage = page; f.mu.Unlock()
+	n := page.Limit
+	if n > len(f.sigs) { n = len(f.sigs) }
+	return f.sigs[:n], time.Millisecond, nil
+}
+
+func (f *fakeTxFetcher) GetWalletDelta(_ context.Context, sig sol.Signature, _ sol.PublicKey) (solana.WalletDelta, time.Duration, error) {
+	f.mu.Lock(); f.deltaCall[sig.String()]++; f.mu.Unlock()
+	if sig.String() == f.failSig { return solana.WalletDelta{}, 0, errors.New("tx unavailable") }
+	return solana.WalletDelta{Fee: 5000, PreLamports: 2_000_000_000, PostLamports: 1_499_995_000}, time.Millisecond, nil
+}
+
+func testSig(b byte) string { var s sol.Signature; s[0] = b; return s.String() }
+
+func newTxTestServer(t *testing.T, tf *fakeTxFetcher) *httptest.Server {
+	t.Helper()
+	c := cache.New(10 * time.Second).WithMaxEntries(1000)
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{Cache: c, Fetcher: dummyFetcher{}, Timeout: 3 * time.Second, MaxConcurrency: 16})
+	txh := handlers.NewTransactionsHandler(handlers.TransactionDeps{Cache: c, Fetcher: tf, TTL: 10 * time.Millisecond, Timeout: 3 * time.Second, MaxConcurrency: 4})
+	lm := rate.NewLimiterMap(1000, 1000, time.Minute)
+	return httptest.NewServer(apihttp.NewRouter(bh, lm, fakeStore{ok: true}, apihttp.WithRoute("/api/transactions", txh)))
+}
+
+func getTransactions(t *testing.T, ts *httptest.Server, query string) (int, types.GetTransactionsResponse) {
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/transactions?"+query, nil)
+	req.Header.Set("X-API-Key", "dev-123")
+	resp, err := ts.Client().Do(req)
+	if err != nil { t.Fatalf("request error: %v", err) }
+	defer resp.Body.Close()
+	var out types.GetTransactionsResponse
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out
+}
+
+func TestTransactionsPageAndCache(t *testing.T) {
+	bt := time.Unix(1_700_000_000, 0).UTC()
+	tf := &fakeTxFetcher{deltaCall: map[string]int{}, sigs: []solana.SignatureInfo{
+		{Signature: testSig(3), Slot: 30, BlockTime: &bt, ConfirmationStatus: "confirmed"},
+		{Signature: testSig(2), Slot: 20, BlockTime: &bt, ConfirmationStatus: "finalized", Err: map[string]any{"InstructionError": []any{0, "Custom"}}},
+		{Signature: testSig(1), Slot: 10, ConfirmationStatus: "finalized"},
+	}}
+	ts := newTxTestServer(t, tf)
+	defer ts.Close()
+	w := "11111111111111111111111111111111"
+
+	status, out := getTransactions(t, ts, "wallet="+w+"&limit=2&before="+testSig(9))
+	if status != http.StatusOK { t.Fatalf("status=%d", status) }
+	if tf.lastPage.Limit != 2 || tf.lastPage.Before.String() != testSig(9) { t.Fatalf("page=%+v", tf.lastPage) }
+	if len(out.Transactions) != 2 || out.NextBefore != testSig(2) { t.Fatalf("out=%+v", out) }
+	first, second := out.Transactions[0], out.Transactions[1]
+	if first.Signature != testSig(3) || first.Status != "success" || first.LamportsDelta != -500_005_000 || first.SolDelta == nil || *first.SolDelta != -0.500005 || first.Fee != 5000 { t.Fatalf("first=%+v", first) }
+	if second.Status != "failed" || second.Err == nil || second.BlockTime == "" { t.Fatalf("second=%+v", second) }
+
+	time.Sleep(20 * time.Millisecond)
+	_, out2 := getTransactions(t, ts, "wallet="+w+"&limit=2")
+	if out2.Transactions[0].Source != "rpc" { t.Fatalf("confirmed tx should expire, got %s", out2.Transactions[0].Source) }
+	if out2.Transactions[1].Source != "cache" { t.Fatalf("finalized tx should be cached permanently, got %s", out2.Transactions[1].Source) }
+}
+
+func TestTransactionsPerTxErrorAndValidation(t *testing.T) {
+	tf := &fakeTxFetcher{deltaCall: map[string]int{}, failSig: testSig(1), sigs: []solana.SignatureInfo{
+		{Signature: testSig(2), Slot: 20, ConfirmationStatus: "finalized"},
+		{Signature: testSig(1), Slot: 10, ConfirmationStatus: "finalized"},
+	}}
+	ts := newTxTestServer(t, tf)
+	defer ts.Close()
+	_, out := getTransactions(t, ts, "wallet=11111111111111111111111111111111")
+	if len(out.Transactions) != 1 || len(out.Errors) != 1 || out.Errors[0].Signature != testSig(1) || out.NextBefore != "" { t.Fatalf("out=%+v", out) }
+
+	for _, q := range []string{"", "wallet=bad", "wallet=11111111111111111111111111111111&limit=0", "wallet=11111111111111111111111111111111&limit=101", "wallet=11111111111111111111111111111111&until=zz"} {
+		if status, _ := getTransactions(t, ts, q); status != http.StatusBadRequest { t.Fatalf("%q: status=%d", q, status) }
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/solana"
+	"github.com/example/solapi/internal/types"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+func newUnitsServer(t *testing.T, lamports uint64) *httptest.Server {
+	t.Helper()
+	c := cache.New(10 * time.Second)
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{Cache: c, Fetcher: &fakeFetcher{lamports: lamports}, Timeout: time.Second, MaxConcurrency: 4})
+	th := handlers.NewTokenBalanceHandler(handlers.TokenDeps{Cache: c, Fetcher: &fakeTokenFetcher{}, TTL: time.Minute, Timeout: time.Second, MaxConcurrency: 4})
+	ts := httptest.NewServer(apihttp.NewRouter(bh, rate.NewLimiterMap(1000, 1000, time.Minute), fakeStore{ok: true}, apihttp.WithRoute("/api/get-token-balances", th)))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// postRaw posts req and returns the status and the undecoded body.
+func postRaw(t *testing.T, ts *httptest.Server, path string, req types.GetBalanceRequest) (int, []byte) {
+	t.Helper()
+	b, _ := json.Marshal(req)
+	hreq, _ := http.NewRequest(http.MethodPost, ts.URL+path, bytes.NewReader(b))
+	hreq.Header.Set("X-API-Key", "k")
+	resp, err := http.DefaultClient.Do(hreq)
+	if err != nil { t.Fatalf("request: %v", err) }
+	defer resp.Body.Close()
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(resp.Body)
+	return resp.StatusCode, buf.Bytes()
+}
+
+func TestBalanceUnits(t *testing.T) {
+	// 18,446,744,073.709551615 SOL cannot survive a float64 round trip
+	const lamports = 18_446_744_073_709_551_615
+	ts := newUnitsServer(t, lamports)
+	wallet := sol.NewWallet().PublicKey().String()
+
+	code, body := postRaw(t, ts, "/api/get-balance", types.GetBalanceRequest{Wallets: []string{wallet}, Units: types.UnitsSolString})
+	if code != http.StatusOK { t.Fatalf("status=%d", code) }
+	var out types.GetBalanceResponse
+	_ = json.Unmarshal(body, &out)
+	if len(out.Balances) != 1 || out.Balances[0].SolString != "18446744073.709551615" || out.Balances[0].Sol != nil {
+		t.Fatalf("sol-string entry=%s", body)
+	}
+	if bytes.Contains(body, []byte(`"sol":`)) { t.Fatalf("float leaked: %s", body) }
+
+	_, body = postRaw(t, ts, "/api/get-balance", types.GetBalanceRequest{Wallets: []string{wallet}, Units: types.UnitsLamports})
+	if bytes.Contains(body, []byte(`"sol`)) || !bytes.Contains(body, []byte(`"lamports":18446744073709551615`)) { t.Fatalf("lamports body=%s", body) }
+
+	_, body = postRaw(t, ts, "/api/get-balance", types.GetBalanceRequest{Wallets: []string{wallet}})
+	out = types.GetBalanceResponse{}
+	_ = json.Unmarshal(body, &out)
+	if out.Balances[0].Sol == nil || out.Balances[0].SolString != "" { t.Fatalf("default body=%s", body) }
+
+	if code, _ := postRaw(t, ts, "/api/get-balance", types.GetBalanceRequest{Wallets: []string{wallet}, Units: "wei"}); code != http.StatusBadRequest {
+		t.Fatalf("invalid units status=%d", code)
+	}
+}
+
+func TestTokenUnits(t *testing.T) {
+	ts := newUnitsServer(t, 0)
+	wallet := sol.NewWallet().PublicKey().String()
+	_, body := postRaw(t, ts, "/api/get-token-balances", types.GetBalanceRequest{Wallets: []string{wallet}, Units: types.UnitsSolString})
+	var out types.GetTokenBalancesResponse
+	_ = json.Unmarshal(body, &out)
+	if len(out.Wallets) != 1 || len(out.Wallets[0].Tokens) != 2 { t.Fatalf("body=%s", body) }
+	for _, tb := range out.Wallets[0].Tokens {
+		if tb.UIAmount != nil { t.Fatalf("float leaked: %+v", tb) }
+		if tb.Mint == "mint-1" && tb.UIAmountString != "1.5" { t.Fatalf("mint-1 ui_amount_string=%q", tb.UIAmountString) }
+	}
+
+	_, body = postRaw(t, ts, "/api/get-token-balances", types.GetBalanceRequest{Wallets: []string{wallet}, Units: types.UnitsLamports})
+	if bytes.Contains(body, []byte(`"ui_amount`)) || !bytes.Contains(body, []byte(`"amount":"1500000"`)) { t.Fatalf("lamports body=%s", body) }
+}
+
+func TestHistoryAndTransactionUnits(t *testing.T) {
+	hts := newHistoryTestServer(t, &fakeHistoryFetcher{final: true}, time.Minute)
+	defer hts.Close()
+	w := "11111111111111111111111111111111"
+	_, hist := postHistory(t, hts, types.GetHistoricalBalanceRequest{Wallets: []string{w}, Slot: 123, Units: types.UnitsSolString})
+	if len(hist.Balances) != 1 || hist.Balances[0].SolString != "2.5" || hist.Balances[0].Sol != nil { t.Fatalf("history=%+v", hist) }
+	_, hist = postHistory(t, hts, types.GetHistoricalBalanceRequest{Wallets: []string{w}, Slot: 123, Units: types.UnitsLamports})
+	if hist.Balances[0].Lamports != 2_500_000_000 || hist.Balances[0].Sol != nil || hist.Balances[0].SolString != "" { t.Fatalf("history=%+v", hist) }
+	if code, _ := postHistory(t, hts, types.GetHistoricalBalanceRequest{Wallets: []string{w}, Slot: 123, Units: "wei"}); code != http.StatusBadRequest { t.Fatalf("invalid units status=%d", code) }
+
+	tts := newTxTestServer(t, &fakeTxFetcher{deltaCall: map[string]int{}, sigs: []solana.SignatureInfo{{Signature: testSig(1), Slot: 10, ConfirmationStatus: "finalized"}}})
+	defer tts.Close()
+	_, txs := getTransactions(t, tts, "wallet="+w+"&units=sol-string")
+	if len(txs.Transactions) != 1 || txs.Transactions[0].SolDeltaString != "-0.500005" || txs.Transactions[0].SolDelta != nil { t.Fatalf("transactions=%+v", txs) }
+	_, txs = getTransactions(t, tts, "wallet="+w+"&units=lamports")
+	if txs.Transactions[0].LamportsDelta != -500_005_000 || txs.Transactions[0].SolDelta != nil || txs.Transactions[0].SolDeltaString != "" { t.Fatalf("transactions=%+v", txs) }
+	if code, _ := getTransactions(t, tts, "wallet="+w+"&units=wei"); code != http.StatusBadRequest { t.Fatalf("invalid units status=%d", code) }
+}
@@ -0,0 +1,262 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/types"
+	"github.com/example/solapi/internal/watchlist"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// memWatchlists is an in-memory watchlist.Store.
+type memWatchlists struct {
+	mu    sync.Mutex
+	lists map[string]watchlist.Watchlist
+}
+
+func (m *memWatchlists) Create(_ context.Context, l watchlist.Watchlist, limit int) error {
+	m.mu.Lock(); defer m.mu.Unlock()
+	n := 0
+	for _, have := range m.lists {
+		if have.Owner == l.Owner { n++ }
+	}
+	if limit > 0 && n >= limit { return watchlist.ErrLimit }
+	m.lists[l.ID] = l
+	return nil
+}
+
+func (m *memWatchlists) Get(_ context.Context, id string) (watchlist.Watchlist, error) {
+	m.mu.Lock(); defer m.mu.Unlock()
+	l, ok := m.lists[id]
+	if !ok { return watchlist.Watchlist{}, watchlist.ErrNotFound }
+	return l, nil
+}
+
+func (m *memWatchlists) List(_ context.Context, owner string) ([]watchlist.Watchlist, error) {
+	m.mu.Lock(); defer m.mu.Unlock()
+	var out []watchlist.Watchlist
+	for _, l := range m.lists {
+		if l.Owner == owner { out = append(out, l) }
+	}
+	return out, nil
+}
+
+func (m *memWatchlists) Delete(_ context.Context, id string) error {
+	m.mu.Lock(); defer m.mu.Unlock()
+	if _, ok := m.lists[id]; !ok { return watchlist.ErrNotFound }
+	delete(m.lists, id)
+	return nil
+}
+
+func (m *memWatchlists) AddWallets(_ context.Context, id string, wallets []string, limit int) error {
+	m.mu.Lock(); defer m.mu.Unlock()
+	l, ok := m.lists[id]
+	if !ok { return watchlist.ErrNotFound }
+	have := make(map[string]bool)
+	for _, w := range l.Wallets { have[w] = true }
+	added := append([]string(nil), l.Wallets...)
+	for _, w := range wallets {
+		if !have[w] { added = append(added, w); have[w] = true }
+	}
+	if limit > 0 && len(added) > limit { return watchlist.ErrFull }
+	l.Wallets = added
+	m.lists[id] = l
+	return nil
+}
+
+func (m *memWatchlists) RemoveWallets(_ context.Context, id string, wallets []string) error {
+	m.mu.Lock(); defer m.mu.Unlock()
+	l, ok := m.lists[id]
+	if !ok { return watchlist.ErrNotFound }
+	drop := make(map[string]bool)
+	for _, w := range wallets { drop[w] = true }
+	kept := []string{}
+	for _, w := range l.Wallets {
+		if !drop[w] { kept = append(kept, w) }
+	}
+	l.Wallets = kept
+	m.lists[id] = l
+	return nil
+}
+
+func newWatchlistServer(t *testing.T, deps handlers.WatchlistDeps) (*httptest.Server, *fakeFetcher) {
+	t.Helper()
+	ff := &fakeFetcher{lamports: 2_000}
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{Cache: cache.New(10 * time.Second), Fetcher: ff, Timeout: time.Second, MaxConcurrency: 8})
+	deps.Store, deps.Balances = &memWatchlists{lists: make(map[string]watchlist.Watchlist)}, bh
+	wlh := handlers.NewWatchlistHandler(deps)
+	ts := httptest.NewServer(apihttp.NewRouter(bh, rate.NewLimiterMap(1000, 1000, time.Minute), fakeStore{ok: true},
+		apihttp.WithRoute("/api/watchlists", wlh), apihttp.WithRoute("/api/watchlists/", wlh)))
+	t.Cleanup(ts.Close)
+	return ts, ff
+}
+
+func watchlistCall(t *testing.T, ts *httptest.Server, method, path, key string, body any, out any) int {
+	t.Helper()
+	var rd bytes.Reader
+	if body != nil {
+		b, _ := json.Marshal(body)
+		rd.Reset(b)
+	}
+	req, _ := http.NewRequest(method, ts.URL+path, &rd)
+	req.Header.Set("X-API-Key", key)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil { t.Fatalf("%s %s: %v", method, path, err) }
+	defer resp.Body.Close()
+	if out != nil { _ = json.NewDecoder(resp.Body).Decode(out) }
+	return resp.StatusCode
+}
+
+func newWallets(n int) []string {
+	out := make([]string, n)
+	for i := range out { out[i] = sol.NewWallet().PublicKey().String() }
+	return out
+}
+
+func TestWatchlistMembershipScopedToKey(t *testing.T) {
+	ts, _ := newWatchlistServer(t, handlers.WatchlistDeps{})
+	ws := newWallets(3)
+	var l types.WatchlistEntry
+	if code := watchlistCall(t, ts, http.MethodPost, "/api/watchlists", "key-a", types.WatchlistRequest{Name: "treasury", Wallets: []string{ws[0], ws[0]}}, &l); code != http.StatusCreated {
+		t.Fatalf("create status=%d", code)
+	}
+	if l.ID == "" || len(l.Wallets) != 1 { t.Fatalf("created=%+v", l) }
+
+	if code := watchlistCall(t, ts, http.MethodPost, "/api/watchlists/"+l.ID+"/wallets", "key-a", types.WatchlistRequest{Wallets: ws[1:]}, &l); code != http.StatusOK || len(l.Wallets) != 3 {
+		t.Fatalf("add status=%d wallets=%v", code, l.Wallets)
+	}
+	if code := watchlistCall(t, ts, http.MethodPost, "/api/watchlists/"+l.ID+"/wallets", "key-a", types.WatchlistRequest{Wallets: []string{"bad"}}, nil); code != http.StatusBadRequest {
+		t.Fatalf("add invalid status=%d", code)
+	}
+	if code := watchlistCall(t, ts, http.MethodDelete, "/api/watchlists/"+l.ID+"/wallets", "key-a", types.WatchlistRequest{Wallets: ws[:1]}, &l); code != http.StatusOK || len(l.Wallets) != 2 {
+		t.Fatalf("remove status=%d wallets=%v", code, l.Wallets)
+	}
+
+	if code := watchlistCall(t, ts, http.MethodGet, "/api/watchlists/"+l.ID, "key-b", nil, nil); code != http.StatusNotFound {
+		t.Fatalf("other key get status=%d", code)
+	}
+	if code := watchlistCall(t, ts, http.MethodGet, "/api/watchlists/"+l.ID+"/balances", "key-b", nil, nil); code != http.StatusNotFound {
+		t.Fatalf("other key balances status=%d", code)
+	}
+	var list struct{ Watchlists []types.WatchlistEntry `json:"watchlists"` }
+	watchlistCall(t, ts, http.MethodGet, "/api/watchlists", "key-a", nil, &list)
+	if len(list.Watchlists) != 1 || list.Watchlists[0].Name != "treasury" { t.Fatalf("list=%+v", list) }
+
+	if code := watchlistCall(t, ts, http.MethodDelete, "/api/watchlists/"+l.ID, "key-a", nil, nil); code != http.StatusNoContent {
+		t.Fatalf("delete status=%d", code)
+	}
+	if code := watchlistCall(t, ts, http.MethodGet, "/api/watchlists/"+l.ID, "key-a", nil, nil); code != http.StatusNotFound {
+		t.Fatalf("get after delete status=%d", code)
+	}
+}
+
+func TestWatchlistBalancesPagePastRequestLimit(t *testing.T) {
+	ts, _ := newWatchlistServer(t, handlers.WatchlistDeps{})
+	ws := newWallets(150)
+	var l types.WatchlistEntry
+	watchlistCall(t, ts, http.MethodPost, "/api/watchlists", "k", types.WatchlistRequest{Name: "big", Wallets: ws[:100]}, &l)
+	watchlistCall(t, ts, http.MethodPost, "/api/watchlists/"+l.ID+"/wallets", "k", types.WatchlistRequest{Wallets: ws[100:]}, &l)
+	if len(l.Wallets) != 150 { t.Fatalf("members=%d", len(l.Wallets)) }
+
+	var seen []string
+	after := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 { t.Fatalf("too many pages") }
+		var page types.WatchlistBalancesResponse
+		if code := watchlistCall(t, ts, http.MethodGet, "/api/watchlists/"+l.ID+"/balances?limit=60&after="+after, "k", nil, &page); code != http.StatusOK {
+			t.Fatalf("balances status=%d", code)
+		}
+		for _, b := range page.Balances {
+			if b.Lamports != 2_000 { t.Fatalf("lamports=%d", b.Lamports) }
+			seen = append(seen, b.Wallet)
+		}
+		if page.NextAfter == "" { break }
+		after = page.NextAfter
+	}
+	sorted := append([]string(nil), ws...)
+	sort.Strings(sorted)
+	if len(seen) != 150 { t.Fatalf("balances=%d", len(seen)) }
+	for i := range sorted {
+		if seen[i] != sorted[i] { t.Fatalf("page order broken at %d", i) }
+	}
+	if code := watchlistCall(t, ts, http.MethodGet, "/api/watchlists/"+l.ID+"/balances?limit=101", "k", nil, nil); code != http.StatusBadRequest {
+		t.Fatalf("limit over max status=%d", code)
+	}
+}
+
+func TestWatchlistLimits(t *testing.T) {
+	ts, _ := newWatchlistServer(t, handlers.WatchlistDeps{MaxPerKey: 1, MaxWallets: 2})
+	ws := newWallets(3)
+	var l types.WatchlistEntry
+	if code := watchlistCall(t, ts, http.MethodPost, "/api/watchlists", "k", types.WatchlistRequest{Name: "a", Wallets: ws[:2]}, &l); code != http.StatusCreated {
+		t.Fatalf("create status=%d", code)
+	}
+	if code := watchlistCall(t, ts, http.MethodPost, "/api/watchlists", "k", types.WatchlistRequest{Name: "b"}, nil); code != http.StatusConflict {
+		t.Fatalf("over list limit status=%d", code)
+	}
+	if code := watchlistCall(t, ts, http.MethodPost, "/api/watchlists/"+l.ID+"/wallets", "k", types.WatchlistRequest{Wallets: ws[2:]}, nil); code != http.StatusConflict {
+		t.Fatalf("over wallet limit status=%d", code)
+	}
+	// re-adding members does not count against the limit
+	if code := watchlistCall(t, ts, http.MethodPost, "/api/watchlists/"+l.ID+"/wallets", "k", types.WatchlistRequest{Wallets: ws[:1]}, nil); code != http.StatusOK {
+		t.Fatalf("re-add status=%d", code)
+	}
+	if code := watchlistCall(t, ts, http.MethodPost, "/api/watchlists", "other", types.WatchlistRequest{Wallets: ws[:1]}, nil); code != http.StatusBadRequest {
+		t.Fatalf("missing name status=%d", code)
+	}
+}
+
+func TestWatchlistWalletLimitHoldsUnderConcurrentAdds(t *testing.T) {
+	ts, _ := newWatchlistServer(t, handlers.WatchlistDeps{MaxWallets: 3})
+	var l types.WatchlistEntry
+	if code := watchlistCall(t, ts, http.MethodPost, "/api/watchlists", "k", types.WatchlistRequest{Name: "a"}, &l); code != http.StatusCreated {
+		t.Fatalf("create status=%d", code)
+	}
+	var wg sync.WaitGroup
+	for _, w := range newWallets(12) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b, _ := json.Marshal(types.WatchlistRequest{Wallets: []string{w}})
+			req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/watchlists/"+l.ID+"/wallets", bytes.NewReader(b))
+			req.Header.Set("X-API-Key", "k")
+			if resp, err := ts.Client().Do(req); err == nil { resp.Body.Close() }
+		}()
+	}
+	wg.Wait()
+	if code := watchlistCall(t, ts, http.MethodGet, "/api/watchlists/"+l.ID, "k", nil, &l); code != http.StatusOK || len(l.Wallets) != 3 {
+		t.Fatalf("status=%d wallets=%d, want 3", code, len(l.Wallets))
+	}
+}
+
+func TestWatchlistLimitHoldsUnderConcurrentCreates(t *testing.T) {
+	ts, _ := newWatchlistServer(t, handlers.WatchlistDeps{MaxPerKey: 2})
+	b, _ := json.Marshal(types.WatchlistRequest{Name: "a"})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/watchlists", bytes.NewReader(b))
+			req.Header.Set("X-API-Key", "k")
+			if resp, err := ts.Client().Do(req); err == nil { resp.Body.Close() }
+		}()
+	}
+	wg.Wait()
+	var out struct{ Watchlists []types.WatchlistEntry `json:"watchlists"` }
+	if code := watchlistCall(t, ts, http.MethodGet, "/api/watchlists", "k", nil, &out); code != http.StatusOK || len(out.Watchlists) != 2 {
+		t.Fatalf("status=%d watchlists=%d, want 2", code, len(out.Watchlists))
+	}
+}
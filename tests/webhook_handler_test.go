@@ -0,0 +1,240 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/solapi/internal/cache"
+	apihttp "github.com/example/solapi/internal/http"
+	"github.com/example/solapi/internal/handlers"
+	"github.com/example/solapi/internal/rate"
+	"github.com/example/solapi/internal/types"
+	"github.com/example/solapi/internal/webhook"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// memWebhooks is an in-memory webhook.Store and webhook.Outbox.
+type memWebhooks struct {
+	mu         sync.Mutex
+	hooks      map[string]webhook.Webhook
+	deliveries []webhook.Delivery
+}
+
+func newMemWebhooks() *memWebhooks { return &memWebhooks{hooks: make(map[string]webhook.Webhook)} }
+
+func (m *memWebhooks) Create(_ context.Context, w webhook.Webhook, limit int) error {
+	m.mu.Lock(); defer m.mu.Unlock()
+	n := 0
+	for _, h := range m.hooks {
+		if h.Owner == w.Owner { n++ }
+	}
+	if limit > 0 && n >= limit { return webhook.ErrLimit }
+	m.hooks[w.ID] = w
+	return nil
+}
+
+func (m *memWebhooks) Get(_ context.Context, id string) (webhook.Webhook, error) {
+	m.mu.Lock(); defer m.mu.Unlock()
+	w, ok := m.hooks[id]
+	if !ok { return webhook.Webhook{}, webhook.ErrNotFound }
+	return w, nil
+}
+
+func (m *memWebhooks) List(_ context.Context, owner string) ([]webhook.Webhook, error) {
+	m.mu.Lock(); defer m.mu.Unlock()
+	var out []webhook.Webhook
+	for _, w := range m.hooks {
+		if w.Owner == owner { out = append(out, w) }
+	}
+	return out, nil
+}
+
+func (m *memWebhooks) Update(_ context.Context, w webhook.Webhook) error {
+	m.mu.Lock(); defer m.mu.Unlock()
+	if _, ok := m.hooks[w.ID]; !ok { return webhook.ErrNotFound }
+	m.hooks[w.ID] = w
+	return nil
+}
+
+func (m *memWebhooks) Delete(_ context.Context, id string) error {
+	m.mu.Lock(); defer m.mu.Unlock()
+	if _, ok := m.hooks[id]; !ok { return webhook.ErrNotFound }
+	delete(m.hooks, id)
+	return nil
+}
+
+func (m *memWebhooks) All(context.Context) ([]webhook.Webhook, error) {
+	m.mu.Lock(); defer m.mu.Unlock()
+	out := make([]webhook.Webhook, 0, len(m.hooks))
+	for _, w := range m.hooks { out = append(out, w) }
+	return out, nil
+}
+
+func (m *memWebhooks) Enqueue(_ context.Context, d webhook.Delivery) error {
+	m.mu.Lock(); defer m.mu.Unlock()
+	m.deliveries = append(m.deliveries, d)
+	return nil
+}
+
+func (m *memWebhooks) Claim(context.Context, time.Time, time.Duration) (webhook.Delivery, bool, error) {
+	return webhook.Delivery{}, false, nil
+}
+func (m *memWebhooks) Retry(context.Context, string, int, time.Time, string) error { return nil }
+func (m *memWebhooks) Finish(context.Context, string, string, int, string) error  { return nil }
+
+func (m *memWebhooks) enqueued() []webhook.Delivery {
+	m.mu.Lock(); defer m.mu.Unlock()
+	return append([]webhook.Delivery(nil), m.deliveries...)
+}
+
+type webhookEnv struct {
+	ts    *httptest.Server
+	whh   *handlers.WebhookHandler
+	hub   *handlers.Hub
+	store *memWebhooks
+}
+
+func newWebhookEnv(t *testing.T, maxPerKey int) *webhookEnv {
+	t.Helper()
+	hub := handlers.NewHub(&recordingWatcher{watched: make(map[sol.PublicKey]int)})
+	bh := handlers.NewBalanceHandler(handlers.BalanceDeps{
+		Cache: cache.New(10 * time.Second), Fetcher: &fakeFetcher{lamports: 1_000}, Timeout: time.Second, MaxConcurrency: 4, Hub: hub,
+	})
+	store := newMemWebhooks()
+	whh := handlers.NewWebhookHandler(handlers.WebhookDeps{Store: store, Outbox: store, Balances: bh, Hub: hub, MaxPerKey: maxPerKey})
+	ts := httptest.NewServer(apihttp.NewRouter(bh, rate.NewLimiterMap(1000, 1000, time.Minute), fakeStore{ok: true},
+		apihttp.WithRoute("/api/webhooks", whh), apihttp.WithRoute("/api/webhooks/", whh)))
+	t.Cleanup(ts.Close)
+	return &webhookEnv{ts: ts, whh: whh, hub: hub, store: store}
+}
+
+func (e *webhookEnv) do(t *testing.T, method, path, key string, body any) (*http.Response, []byte) {
+	t.Helper()
+	var rd *bytes.Reader
+	if body != nil {
+		b, _ := json.Marshal(body)
+		rd = bytes.NewReader(b)
+	} else {
+		rd = bytes.NewReader(nil)
+	}
+	req, _ := http.NewRequest(method, e.ts.URL+path, rd)
+	req.Header.Set("X-API-Key", key)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil { t.Fatalf("%s %s: %v", method, path, err) }
+	defer resp.Body.Close()
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(resp.Body)
+	return resp, buf.Bytes()
+}
+
+func TestWebhookCRUDScopedToKey(t *testing.T) {
+	env := newWebhookEnv(t, 0)
+	wallet := sol.NewWallet().PublicKey().String()
+	resp, body := env.do(t, http.MethodPost, "/api/webhooks", "key-a", types.WebhookRequest{URL: "https://example.com/hook", Wallets: []string{wallet}})
+	if resp.StatusCode != http.StatusCreated { t.Fatalf("create status=%d body=%s", resp.StatusCode, body) }
+	var created types.WebhookEntry
+	_ = json.Unmarshal(body, &created)
+	if created.ID == "" || created.Secret == "" { t.Fatalf("create should return id and secret: %+v", created) }
+
+	resp, body = env.do(t, http.MethodGet, "/api/webhooks/"+created.ID, "key-a", nil)
+	var got types.WebhookEntry
+	_ = json.Unmarshal(body, &got)
+	if resp.StatusCode != http.StatusOK || got.Secret != "" { t.Fatalf("get status=%d secret=%q", resp.StatusCode, got.Secret) }
+
+	if resp, _ := env.do(t, http.MethodGet, "/api/webhooks/"+created.ID, "key-b", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("other key get status=%d", resp.StatusCode)
+	}
+	if resp, _ := env.do(t, http.MethodDelete, "/api/webhooks/"+created.ID, "key-b", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("other key delete status=%d", resp.StatusCode)
+	}
+	_, body = env.do(t, http.MethodGet, "/api/webhooks", "key-b", nil)
+	var list struct{ Webhooks []types.WebhookEntry `json:"webhooks"` }
+	_ = json.Unmarshal(body, &list)
+	if len(list.Webhooks) != 0 { t.Fatalf("other key lists %d webhooks", len(list.Webhooks)) }
+
+	floor := uint64(500)
+	resp, body = env.do(t, http.MethodPut, "/api/webhooks/"+created.ID, "key-a", types.WebhookRequest{URL: "https://example.com/v2", Wallets: []string{wallet}, FloorLamports: &floor})
+	_ = json.Unmarshal(body, &got)
+	if resp.StatusCode != http.StatusOK || got.URL != "https://example.com/v2" || got.FloorLamports == nil || *got.FloorLamports != 500 {
+		t.Fatalf("update status=%d entry=%+v", resp.StatusCode, got)
+	}
+
+	if resp, _ := env.do(t, http.MethodDelete, "/api/webhooks/"+created.ID, "key-a", nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete status=%d", resp.StatusCode)
+	}
+	if resp, _ := env.do(t, http.MethodGet, "/api/webhooks/"+created.ID, "key-a", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("get after delete status=%d", resp.StatusCode)
+	}
+}
+
+func TestWebhookValidationAndLimit(t *testing.T) {
+	env := newWebhookEnv(t, 1)
+	wallet := sol.NewWallet().PublicKey().String()
+	if resp, _ := env.do(t, http.MethodPost, "/api/webhooks", "k", types.WebhookRequest{URL: "ftp://example.com", Wallets: []string{wallet}}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("bad url status=%d", resp.StatusCode)
+	}
+	if resp, _ := env.do(t, http.MethodPost, "/api/webhooks", "k", types.WebhookRequest{URL: "http://127.0.0.1", Wallets: []string{wallet}}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("loopback url status=%d", resp.StatusCode)
+	}
+	if resp, _ := env.do(t, http.MethodPost, "/api/webhooks", "k", types.WebhookRequest{URL: "https://example.com", Wallets: []string{"bad"}}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("bad wallet status=%d", resp.StatusCode)
+	}
+	if resp, _ := env.do(t, http.MethodPost, "/api/webhooks", "k", types.WebhookRequest{URL: "https://example.com", Wallets: []string{wallet}}); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create status=%d", resp.StatusCode)
+	}
+	if resp, _ := env.do(t, http.MethodPost, "/api/webhooks", "k", types.WebhookRequest{URL: "https://example.com", Wallets: []string{wallet}}); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("over limit status=%d", resp.StatusCode)
+	}
+}
+
+func TestWebhookLimitHoldsUnderConcurrentCreates(t *testing.T) {
+	env := newWebhookEnv(t, 2)
+	wallet := sol.NewWallet().PublicKey().String()
+	b, _ := json.Marshal(types.WebhookRequest{URL: "https://example.com", Wallets: []string{wallet}})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodPost, env.ts.URL+"/api/webhooks", bytes.NewReader(b))
+			req.Header.Set("X-API-Key", "k")
+			if resp, err := http.DefaultClient.Do(req); err == nil { resp.Body.Close() }
+		}()
+	}
+	wg.Wait()
+	if hooks, _ := env.store.All(context.Background()); len(hooks) != 2 { t.Fatalf("webhooks=%d, want 2", len(hooks)) }
+}
+
+func TestWebhookWatcherEnqueuesFiringChanges(t *testing.T) {
+	env := newWebhookEnv(t, 0)
+	wallet := sol.NewWallet().PublicKey().String()
+	if resp, body := env.do(t, http.MethodPost, "/api/webhooks", "k", types.WebhookRequest{URL: "https://example.com", Wallets: []string{wallet}, MinDeltaLamports: 100}); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create status=%d body=%s", resp.StatusCode, body)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go env.whh.Run(ctx)
+	// the baseline of 1000 lamports comes from the fetcher at slot 100
+	time.Sleep(50 * time.Millisecond)
+
+	env.hub.Publish(types.BalanceEntry{Wallet: wallet, Lamports: 1_050, Slot: 101}) // below min delta
+	time.Sleep(50 * time.Millisecond)
+	env.hub.Publish(types.BalanceEntry{Wallet: wallet, Lamports: 1_200, Slot: 102})
+	deadline := time.Now().Add(2 * time.Second)
+	for len(env.store.enqueued()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	got := env.store.enqueued()
+	if len(got) != 1 { t.Fatalf("deliveries=%d", len(got)) }
+	var ev types.WebhookEvent
+	if err := json.Unmarshal(got[0].Payload, &ev); err != nil { t.Fatalf("payload: %v", err) }
+	if ev.Wallet != wallet || ev.Current.Lamports != 1_200 || ev.DeltaLamports != 150 || ev.Type != "balance.changed" {
+		t.Fatalf("event=%+v", ev)
+	}
+}